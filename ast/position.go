@@ -0,0 +1,31 @@
+package ast
+
+import "github.com/thingsme/thingscript/token"
+
+// StatementPosition returns the position where stmt starts.
+// The statements starting with an identifier, like `x := 1` and `x = 2`, are at the identifier.
+func StatementPosition(stmt Statement) token.Position {
+	switch s := stmt.(type) {
+	case *VarStatement:
+		if s.Token.Position.Line == 0 && s.Name != nil {
+			return s.Name.Token.Position
+		}
+		return s.Token.Position
+	case *AssignStatement:
+		return s.Name.Token.Position
+	case *OperAssignStatement:
+		return s.Name.Token.Position
+	case *ReturnStatement:
+		return s.Token.Position
+	case *BreakStatement:
+		return s.Token.Position
+	case *FunctionStatement:
+		return s.Token.Position
+	case *BlockStatement:
+		return s.Token.Position
+	case *ExpressionStatement:
+		return s.Token.Position
+	default:
+		return token.Position{}
+	}
+}
@@ -0,0 +1,36 @@
+package ast_test
+
+import (
+	"testing"
+
+	"github.com/thingsme/thingscript/ast"
+	"github.com/thingsme/thingscript/lexer"
+	"github.com/thingsme/thingscript/parser"
+	"github.com/thingsme/thingscript/token"
+)
+
+func TestStatementPosition(t *testing.T) {
+	input := `x := 1
+var y = 2
+  x = 3
+x += 1
+func f() {
+    return x
+}
+f()`
+	expected := []token.Position{{Line: 1, Column: 1}, {Line: 2, Column: 1}, {Line: 3, Column: 3}, {Line: 4, Column: 1}, {Line: 5, Column: 1}, {Line: 8, Column: 1}}
+
+	program := parser.New(lexer.New(input)).ParseProgram()
+	if len(program.Statements) != len(expected) {
+		t.Fatalf("expected %d statements, got=%d", len(expected), len(program.Statements))
+	}
+	for i, stmt := range program.Statements {
+		if pos := ast.StatementPosition(stmt); pos != expected[i] {
+			t.Errorf("statement %d: expected %s, got=%s", i, expected[i], pos)
+		}
+	}
+	ret := program.Statements[4].(*ast.FunctionStatement).Body.Statements[0]
+	if pos := ast.StatementPosition(ret); pos != (token.Position{Line: 6, Column: 5}) {
+		t.Errorf("return: expected Ln 6, Col 5, got=%s", pos)
+	}
+}
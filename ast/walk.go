@@ -0,0 +1,106 @@
+package ast
+
+import "sort"
+
+// Inspect traverses the tree of node in depth-first order,
+// it calls f(node) and then f for the children of node if f returns true.
+// The pairs of a HashMapLiteral are visited in the order of their keys' String().
+func Inspect(node Node, f func(Node) bool) {
+	if node == nil || !f(node) {
+		return
+	}
+	switch n := node.(type) {
+	case *Program:
+		for _, s := range n.Statements {
+			Inspect(s, f)
+		}
+	case *ReturnStatement:
+		inspectExpression(n.ReturnValue, f)
+	case *VarStatement:
+		Inspect(n.Name, f)
+		inspectExpression(n.Value, f)
+	case *AssignStatement:
+		Inspect(n.Name, f)
+		inspectExpression(n.Value, f)
+	case *OperAssignStatement:
+		Inspect(n.Name, f)
+		inspectExpression(n.Value, f)
+	case *FunctionStatement:
+		Inspect(n.Name, f)
+		for _, p := range n.Parameters {
+			Inspect(p, f)
+		}
+		inspectBlock(n.Body, f)
+	case *BlockStatement:
+		for _, s := range n.Statements {
+			Inspect(s, f)
+		}
+	case *ExpressionStatement:
+		inspectExpression(n.Expression, f)
+	case *PrefixExpression:
+		inspectExpression(n.Right, f)
+	case *InfixExpression:
+		inspectExpression(n.Left, f)
+		inspectExpression(n.Right, f)
+	case *IfExpression:
+		for i, c := range n.Condition {
+			inspectExpression(c, f)
+			if i < len(n.Consequence) {
+				inspectBlock(n.Consequence[i], f)
+			}
+		}
+		inspectBlock(n.Alternative, f)
+	case *ImmediateIfExpression:
+		inspectExpression(n.Left, f)
+		inspectExpression(n.Right, f)
+	case *WhileExpression:
+		inspectExpression(n.Condition, f)
+		inspectBlock(n.Block, f)
+	case *DoWhileExpression:
+		inspectBlock(n.Block, f)
+		inspectExpression(n.Condition, f)
+	case *FunctionLiteral:
+		for _, p := range n.Parameters {
+			Inspect(p, f)
+		}
+		inspectBlock(n.Body, f)
+	case *ArrayLiteral:
+		for _, e := range n.Elements {
+			inspectExpression(e, f)
+		}
+	case *HashMapLiteral:
+		keys := make([]Expression, 0, len(n.Pairs))
+		for k := range n.Pairs {
+			keys = append(keys, k)
+		}
+		sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+		for _, k := range keys {
+			inspectExpression(k, f)
+			inspectExpression(n.Pairs[k], f)
+		}
+	case *CallExpression:
+		inspectExpression(n.Function, f)
+		for _, a := range n.Arguments {
+			inspectExpression(a, f)
+		}
+	case *IndexExpression:
+		inspectExpression(n.Left, f)
+		inspectExpression(n.Index, f)
+	case *AccessExpression:
+		inspectExpression(n.Left, f)
+		inspectExpression(n.Right, f)
+	}
+}
+
+// inspectExpression and inspectBlock skip the nil children, which are typed nil in the interface.
+func inspectExpression(e Expression, f func(Node) bool) {
+	if e != nil {
+		Inspect(e, f)
+	}
+}
+
+func inspectBlock(b *BlockStatement, f func(Node) bool) {
+	if b != nil {
+		Inspect(b, f)
+	}
+}
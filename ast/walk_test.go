@@ -0,0 +1,50 @@
+package ast_test
+
+import (
+	"testing"
+
+	"github.com/thingsme/thingscript/ast"
+	"github.com/thingsme/thingscript/lexer"
+	"github.com/thingsme/thingscript/parser"
+)
+
+func TestInspect(t *testing.T) {
+	input := `x := 1
+	func add(a, b) { return a + b }
+	h := {"k": func(v) { v * 2 }}
+	if x > 0 { add(x, 2) } else { h["k"](x) }
+	while (x < 3) { x += 1 }
+	do { x = -x } while false
+	[1, 2].map(func(e) { e })`
+
+	program := parser.New(lexer.New(input)).ParseProgram()
+	functions := 0
+	identifiers := map[string]int{}
+	ast.Inspect(program, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FunctionStatement, *ast.FunctionLiteral:
+			functions++
+		case *ast.Identifier:
+			identifiers[n.Value]++
+		}
+		return true
+	})
+	if functions != 3 {
+		t.Errorf("expected 3 functions, got=%d", functions)
+	}
+	for name, count := range map[string]int{"x": 8, "a": 2, "v": 2, "e": 2, "add": 2, "h": 2, "map": 1} {
+		if identifiers[name] != count {
+			t.Errorf("expected %d identifiers %q, got=%d", count, name, identifiers[name])
+		}
+	}
+
+	visited := 0
+	ast.Inspect(program, func(n ast.Node) bool {
+		visited++
+		_, ok := n.(*ast.Program)
+		return ok
+	})
+	if visited != len(program.Statements)+1 {
+		t.Errorf("children of skipped nodes are visited, got=%d", visited)
+	}
+}
@@ -0,0 +1,507 @@
+// Package checker finds the problems of scripts without evaluating them,
+// and resolves their identifiers for the editors.
+//
+//	result := checker.Check(source, env)
+//	for _, d := range result.Diagnostics {
+//		fmt.Println(d.Pos, d.Message)
+//	}
+//
+// The environment provides the builtins, the packages and the variables defined by the host.
+// The variables are resolved in the function where they are declared and its outer functions,
+// regardless of the order of the statements, since the closures are called after the outer declarations.
+package checker
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"github.com/thingsme/thingscript/ast"
+	"github.com/thingsme/thingscript/lexer"
+	"github.com/thingsme/thingscript/object"
+	"github.com/thingsme/thingscript/parser"
+	"github.com/thingsme/thingscript/token"
+)
+
+// Kind is the kind of a symbol.
+type Kind int
+
+const (
+	Variable Kind = iota
+	Function
+	Parameter
+	Package
+	Builtin
+	// Predefined is a variable defined in the environment by the host.
+	Predefined
+)
+
+func (k Kind) String() string {
+	switch k {
+	case Variable:
+		return "var"
+	case Function:
+		return "func"
+	case Parameter:
+		return "param"
+	case Package:
+		return "package"
+	case Builtin:
+		return "builtin"
+	case Predefined:
+		return "predefined"
+	default:
+		return "unknown"
+	}
+}
+
+// Symbol is a declared name.
+type Symbol struct {
+	Name string
+	Kind Kind
+	// Pos is the position of the first declaration, it is zero for the builtins and the predefined variables.
+	Pos token.Position
+	// Package is the package of the variables initialized by import("name").
+	Package object.Package
+	// Params are the parameters of the functions.
+	Params []string
+	// Doc describes the builtins.
+	Doc *object.MemberDoc
+
+	// unique is true if the symbol is declared once and never assigned,
+	// so that its kind, package and parameters are reliable.
+	unique bool
+}
+
+// Scope is the scope of a function, or the program for the outermost scope.
+type Scope struct {
+	// Start and End are the positions of the braces of the function body, they are zero for the program.
+	Start, End token.Position
+	Outer      *Scope
+	Symbols    map[string]*Symbol
+}
+
+// Lookup returns the symbol of name in s or its outer scopes.
+func (s *Scope) Lookup(name string) *Symbol {
+	for sc := s; sc != nil; sc = sc.Outer {
+		if sym, ok := sc.Symbols[name]; ok {
+			return sym
+		}
+	}
+	return nil
+}
+
+// Contains returns true if pos is in s.
+func (s *Scope) Contains(pos token.Position) bool {
+	if s.Outer == nil {
+		return true
+	}
+	return !before(pos, s.Start) && (s.End.Line == 0 || !before(s.End, pos))
+}
+
+func before(a, b token.Position) bool {
+	return a.Line < b.Line || a.Line == b.Line && a.Column < b.Column
+}
+
+// Member is a member of a package accessed by the script, like println of fmt.println().
+type Member struct {
+	Package object.Package
+	Doc     *object.MemberDoc
+}
+
+// Diagnostic is a problem of the script.
+type Diagnostic struct {
+	Pos     token.Position
+	Message string
+}
+
+// Result is the result of Check.
+type Result struct {
+	Program     *ast.Program
+	Diagnostics []Diagnostic
+	// Defs are the symbols declared by the identifiers.
+	Defs map[*ast.Identifier]*Symbol
+	// Uses are the symbols referred by the identifiers.
+	Uses map[*ast.Identifier]*Symbol
+	// Members are the members of the packages referred by the identifiers.
+	Members map[*ast.Identifier]*Member
+	// Scopes are the scopes of the program and the functions, the program first.
+	Scopes []*Scope
+}
+
+// ScopeAt returns the innermost scope containing pos.
+func (r *Result) ScopeAt(pos token.Position) *Scope {
+	var ret *Scope
+	for _, s := range r.Scopes {
+		if s.Contains(pos) && (ret == nil || before(ret.Start, s.Start)) {
+			ret = s
+		}
+	}
+	return ret
+}
+
+var errorPosition = regexp.MustCompile(`^\[Ln (\d+), Col (\d+)\] (.*)$`)
+
+// Check parses source and returns the problems and the symbols.
+// The symbols are resolved only if source has no syntax errors.
+func Check(source string, env *object.Environment) *Result {
+	r := &Result{
+		Defs:    make(map[*ast.Identifier]*Symbol),
+		Uses:    make(map[*ast.Identifier]*Symbol),
+		Members: make(map[*ast.Identifier]*Member),
+	}
+	p := parser.New(lexer.New(source))
+	r.Program = p.ParseProgram()
+	if errs := p.Errors(); len(errs) > 0 {
+		for _, e := range errs {
+			d := Diagnostic{Message: e}
+			if m := errorPosition.FindStringSubmatch(e); m != nil {
+				d.Pos.Line, _ = strconv.Atoi(m[1])
+				d.Pos.Column, _ = strconv.Atoi(m[2])
+				d.Message = m[3]
+			}
+			r.Diagnostics = append(r.Diagnostics, d)
+		}
+		return r
+	}
+	c := &checker{r: r, env: env, braces: matchBraces(source)}
+	global := &Scope{Symbols: make(map[string]*Symbol)}
+	r.Scopes = append(r.Scopes, global)
+	c.declare(global, r.Program.Statements)
+	c.statements(global, r.Program.Statements)
+	return r
+}
+
+// matchBraces returns the positions of the closing braces by the positions of the opening ones.
+func matchBraces(source string) map[token.Position]token.Position {
+	braces := make(map[token.Position]token.Position)
+	var open []token.Position
+	l := lexer.New(source)
+	for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+		switch tok.Type {
+		case token.LBRACE:
+			open = append(open, tok.Position)
+		case token.RBRACE:
+			if len(open) > 0 {
+				braces[open[len(open)-1]] = tok.Position
+				open = open[:len(open)-1]
+			}
+		}
+	}
+	return braces
+}
+
+type checker struct {
+	r      *Result
+	env    *object.Environment
+	braces map[token.Position]token.Position
+}
+
+func (c *checker) report(pos token.Position, format string, args ...any) {
+	c.r.Diagnostics = append(c.r.Diagnostics, Diagnostic{Pos: pos, Message: fmt.Sprintf(format, args...)})
+}
+
+// declare declares the names of the statements of a function body in scope,
+// including the blocks of if and while but not the bodies of the nested functions.
+func (c *checker) declare(scope *Scope, stmts []ast.Statement) {
+	for _, stmt := range stmts {
+		ast.Inspect(stmt, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.VarStatement:
+				sym := &Symbol{Name: n.Name.Value, Kind: Variable, Pos: n.Name.Token.Position}
+				switch v := n.Value.(type) {
+				case *ast.FunctionLiteral:
+					sym.Kind = Function
+					sym.Params = paramNames(v.Parameters)
+				case *ast.CallExpression:
+					if name, ok := importName(v); ok {
+						if pkg, ok := c.env.Import(name); ok {
+							sym.Kind = Package
+							sym.Package = pkg
+						}
+					}
+				}
+				c.define(scope, sym)
+			case *ast.FunctionStatement:
+				c.define(scope, &Symbol{Name: n.Name.Value, Kind: Function, Pos: n.Name.Token.Position, Params: paramNames(n.Parameters)})
+				return false
+			case *ast.AssignStatement:
+				c.assigned(scope, n.Name.Value)
+			case *ast.OperAssignStatement:
+				c.assigned(scope, n.Name.Value)
+			case *ast.FunctionLiteral:
+				return false
+			}
+			return true
+		})
+	}
+}
+
+func (c *checker) define(scope *Scope, sym *Symbol) {
+	if prev, ok := scope.Symbols[sym.Name]; ok {
+		prev.unique = false
+		return
+	}
+	sym.unique = true
+	scope.Symbols[sym.Name] = sym
+}
+
+// assigned marks the symbol assigned in scope, it may be declared in the outer scopes which are declared already.
+func (c *checker) assigned(scope *Scope, name string) {
+	if sym := scope.Lookup(name); sym != nil {
+		sym.unique = false
+	}
+}
+
+// resolve returns the symbol of name in scope, the environment or the builtins.
+func (c *checker) resolve(scope *Scope, name string) *Symbol {
+	if sym := scope.Lookup(name); sym != nil {
+		return sym
+	}
+	if _, ok := c.env.Get(name); ok {
+		return &Symbol{Name: name, Kind: Predefined}
+	}
+	if c.env.Builtin(name) != nil {
+		sym := &Symbol{Name: name, Kind: Builtin}
+		if name == "import" {
+			sym.Doc = &object.MemberDoc{Name: name, Signature: "import(name)", Doc: "returns the package of name"}
+		} else if pkg, ok := c.env.Import(""); ok {
+			sym.Doc = memberDoc(pkg, name)
+		}
+		return sym
+	}
+	return nil
+}
+
+func (c *checker) statements(scope *Scope, stmts []ast.Statement) {
+	for _, stmt := range stmts {
+		c.statement(scope, stmt)
+	}
+}
+
+func (c *checker) statement(scope *Scope, stmt ast.Statement) {
+	switch s := stmt.(type) {
+	case *ast.VarStatement:
+		c.r.Defs[s.Name] = scope.Lookup(s.Name.Value)
+		c.expression(scope, s.Value)
+	case *ast.AssignStatement:
+		c.identifier(scope, s.Name)
+		c.expression(scope, s.Value)
+	case *ast.OperAssignStatement:
+		c.identifier(scope, s.Name)
+		c.expression(scope, s.Value)
+	case *ast.FunctionStatement:
+		c.r.Defs[s.Name] = scope.Lookup(s.Name.Value)
+		c.function(scope, s.Parameters, s.Body)
+	case *ast.ReturnStatement:
+		c.expression(scope, s.ReturnValue)
+	case *ast.ExpressionStatement:
+		c.expression(scope, s.Expression)
+	case *ast.BlockStatement:
+		c.block(scope, s)
+	}
+}
+
+func (c *checker) block(scope *Scope, b *ast.BlockStatement) {
+	if b != nil {
+		c.statements(scope, b.Statements)
+	}
+}
+
+func (c *checker) function(outer *Scope, params []*ast.Identifier, body *ast.BlockStatement) {
+	scope := &Scope{Outer: outer, Start: body.Token.Position, End: c.braces[body.Token.Position], Symbols: make(map[string]*Symbol)}
+	c.r.Scopes = append(c.r.Scopes, scope)
+	for _, p := range params {
+		sym := &Symbol{Name: p.Value, Kind: Parameter, Pos: p.Token.Position}
+		c.define(scope, sym)
+		c.r.Defs[p] = sym
+	}
+	c.declare(scope, body.Statements)
+	c.block(scope, body)
+}
+
+func (c *checker) identifier(scope *Scope, id *ast.Identifier) *Symbol {
+	sym := c.resolve(scope, id.Value)
+	if sym == nil {
+		c.report(id.Token.Position, "undefined: %s", id.Value)
+		return nil
+	}
+	c.r.Uses[id] = sym
+	return sym
+}
+
+func (c *checker) expression(scope *Scope, exp ast.Expression) {
+	switch e := exp.(type) {
+	case nil:
+	case *ast.Identifier:
+		c.identifier(scope, e)
+	case *ast.PrefixExpression:
+		c.expression(scope, e.Right)
+	case *ast.InfixExpression:
+		c.expression(scope, e.Left)
+		c.expression(scope, e.Right)
+	case *ast.ImmediateIfExpression:
+		c.expression(scope, e.Left)
+		c.expression(scope, e.Right)
+	case *ast.IfExpression:
+		for i, cond := range e.Condition {
+			c.expression(scope, cond)
+			if i < len(e.Consequence) {
+				c.block(scope, e.Consequence[i])
+			}
+		}
+		c.block(scope, e.Alternative)
+	case *ast.WhileExpression:
+		c.expression(scope, e.Condition)
+		c.block(scope, e.Block)
+	case *ast.DoWhileExpression:
+		c.block(scope, e.Block)
+		c.expression(scope, e.Condition)
+	case *ast.FunctionLiteral:
+		c.function(scope, e.Parameters, e.Body)
+	case *ast.ArrayLiteral:
+		for _, el := range e.Elements {
+			c.expression(scope, el)
+		}
+	case *ast.HashMapLiteral:
+		for k, v := range e.Pairs {
+			c.expression(scope, k)
+			c.expression(scope, v)
+		}
+	case *ast.IndexExpression:
+		c.expression(scope, e.Left)
+		c.expression(scope, e.Index)
+	case *ast.CallExpression:
+		c.call(scope, e)
+	case *ast.AccessExpression:
+		c.access(scope, e)
+	}
+}
+
+func (c *checker) call(scope *Scope, e *ast.CallExpression) {
+	c.expression(scope, e.Function)
+	for _, a := range e.Arguments {
+		c.expression(scope, a)
+	}
+	if name, ok := importName(e); ok {
+		if _, ok := c.env.Import(name); !ok {
+			c.report(e.Arguments[0].(*ast.StringLiteral).Token.Position, "package %q not found", name)
+		}
+		return
+	}
+	id, ok := e.Function.(*ast.Identifier)
+	if !ok {
+		return
+	}
+	sym := c.r.Uses[id]
+	switch {
+	case sym == nil:
+	case sym.Kind == Function && sym.unique && len(sym.Params) != len(e.Arguments):
+		c.report(id.Token.Position, "wrong number of arguments to %s. want=%d got=%d", id.Value, len(sym.Params), len(e.Arguments))
+	case sym.Kind == Builtin && sym.Doc != nil:
+		c.arity(id, id.Value, sym.Doc, len(e.Arguments))
+	}
+}
+
+// arity reports the call of the described member with the wrong number of arguments.
+func (c *checker) arity(id *ast.Identifier, name string, doc *object.MemberDoc, got int) {
+	min, max := doc.Arity()
+	if min < 0 || got >= min && (max < 0 || got <= max) {
+		return
+	}
+	var want string
+	switch {
+	case max < 0:
+		want = fmt.Sprintf(" >= %d", min)
+	case min == max:
+		want = fmt.Sprintf("=%d", min)
+	case min+1 == max:
+		want = fmt.Sprintf("=%d or %d", min, max)
+	default:
+		want = fmt.Sprintf("=%d to %d", min, max)
+	}
+	c.report(id.Token.Position, "wrong number of arguments to %s. want%s got=%d", name, want, got)
+}
+
+func (c *checker) access(scope *Scope, e *ast.AccessExpression) {
+	c.expression(scope, e.Left)
+	var name *ast.Identifier
+	var call *ast.CallExpression
+	switch r := e.Right.(type) {
+	case *ast.Identifier:
+		name = r
+	case *ast.CallExpression:
+		name, _ = r.Function.(*ast.Identifier)
+		call = r
+		for _, a := range r.Arguments {
+			c.expression(scope, a)
+		}
+	}
+	if name == nil {
+		return
+	}
+	pkg := c.packageOf(e.Left)
+	if pkg == nil {
+		return
+	}
+	if pkg.Member(name.Value) == nil {
+		c.report(name.Token.Position, "%q is not a member of package %q", name.Value, pkg.Name())
+		return
+	}
+	doc := memberDoc(pkg, name.Value)
+	c.r.Members[name] = &Member{Package: pkg, Doc: doc}
+	if call != nil && doc != nil {
+		c.arity(name, pkg.Name()+"."+name.Value, doc, len(call.Arguments))
+	}
+}
+
+// packageOf returns the package of exp if exp is import("name") or a variable initialized by it.
+func (c *checker) packageOf(exp ast.Expression) object.Package {
+	switch e := exp.(type) {
+	case *ast.Identifier:
+		if sym := c.r.Uses[e]; sym != nil && sym.Kind == Package && sym.unique {
+			return sym.Package
+		}
+	case *ast.CallExpression:
+		if name, ok := importName(e); ok {
+			pkg, _ := c.env.Import(name)
+			return pkg
+		}
+	}
+	return nil
+}
+
+// importName returns the name of the package if call is import("name").
+func importName(call *ast.CallExpression) (string, bool) {
+	fn, ok := call.Function.(*ast.Identifier)
+	if !ok || fn.Value != "import" || len(call.Arguments) != 1 {
+		return "", false
+	}
+	s, ok := call.Arguments[0].(*ast.StringLiteral)
+	if !ok {
+		return "", false
+	}
+	return s.Value, true
+}
+
+func paramNames(params []*ast.Identifier) []string {
+	names := make([]string, len(params))
+	for i, p := range params {
+		names[i] = p.Value
+	}
+	return names
+}
+
+// memberDoc returns the description of the member of pkg, or nil if pkg does not describe it.
+func memberDoc(pkg object.Package, name string) *object.MemberDoc {
+	d, ok := pkg.(object.Describer)
+	if !ok {
+		return nil
+	}
+	for _, m := range d.Members() {
+		if m.Name == name {
+			return &m
+		}
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package checker_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/thingsme/thingscript/ast"
+	"github.com/thingsme/thingscript/checker"
+	"github.com/thingsme/thingscript/object"
+	"github.com/thingsme/thingscript/stdlib"
+	"github.com/thingsme/thingscript/token"
+)
+
+func newEnv() *object.Environment {
+	env := object.NewEnvironment()
+	env.RegisterPackages(stdlib.Packages()...)
+	return env
+}
+
+func TestCheckDiagnostics(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{`x := 1; x + 1`, nil},
+		{`y + 1`, []string{"Ln 1, Col 1: undefined: y"}},
+		{`y = 1`, []string{"Ln 1, Col 1: undefined: y"}},
+		{`func f() { return g() }
+func g() { return 1 }
+f()`, nil},
+		{`func f(a, b) { return a + b }
+f(1)`, []string{"Ln 2, Col 1: wrong number of arguments to f. want=2 got=1"}},
+		{`f := func(a) { return a }
+f = func() { return 1 }
+f()`, nil},
+		{`func f(a) { return b }`, []string{"Ln 1, Col 20: undefined: b"}},
+		{`out := import("fmt")
+out.println("a")
+out.printx("a")`, []string{`Ln 3, Col 5: "printx" is not a member of package "fmt"`}},
+		{`import("fmt").println(1)`, nil},
+		{`import("fmt").printf()`, []string{"Ln 1, Col 15: wrong number of arguments to fmt.printf. want >= 1 got=0"}},
+		{`kv := import("kv")
+kv.get()
+kv.set("a", 1, 2, 3)
+kv.keys()`, []string{"Ln 2, Col 4: wrong number of arguments to kv.get. want=1 or 2 got=0", "Ln 3, Col 4: wrong number of arguments to kv.set. want=2 or 3 got=4"}},
+		{`help(1, "type", 3)`, []string{"Ln 1, Col 1: wrong number of arguments to help. want=1 or 2 got=3"}},
+		{`import("nothing")`, []string{`Ln 1, Col 8: package "nothing" not found`}},
+		{`int("1") + n`, []string{"Ln 1, Col 12: undefined: n"}},
+		{`if (true) { z := 1 } else { z = 2 }
+z`, nil},
+		{`x := {"a": v}`, []string{"Ln 1, Col 12: undefined: v"}},
+		{`x := 1 +`, []string{`Ln 1, Col 11: no prefix parse function for "EOF" found`}},
+	}
+
+	for _, tt := range tests {
+		r := checker.Check(tt.input, newEnv())
+		var got []string
+		for _, d := range r.Diagnostics {
+			got = append(got, fmt.Sprintf("%s: %s", d.Pos, d.Message))
+		}
+		if fmt.Sprint(got) != fmt.Sprint(tt.expected) {
+			t.Errorf("expected %q, got=%q <= %s", tt.expected, got, tt.input)
+		}
+	}
+}
+
+func TestCheckPredefined(t *testing.T) {
+	env := newEnv()
+	env.Set("config", &object.String{Value: "x"})
+	r := checker.Check(`config`, env)
+	if len(r.Diagnostics) != 0 {
+		t.Fatalf("unexpected diagnostics %v", r.Diagnostics)
+	}
+	id := r.Program.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.Identifier)
+	if sym := r.Uses[id]; sym == nil || sym.Kind != checker.Predefined {
+		t.Errorf("expected predefined, got=%v", sym)
+	}
+}
+
+func TestCheckSymbols(t *testing.T) {
+	input := `out := import("fmt")
+func add(a, b) {
+    sum := a + b
+    return sum
+}
+out.println(add(1, 2))`
+	r := checker.Check(input, newEnv())
+	if len(r.Diagnostics) != 0 {
+		t.Fatalf("unexpected diagnostics %v", r.Diagnostics)
+	}
+
+	uses := make(map[string]*checker.Symbol)
+	for id, sym := range r.Uses {
+		uses[fmt.Sprintf("%s %s", id.Value, id.Token.Position)] = sym
+	}
+	tests := []struct {
+		use  string
+		kind checker.Kind
+		pos  token.Position
+	}{
+		{"a Ln 3, Col 12", checker.Parameter, token.Position{Line: 2, Column: 10}},
+		{"sum Ln 4, Col 12", checker.Variable, token.Position{Line: 3, Column: 5}},
+		{"out Ln 6, Col 1", checker.Package, token.Position{Line: 1, Column: 1}},
+		{"add Ln 6, Col 13", checker.Function, token.Position{Line: 2, Column: 6}},
+	}
+	for _, tt := range tests {
+		sym := uses[tt.use]
+		if sym == nil {
+			t.Errorf("%s is not resolved", tt.use)
+			continue
+		}
+		if sym.Kind != tt.kind || sym.Pos != tt.pos {
+			t.Errorf("%s: expected %s at %s, got=%s at %s", tt.use, tt.kind, tt.pos, sym.Kind, sym.Pos)
+		}
+	}
+
+	var member *checker.Member
+	for id, m := range r.Members {
+		if id.Value == "println" {
+			member = m
+		}
+	}
+	if member == nil || member.Doc == nil || member.Doc.Signature == "" {
+		t.Errorf("expected the description of println, got=%v", member)
+	}
+
+	scope := r.ScopeAt(token.Position{Line: 3, Column: 5})
+	if scope.Lookup("sum") == nil || scope.Lookup("out") == nil {
+		t.Errorf("expected sum and out in the scope of add")
+	}
+	if r.ScopeAt(token.Position{Line: 6, Column: 1}).Lookup("sum") != nil {
+		t.Errorf("unexpected sum in the global scope")
+	}
+}
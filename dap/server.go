@@ -0,0 +1,575 @@
+// Package dap implements the Debug Adapter Protocol for the scripts, so that editors can debug them.
+//
+//	s := dap.NewServer(os.Stdin, os.Stdout)
+//	s.NewEnvironment = func(path string, args []string, stdout io.Writer) *object.Environment { ... }
+//	err := s.Serve()
+//
+// The server debugs a single script launched by the "launch" request, it has a single thread
+// and the scopes of a frame are the locals, the closures and the globals of its environment chain.
+package dap
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/thingsme/thingscript/ast"
+	"github.com/thingsme/thingscript/debugger"
+	"github.com/thingsme/thingscript/eval"
+	"github.com/thingsme/thingscript/internal/framing"
+	"github.com/thingsme/thingscript/lexer"
+	"github.com/thingsme/thingscript/object"
+	"github.com/thingsme/thingscript/parser"
+)
+
+// threadID is the only thread of the script.
+const threadID = 1
+
+// Server is the debug adapter serving a debug session.
+type Server struct {
+	// NewEnvironment returns the environment evaluating the script at path with args.
+	// Its Stdout must be stdout, which sends the output events, before the packages are registered.
+	// The server sets its Context. It defaults to object.NewEnvironment.
+	NewEnvironment func(path string, args []string, stdout io.Writer) *object.Environment
+
+	in  *bufio.Reader
+	out io.Writer
+	wmu sync.Mutex
+	seq int
+
+	debugger *debugger.Debugger
+	resume   chan debugger.Action
+	done     chan struct{}
+
+	mu          sync.Mutex
+	path        string
+	program     *ast.Program
+	env         *object.Environment
+	cancel      context.CancelFunc
+	stopOnEntry bool
+	configured  bool
+	running     bool
+	stop        *debugger.Stop
+	stops       int
+	refs        map[int]any
+	// breakpoints are the lines of the breakpoints by the absolute path of the source,
+	// the ones of the launched script are set to the debugger.
+	breakpoints map[string][]int
+}
+
+// NewServer returns the server reading the requests from in and writing the responses and the events to out.
+func NewServer(in io.Reader, out io.Writer) *Server {
+	s := &Server{
+		in:          bufio.NewReader(in),
+		out:         out,
+		resume:      make(chan debugger.Action),
+		done:        make(chan struct{}),
+		breakpoints: make(map[string][]int),
+	}
+	s.debugger = debugger.New(s.stopped)
+	return s
+}
+
+type request struct {
+	Seq       int             `json:"seq"`
+	Type      string          `json:"type"`
+	Command   string          `json:"command"`
+	Arguments json.RawMessage `json:"arguments"`
+}
+
+type response struct {
+	Seq        int    `json:"seq"`
+	Type       string `json:"type"`
+	RequestSeq int    `json:"request_seq"`
+	Success    bool   `json:"success"`
+	Command    string `json:"command"`
+	Message    string `json:"message,omitempty"`
+	Body       any    `json:"body,omitempty"`
+}
+
+type event struct {
+	Seq   int    `json:"seq"`
+	Type  string `json:"type"`
+	Event string `json:"event"`
+	Body  any    `json:"body,omitempty"`
+}
+
+// Serve serves the requests until the "disconnect" request or the end of the input.
+func (s *Server) Serve() error {
+	defer s.terminate()
+	for {
+		content, err := framing.Read(s.in)
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		var req request
+		if err := json.Unmarshal(content, &req); err != nil {
+			return fmt.Errorf("invalid message: %w", err)
+		}
+		if req.Type != "request" {
+			continue
+		}
+		body, err := s.handle(&req)
+		res := &response{Type: "response", RequestSeq: req.Seq, Command: req.Command, Success: err == nil, Body: body}
+		if err != nil {
+			res.Message = err.Error()
+		}
+		if err := s.send(res); err != nil {
+			return err
+		}
+		if err == nil {
+			if err := s.after(&req); err != nil {
+				return err
+			}
+		}
+		if req.Command == "disconnect" {
+			return nil
+		}
+	}
+}
+
+// handle returns the body of the response to req.
+func (s *Server) handle(req *request) (any, error) {
+	switch req.Command {
+	case "initialize":
+		return map[string]any{
+			"supportsConfigurationDoneRequest": true,
+			"supportsTerminateRequest":         true,
+		}, nil
+	case "launch":
+		var args struct {
+			Program     string   `json:"program"`
+			Args        []string `json:"args"`
+			StopOnEntry bool     `json:"stopOnEntry"`
+		}
+		if err := unmarshalArgs(req, &args); err != nil {
+			return nil, err
+		}
+		return nil, s.launch(args.Program, args.Args, args.StopOnEntry)
+	case "setBreakpoints":
+		var args struct {
+			Source struct {
+				Path string `json:"path"`
+			} `json:"source"`
+			Breakpoints []struct {
+				Line int `json:"line"`
+			} `json:"breakpoints"`
+		}
+		if err := unmarshalArgs(req, &args); err != nil {
+			return nil, err
+		}
+		lines := []int{}
+		for _, bp := range args.Breakpoints {
+			lines = append(lines, bp.Line)
+		}
+		s.mu.Lock()
+		s.breakpoints[absPath(args.Source.Path)] = lines
+		// the breakpoints before launch are kept until the script is known
+		verified := s.path != "" && sameFile(s.path, args.Source.Path)
+		s.mu.Unlock()
+		if verified {
+			s.debugger.SetBreakpoints(lines)
+		}
+		breakpoints := []map[string]any{}
+		for _, line := range lines {
+			breakpoints = append(breakpoints, map[string]any{"verified": verified, "line": line})
+		}
+		return map[string]any{"breakpoints": breakpoints}, nil
+	case "setExceptionBreakpoints":
+		return map[string]any{"breakpoints": []any{}}, nil
+	case "configurationDone":
+		s.mu.Lock()
+		s.configured = true
+		s.mu.Unlock()
+		return nil, nil
+	case "threads":
+		return map[string]any{"threads": []map[string]any{{"id": threadID, "name": "main"}}}, nil
+	case "stackTrace":
+		stop, err := s.currentStop()
+		if err != nil {
+			return nil, err
+		}
+		frames := []map[string]any{}
+		for i, f := range stop.Frames {
+			frames = append(frames, map[string]any{
+				"id":     i + 1,
+				"name":   f.Name,
+				"line":   f.Pos.Line,
+				"column": f.Pos.Column,
+				"source": s.source(),
+			})
+		}
+		return map[string]any{"stackFrames": frames, "totalFrames": len(frames)}, nil
+	case "scopes":
+		var args struct {
+			FrameID int `json:"frameId"`
+		}
+		if err := unmarshalArgs(req, &args); err != nil {
+			return nil, err
+		}
+		frame, err := s.frame(args.FrameID)
+		if err != nil {
+			return nil, err
+		}
+		scopes := []map[string]any{}
+		s.mu.Lock()
+		for _, scope := range debugger.Scopes(frame.Env) {
+			scopes = append(scopes, map[string]any{
+				"name":               scope.Name,
+				"presentationHint":   scope.Name,
+				"variablesReference": s.ref(scope.Env),
+				"expensive":          false,
+			})
+		}
+		s.mu.Unlock()
+		return map[string]any{"scopes": scopes}, nil
+	case "variables":
+		var args struct {
+			VariablesReference int `json:"variablesReference"`
+		}
+		if err := unmarshalArgs(req, &args); err != nil {
+			return nil, err
+		}
+		return s.variables(args.VariablesReference)
+	case "evaluate":
+		var args struct {
+			Expression string `json:"expression"`
+			FrameID    int    `json:"frameId"`
+		}
+		if err := unmarshalArgs(req, &args); err != nil {
+			return nil, err
+		}
+		frame, err := s.frame(args.FrameID)
+		if err != nil {
+			return nil, err
+		}
+		name := strings.TrimSpace(args.Expression)
+		val, ok := frame.Env.Get(name)
+		if !ok {
+			return nil, fmt.Errorf("identifier not found: %s", name)
+		}
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return map[string]any{"result": debugger.Inspect(val), "variablesReference": s.ref(val)}, nil
+	case "continue", "next", "stepIn", "stepOut":
+		if err := s.takeStop(); err != nil {
+			return nil, err
+		}
+		if req.Command == "continue" {
+			return map[string]any{"allThreadsContinued": true}, nil
+		}
+		return nil, nil
+	case "pause":
+		s.debugger.Pause()
+		return nil, nil
+	case "disconnect", "terminate":
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unsupported command %q", req.Command)
+	}
+}
+
+// after takes the actions which follow the response to req.
+func (s *Server) after(req *request) error {
+	switch req.Command {
+	case "initialize":
+		return s.send(&event{Type: "event", Event: "initialized"})
+	case "launch", "configurationDone":
+		s.start()
+	case "continue":
+		s.resume <- debugger.Continue
+	case "next":
+		s.resume <- debugger.StepOver
+	case "stepIn":
+		s.resume <- debugger.StepIn
+	case "stepOut":
+		s.resume <- debugger.StepOut
+	case "terminate":
+		s.terminate()
+	}
+	return nil
+}
+
+func unmarshalArgs(req *request, v any) error {
+	if len(req.Arguments) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(req.Arguments, v); err != nil {
+		return fmt.Errorf("invalid arguments of %s: %w", req.Command, err)
+	}
+	return nil
+}
+
+func (s *Server) launch(path string, args []string, stopOnEntry bool) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	p := parser.New(lexer.New(string(b)))
+	program := p.ParseProgram()
+	if len(p.Errors()) > 0 {
+		return errors.New(strings.Join(p.Errors(), "\n"))
+	}
+	stdout := &output{s: s, category: "stdout"}
+	var env *object.Environment
+	if s.NewEnvironment != nil {
+		env = s.NewEnvironment(path, args, stdout)
+	} else {
+		env = object.NewEnvironment()
+		env.Stdout = stdout
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	env.Context = s.debugger.Context(ctx)
+	if stopOnEntry {
+		s.debugger.Pause()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.program != nil {
+		cancel()
+		return errors.New("script is already launched")
+	}
+	s.path, s.program, s.env, s.cancel, s.stopOnEntry = path, program, env, cancel, stopOnEntry
+	s.debugger.SetBreakpoints(s.breakpoints[absPath(path)])
+	return nil
+}
+
+// start starts the evaluation once the script is launched and the configuration is done.
+func (s *Server) start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.program == nil || !s.configured || s.running {
+		return
+	}
+	s.running = true
+	go func() {
+		defer close(s.done)
+		ret := eval.Eval(s.program, s.env)
+		exitCode := s.env.ExitCode()
+		if err, ok := ret.(*object.Error); ok {
+			if s.env.Ctx().Err() == nil {
+				s.send(&event{Type: "event", Event: "output", Body: map[string]any{"category": "stderr", "output": err.Message + "\n"}})
+			}
+			if exitCode == 0 {
+				exitCode = 1
+			}
+		}
+		s.send(&event{Type: "event", Event: "exited", Body: map[string]any{"exitCode": exitCode}})
+		s.send(&event{Type: "event", Event: "terminated"})
+	}()
+}
+
+// terminate stops the evaluation and waits for it.
+func (s *Server) terminate() {
+	s.mu.Lock()
+	running, cancel := s.running, s.cancel
+	s.mu.Unlock()
+	if !running {
+		return
+	}
+	// canceled before reading the stop, so that the evaluation stopping later quits by itself
+	cancel()
+	s.mu.Lock()
+	stop := s.stop
+	s.mu.Unlock()
+	if stop != nil {
+		select {
+		case s.resume <- debugger.Quit:
+		case <-s.done:
+		}
+	}
+	<-s.done
+}
+
+// stopped is called by the debugger, it blocks the evaluation until the client resumes it.
+func (s *Server) stopped(stop *debugger.Stop) debugger.Action {
+	s.mu.Lock()
+	if s.env.Ctx().Err() != nil {
+		s.mu.Unlock()
+		return debugger.Quit
+	}
+	reason := stop.Reason
+	if s.stops == 0 && s.stopOnEntry {
+		reason = "entry"
+	}
+	s.stops++
+	s.stop = stop
+	s.refs = make(map[int]any)
+	s.mu.Unlock()
+
+	s.send(&event{Type: "event", Event: "stopped", Body: map[string]any{
+		"reason":            reason,
+		"threadId":          threadID,
+		"allThreadsStopped": true,
+	}})
+	action := <-s.resume
+
+	s.mu.Lock()
+	s.stop = nil
+	s.refs = nil
+	s.mu.Unlock()
+	return action
+}
+
+// currentStop returns the current stop, or an error if the evaluation is running.
+func (s *Server) currentStop() (*debugger.Stop, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stop == nil {
+		return nil, errors.New("script is not stopped")
+	}
+	return s.stop, nil
+}
+
+// takeStop clears the current stop of the evaluation which is resumed after the response,
+// so that the requests before the next stop see it running. It returns an error if the evaluation is running.
+func (s *Server) takeStop() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stop == nil {
+		return errors.New("script is not stopped")
+	}
+	s.stop = nil
+	s.refs = nil
+	return nil
+}
+
+func (s *Server) frame(id int) (debugger.Frame, error) {
+	stop, err := s.currentStop()
+	if err != nil {
+		return debugger.Frame{}, err
+	}
+	if id == 0 {
+		id = 1
+	}
+	if id < 1 || id > len(stop.Frames) {
+		return debugger.Frame{}, fmt.Errorf("invalid frame %d", id)
+	}
+	return stop.Frames[id-1], nil
+}
+
+// ref returns the variables reference of v, which is an environment or an array or a map.
+// It returns 0 for the other values which have no children.
+// The references are valid until the evaluation is resumed, s.mu must be held.
+func (s *Server) ref(v any) int {
+	switch v.(type) {
+	case *object.Environment, *object.Array, *object.HashMap:
+	default:
+		return 0
+	}
+	if s.refs == nil {
+		return 0
+	}
+	for ref, r := range s.refs {
+		if r == v {
+			return ref
+		}
+	}
+	ref := len(s.refs) + 1
+	s.refs[ref] = v
+	return ref
+}
+
+func (s *Server) variables(ref int) (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	target, ok := s.refs[ref]
+	if !ok {
+		return nil, fmt.Errorf("invalid variables reference %d", ref)
+	}
+	vars := []map[string]any{}
+	add := func(name string, val object.Object) {
+		typ := "NULL"
+		if val != nil {
+			typ = string(val.Type())
+		}
+		vars = append(vars, map[string]any{
+			"name":               name,
+			"value":              debugger.Inspect(val),
+			"type":               typ,
+			"variablesReference": s.ref(val),
+		})
+	}
+	switch target := target.(type) {
+	case *object.Environment:
+		for _, v := range debugger.Variables(target) {
+			add(v.Name, v.Value)
+		}
+	case *object.Array:
+		for i, e := range target.Elements {
+			add(fmt.Sprintf("[%d]", i), e)
+		}
+	case *object.HashMap:
+		pairs := target.OrderedPairs()
+		if target.Keys == nil {
+			sort.Slice(pairs, func(i, j int) bool { return pairs[i].Key.Inspect() < pairs[j].Key.Inspect() })
+		}
+		for _, p := range pairs {
+			add(p.Key.Inspect(), p.Value)
+		}
+	}
+	return map[string]any{"variables": vars}, nil
+}
+
+func (s *Server) source() map[string]any {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return map[string]any{"name": filepath.Base(s.path), "path": s.path}
+}
+
+// absPath returns the absolute path of p, or p if it fails.
+func absPath(p string) string {
+	if a, err := filepath.Abs(p); err == nil {
+		return a
+	}
+	return p
+}
+
+func sameFile(a, b string) bool {
+	a, errA := filepath.Abs(a)
+	b, errB := filepath.Abs(b)
+	return errA == nil && errB == nil && a == b
+}
+
+func (s *Server) send(msg any) error {
+	s.wmu.Lock()
+	defer s.wmu.Unlock()
+	s.seq++
+	switch msg := msg.(type) {
+	case *response:
+		msg.Seq = s.seq
+	case *event:
+		msg.Seq = s.seq
+	}
+	content, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	return framing.Write(s.out, content)
+}
+
+// output sends what the script writes as the output events.
+type output struct {
+	s        *Server
+	category string
+}
+
+func (o *output) Write(p []byte) (int, error) {
+	err := o.s.send(&event{Type: "event", Event: "output", Body: map[string]any{"category": o.category, "output": string(p)}})
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
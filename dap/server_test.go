@@ -0,0 +1,328 @@
+package dap_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/thingsme/thingscript/dap"
+	"github.com/thingsme/thingscript/internal/framing"
+	"github.com/thingsme/thingscript/object"
+	"github.com/thingsme/thingscript/stdlib"
+)
+
+const script = `fmt := import("fmt")
+func add(a, b) {
+    c := a + b
+    return c
+}
+x := [1, 2]
+y := add(x[0], x[1])
+fmt.println("y =", y)
+`
+
+type message struct {
+	Seq        int             `json:"seq"`
+	Type       string          `json:"type"`
+	Command    string          `json:"command"`
+	Event      string          `json:"event"`
+	RequestSeq int             `json:"request_seq"`
+	Success    bool            `json:"success"`
+	Message    string          `json:"message"`
+	Body       json.RawMessage `json:"body"`
+}
+
+type client struct {
+	t        *testing.T
+	w        io.Writer
+	messages chan message
+	seq      int
+	// events are the events received while waiting for the responses
+	events []message
+}
+
+func (c *client) request(command string, args any) message {
+	c.t.Helper()
+	c.seq++
+	b, _ := json.Marshal(map[string]any{"seq": c.seq, "type": "request", "command": command, "arguments": args})
+	if err := framing.Write(c.w, b); err != nil {
+		c.t.Fatal(err)
+	}
+	for {
+		msg := c.read()
+		if msg.Type == "response" && msg.RequestSeq == c.seq {
+			return msg
+		}
+		c.events = append(c.events, msg)
+	}
+}
+
+// event returns the next event named name, skipping the others.
+func (c *client) event(name string) message {
+	c.t.Helper()
+	for i, e := range c.events {
+		if e.Event == name {
+			c.events = append(c.events[:i:i], c.events[i+1:]...)
+			return e
+		}
+	}
+	for {
+		msg := c.read()
+		if msg.Type == "event" && msg.Event == name {
+			return msg
+		}
+		c.events = append(c.events, msg)
+	}
+}
+
+func (c *client) read() message {
+	c.t.Helper()
+	select {
+	case msg, ok := <-c.messages:
+		if !ok {
+			c.t.Fatal("connection closed")
+		}
+		return msg
+	case <-time.After(5 * time.Second):
+		c.t.Fatal("timeout")
+		return message{}
+	}
+}
+
+// receive reads the messages from r until it is closed, so that the server never blocks on writing.
+func (c *client) receive(r io.Reader) {
+	defer close(c.messages)
+	br := bufio.NewReader(r)
+	for {
+		b, err := framing.Read(br)
+		if err != nil {
+			return
+		}
+		var msg message
+		if err := json.Unmarshal(b, &msg); err == nil {
+			c.messages <- msg
+		}
+	}
+}
+
+func body(t *testing.T, msg message) map[string]any {
+	t.Helper()
+	if !msg.Success {
+		t.Fatalf("%s failed: %s", msg.Command, msg.Message)
+	}
+	var b map[string]any
+	json.Unmarshal(msg.Body, &b)
+	return b
+}
+
+func start(t *testing.T) (*client, chan error) {
+	inR, inW := io.Pipe()
+	outR, outW := io.Pipe()
+	server := dap.NewServer(inR, outW)
+	server.NewEnvironment = func(path string, args []string, stdout io.Writer) *object.Environment {
+		env := object.NewEnvironment()
+		env.Stdout = stdout
+		env.RegisterPackages(stdlib.Packages()...)
+		return env
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- server.Serve()
+		outW.Close()
+	}()
+	c := &client{t: t, w: inW, messages: make(chan message, 100)}
+	go c.receive(outR)
+	t.Cleanup(func() { inW.Close() })
+
+	body(t, c.request("initialize", map[string]any{"adapterID": "thingscript"}))
+	c.event("initialized")
+	return c, done
+}
+
+func TestSession(t *testing.T) {
+	c, done := start(t)
+	res := c.request("launch", map[string]any{"program": "missing.txs"})
+	if res.Success {
+		t.Fatal("expected launch of missing file to fail")
+	}
+	path := filepath.Join(t.TempDir(), "add.txs")
+	os.WriteFile(path, []byte(script), 0644)
+	body(t, c.request("launch", map[string]any{"program": path}))
+	bps := body(t, c.request("setBreakpoints", map[string]any{
+		"source":      map[string]any{"path": path},
+		"breakpoints": []map[string]any{{"line": 3}},
+	}))
+	if !strings.Contains(mustJSON(bps), `"verified":true`) {
+		t.Errorf("expected verified breakpoints, got=%s", mustJSON(bps))
+	}
+	body(t, c.request("configurationDone", nil))
+
+	stopped := c.event("stopped")
+	if !strings.Contains(string(stopped.Body), `"reason":"breakpoint"`) {
+		t.Errorf("expected breakpoint stop, got=%s", stopped.Body)
+	}
+	trace := mustJSON(body(t, c.request("stackTrace", map[string]any{"threadId": 1})))
+	for _, expected := range []string{`"name":"add"`, `"line":3`, `"name":"main"`, `"line":7`} {
+		if !strings.Contains(trace, expected) {
+			t.Errorf("expected %s in stack trace %s", expected, trace)
+		}
+	}
+
+	scopes := body(t, c.request("scopes", map[string]any{"frameId": 1}))["scopes"].([]any)
+	if len(scopes) != 2 {
+		t.Fatalf("expected locals and globals, got=%v", scopes)
+	}
+	locals := scopes[0].(map[string]any)
+	globals := scopes[1].(map[string]any)
+	if locals["name"] != "locals" || globals["name"] != "globals" {
+		t.Errorf("unexpected scopes %v", scopes)
+	}
+	vars := mustJSON(body(t, c.request("variables", map[string]any{"variablesReference": locals["variablesReference"]})))
+	if !strings.Contains(vars, `"name":"a","type":"INTEGER","value":"1"`) || !strings.Contains(vars, `"name":"b"`) {
+		t.Errorf("unexpected locals %s", vars)
+	}
+	gvars := body(t, c.request("variables", map[string]any{"variablesReference": globals["variablesReference"]}))["variables"].([]any)
+	var xRef any
+	for _, v := range gvars {
+		if v := v.(map[string]any); v["name"] == "x" {
+			xRef = v["variablesReference"]
+		}
+	}
+	if xRef == nil || xRef.(float64) == 0 {
+		t.Fatalf("expected x to be expandable, got=%v", gvars)
+	}
+	elements := mustJSON(body(t, c.request("variables", map[string]any{"variablesReference": xRef})))
+	if !strings.Contains(elements, `"name":"[1]","type":"INTEGER","value":"2"`) {
+		t.Errorf("unexpected elements %s", elements)
+	}
+	eval := body(t, c.request("evaluate", map[string]any{"expression": "b", "frameId": 1}))
+	if eval["result"] != "2" {
+		t.Errorf("expected b = 2, got=%v", eval)
+	}
+
+	body(t, c.request("stepOut", map[string]any{"threadId": 1}))
+	c.event("stopped")
+	trace = mustJSON(body(t, c.request("stackTrace", map[string]any{"threadId": 1})))
+	if !strings.Contains(trace, `"line":8`) || strings.Contains(trace, `"name":"add"`) {
+		t.Errorf("expected to stop at line 8 of main, got=%s", trace)
+	}
+
+	body(t, c.request("continue", map[string]any{"threadId": 1}))
+	output := c.event("output")
+	if !strings.Contains(string(output.Body), `"output":"y = 3\n"`) {
+		t.Errorf("unexpected output %s", output.Body)
+	}
+	exited := c.event("exited")
+	if !strings.Contains(string(exited.Body), `"exitCode":0`) {
+		t.Errorf("unexpected exit %s", exited.Body)
+	}
+	c.event("terminated")
+	body(t, c.request("disconnect", nil))
+	if err := <-done; err != nil {
+		t.Error(err)
+	}
+}
+
+func TestDisconnectWhileStopped(t *testing.T) {
+	c, done := start(t)
+	path := filepath.Join(t.TempDir(), "add.txs")
+	os.WriteFile(path, []byte(script), 0644)
+	body(t, c.request("launch", map[string]any{"program": path, "stopOnEntry": true}))
+	body(t, c.request("configurationDone", nil))
+	stopped := c.event("stopped")
+	if !strings.Contains(string(stopped.Body), `"reason":"entry"`) {
+		t.Errorf("expected entry stop, got=%s", stopped.Body)
+	}
+	if res := c.request("variables", map[string]any{"variablesReference": 99}); res.Success {
+		t.Errorf("expected invalid reference to fail")
+	}
+	body(t, c.request("disconnect", nil))
+	if err := <-done; err != nil {
+		t.Error(err)
+	}
+}
+
+func TestResumeWhileRunning(t *testing.T) {
+	c, done := start(t)
+	path := filepath.Join(t.TempDir(), "sleep.txs")
+	os.WriteFile(path, []byte(`time := import("time")
+x := 1
+time.sleep(time.milliseconds(300))
+x = 2
+`), 0644)
+	body(t, c.request("launch", map[string]any{"program": path}))
+	body(t, c.request("setBreakpoints", map[string]any{
+		"source":      map[string]any{"path": path},
+		"breakpoints": []map[string]any{{"line": 2}, {"line": 4}},
+	}))
+	body(t, c.request("configurationDone", nil))
+	c.event("stopped")
+
+	body(t, c.request("continue", map[string]any{"threadId": 1}))
+	for _, command := range []string{"continue", "next", "stepIn", "stepOut"} {
+		if res := c.request(command, map[string]any{"threadId": 1}); res.Success {
+			t.Errorf("expected %s to fail while running", command)
+		}
+	}
+	stopped := c.event("stopped")
+	if !strings.Contains(string(stopped.Body), `"reason":"breakpoint"`) {
+		t.Errorf("expected breakpoint stop, got=%s", stopped.Body)
+	}
+	trace := mustJSON(body(t, c.request("stackTrace", map[string]any{"threadId": 1})))
+	if !strings.Contains(trace, `"line":4`) {
+		t.Errorf("expected to stop at line 4, got=%s", trace)
+	}
+	body(t, c.request("continue", map[string]any{"threadId": 1}))
+	c.event("terminated")
+	body(t, c.request("disconnect", nil))
+	if err := <-done; err != nil {
+		t.Error(err)
+	}
+}
+
+func TestBreakpointsBeforeLaunch(t *testing.T) {
+	c, done := start(t)
+	dir := t.TempDir()
+	path := filepath.Join(dir, "add.txs")
+	os.WriteFile(path, []byte(script), 0644)
+	for _, source := range []string{path, filepath.Join(dir, "other.txs")} {
+		bps := body(t, c.request("setBreakpoints", map[string]any{
+			"source":      map[string]any{"path": source},
+			"breakpoints": []map[string]any{{"line": 3}, {"line": 8}},
+		}))
+		if strings.Contains(mustJSON(bps), `"verified":true`) {
+			t.Errorf("breakpoints before launch should not be verified, got=%s", mustJSON(bps))
+		}
+	}
+	body(t, c.request("setBreakpoints", map[string]any{
+		"source":      map[string]any{"path": path},
+		"breakpoints": []map[string]any{{"line": 3}},
+	}))
+	body(t, c.request("launch", map[string]any{"program": path}))
+	body(t, c.request("configurationDone", nil))
+
+	c.event("stopped")
+	trace := mustJSON(body(t, c.request("stackTrace", map[string]any{"threadId": 1})))
+	if !strings.Contains(trace, `"line":3`) {
+		t.Errorf("expected to stop at line 3, got=%s", trace)
+	}
+	// line 8 is a breakpoint of the other source only
+	body(t, c.request("continue", map[string]any{"threadId": 1}))
+	c.event("output")
+	c.event("exited")
+	c.event("terminated")
+	body(t, c.request("disconnect", nil))
+	if err := <-done; err != nil {
+		t.Error(err)
+	}
+}
+
+func mustJSON(v any) string {
+	b, _ := json.Marshal(v)
+	return string(b)
+}
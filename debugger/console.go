@@ -0,0 +1,153 @@
+package debugger
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const consoleHelp = `Commands:
+  break <line>, b     set a breakpoint
+  clear <line>        remove a breakpoint
+  breakpoints         list the breakpoints
+  continue, c         run until the next breakpoint
+  step, s             stop at the next statement
+  next, n             stop at the next statement of the current function
+  out, o              stop at the next statement of the caller
+  print <name>, p     print a variable, looked up through the outer environments
+  vars, v [frame]     print the variables of the scopes of a frame
+  stack, bt           print the call stack
+  list, l             print the source around the current line
+  quit, q             stop the evaluation
+  help, h             print this help`
+
+// Console is the interactive front end of a Debugger, it reads the commands from in
+// and writes to out whenever the evaluation stops.
+type Console struct {
+	*Debugger
+	in     *bufio.Scanner
+	out    io.Writer
+	source []string
+}
+
+// NewConsole returns the console debugging the script of source,
+// which pauses at the first statement so that the breakpoints can be set.
+func NewConsole(in io.Reader, out io.Writer, source string) *Console {
+	c := &Console{
+		in:     bufio.NewScanner(in),
+		out:    out,
+		source: strings.Split(source, "\n"),
+	}
+	c.Debugger = New(c.stopped)
+	c.Pause()
+	return c
+}
+
+func (c *Console) stopped(stop *Stop) Action {
+	top := stop.Frames[0]
+	fmt.Fprintf(c.out, "stopped at %s (%s) in %s\n", top.Pos, stop.Reason, top.Name)
+	c.printLine(top.Pos.Line, "=> ")
+	for {
+		fmt.Fprint(c.out, "(debug) ")
+		if !c.in.Scan() {
+			fmt.Fprintln(c.out)
+			return Quit
+		}
+		fields := strings.Fields(c.in.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		cmd, args := fields[0], fields[1:]
+		switch cmd {
+		case "continue", "c":
+			return Continue
+		case "step", "s":
+			return StepIn
+		case "next", "n":
+			return StepOver
+		case "out", "o":
+			return StepOut
+		case "quit", "q":
+			return Quit
+		case "break", "b", "clear":
+			line, err := lineArg(args)
+			if err != nil {
+				fmt.Fprintln(c.out, err)
+				continue
+			}
+			if cmd == "clear" {
+				c.ClearBreakpoint(line)
+			} else {
+				c.SetBreakpoint(line)
+				fmt.Fprintf(c.out, "breakpoint at line %d\n", line)
+			}
+		case "breakpoints":
+			for _, line := range c.Breakpoints() {
+				c.printLine(line, "*  ")
+			}
+		case "print", "p":
+			if len(args) != 1 {
+				fmt.Fprintln(c.out, "usage: print <name>")
+				continue
+			}
+			val, ok := top.Env.Get(args[0])
+			if !ok {
+				fmt.Fprintf(c.out, "identifier not found: %s\n", args[0])
+				continue
+			}
+			fmt.Fprintf(c.out, "%s = %s\n", args[0], Inspect(val))
+		case "vars", "v":
+			idx := 0
+			if len(args) == 1 {
+				n, err := strconv.Atoi(args[0])
+				if err != nil || n < 0 || n >= len(stop.Frames) {
+					fmt.Fprintf(c.out, "invalid frame %q\n", args[0])
+					continue
+				}
+				idx = n
+			}
+			for _, scope := range Scopes(stop.Frames[idx].Env) {
+				fmt.Fprintf(c.out, "%s:\n", scope.Name)
+				for _, v := range Variables(scope.Env) {
+					fmt.Fprintf(c.out, "  %s = %s\n", v.Name, Inspect(v.Value))
+				}
+			}
+		case "stack", "bt":
+			for i, f := range stop.Frames {
+				fmt.Fprintf(c.out, "#%d %s at %s\n", i, f.Name, f.Pos)
+			}
+		case "list", "l":
+			for line := top.Pos.Line - 3; line <= top.Pos.Line+3; line++ {
+				prefix := "   "
+				if line == top.Pos.Line {
+					prefix = "=> "
+				}
+				c.printLine(line, prefix)
+			}
+		case "help", "h":
+			fmt.Fprintln(c.out, consoleHelp)
+		default:
+			fmt.Fprintf(c.out, "unknown command %q, type help for the commands\n", cmd)
+		}
+	}
+}
+
+func (c *Console) printLine(line int, prefix string) {
+	if line < 1 || line > len(c.source) {
+		return
+	}
+	fmt.Fprintf(c.out, "%s%4d  %s\n", prefix, line, c.source[line-1])
+}
+
+func lineArg(args []string) (int, error) {
+	if len(args) != 1 {
+		return 0, fmt.Errorf("line is required")
+	}
+	line, err := strconv.Atoi(args[0])
+	if err != nil || line < 1 {
+		return 0, fmt.Errorf("invalid line %q", args[0])
+	}
+	return line, nil
+}
@@ -0,0 +1,312 @@
+// Package debugger stops the evaluation of scripts at breakpoints and steps through their statements.
+//
+// A Debugger is a eval.DebugHook, it calls the function given to New when the evaluation stops
+// and resumes the evaluation by the returned Action.
+//
+//	d := debugger.New(func(stop *debugger.Stop) debugger.Action {
+//		fmt.Println(stop.Reason, stop.Frames[0].Pos)
+//		return debugger.StepOver
+//	})
+//	d.SetBreakpoint(12)
+//	env.Context = d.Context(context.Background())
+//	eval.Eval(program, env)
+package debugger
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/thingsme/thingscript/ast"
+	"github.com/thingsme/thingscript/eval"
+	"github.com/thingsme/thingscript/object"
+	"github.com/thingsme/thingscript/token"
+)
+
+// Action resumes the stopped evaluation.
+type Action int
+
+const (
+	// Continue runs until the next breakpoint.
+	Continue Action = iota
+	// StepIn stops at the next statement.
+	StepIn
+	// StepOver stops at the next statement of the current function, or of its callers once it returns.
+	StepOver
+	// StepOut stops at the next statement of the caller.
+	StepOut
+	// Quit stops the evaluation by canceling the context returned by Context.
+	Quit
+)
+
+// The reasons of Stop.
+const (
+	ReasonBreakpoint = "breakpoint"
+	ReasonStep       = "step"
+	ReasonPause      = "pause"
+)
+
+// Frame is a function being called, the program itself is the outermost frame named "main".
+type Frame struct {
+	// Name is the name of the function, it is "func" for the function literals.
+	Name string
+	// Pos is the position of the statement being evaluated.
+	Pos token.Position
+	// Env is the environment of the statement.
+	Env *object.Environment
+}
+
+// Stop is the state of the stopped evaluation.
+type Stop struct {
+	Reason string
+	// Stmt is the statement which is evaluated next.
+	Stmt ast.Statement
+	// Frames is the call stack, the innermost frame first.
+	Frames []Frame
+}
+
+// Debugger implements eval.DebugHook.
+// The hooks are serialized and the call stacks are tracked per goroutine, so the functions run by go()
+// have their own stacks. The breakpoints stop any goroutine, but a step stops only the goroutine where it started.
+type Debugger struct {
+	onStop func(*Stop) Action
+
+	mu          sync.Mutex
+	breakpoints map[int]bool
+	pause       bool
+	action      Action
+	depth       int   // the depth of the frames where the step started
+	stepper     int64 // the goroutine where the step started
+	main        int64 // the goroutine evaluating the program, 0 until the first hook
+	frames      map[int64][]*Frame
+	cancel      context.CancelFunc
+
+	// stopMu serializes the hooks, it is held while the evaluation is stopped.
+	stopMu sync.Mutex
+}
+
+var _ eval.DebugHook = &Debugger{}
+
+// New returns the debugger which calls onStop when the evaluation stops.
+// onStop blocks the evaluation until it returns the action to resume it.
+func New(onStop func(*Stop) Action) *Debugger {
+	return &Debugger{onStop: onStop, breakpoints: make(map[int]bool), frames: make(map[int64][]*Frame)}
+}
+
+// Context returns the context which makes the evaluation call d, set it to Environment.Context.
+func (d *Debugger) Context(parent context.Context) context.Context {
+	ctx, cancel := context.WithCancel(parent)
+	d.mu.Lock()
+	d.cancel = cancel
+	d.mu.Unlock()
+	return eval.WithDebugHook(ctx, d)
+}
+
+// SetBreakpoint stops the evaluation at the statements starting at line.
+func (d *Debugger) SetBreakpoint(line int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.breakpoints[line] = true
+}
+
+// ClearBreakpoint removes the breakpoint at line.
+func (d *Debugger) ClearBreakpoint(line int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.breakpoints, line)
+}
+
+// SetBreakpoints replaces the breakpoints by lines.
+func (d *Debugger) SetBreakpoints(lines []int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.breakpoints = make(map[int]bool, len(lines))
+	for _, line := range lines {
+		d.breakpoints[line] = true
+	}
+}
+
+// Breakpoints returns the lines of the breakpoints in ascending order.
+func (d *Debugger) Breakpoints() []int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	lines := make([]int, 0, len(d.breakpoints))
+	for line := range d.breakpoints {
+		lines = append(lines, line)
+	}
+	sort.Ints(lines)
+	return lines
+}
+
+// Pause stops the evaluation at the next statement,
+// calling it before the evaluation stops at the first statement.
+func (d *Debugger) Pause() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.pause = true
+}
+
+// Statement stops the evaluation if stmt has a breakpoint or a step ends at stmt.
+func (d *Debugger) Statement(stmt ast.Statement, pos token.Position, env *object.Environment) {
+	d.stopMu.Lock()
+	defer d.stopMu.Unlock()
+
+	g := goroutineID()
+	d.mu.Lock()
+	frames := d.stack(g)
+	if len(frames) == 0 {
+		// a goroutine of the host evaluating statements outside functions
+		frames = []*Frame{{Name: "main"}}
+		d.frames[g] = frames
+	}
+	top := frames[len(frames)-1]
+	top.Pos = pos
+	top.Env = env
+	depth := len(frames)
+	stepping := g == d.stepper
+	var reason string
+	switch {
+	case d.pause:
+		reason = ReasonPause
+	case d.action == StepIn && stepping,
+		d.action == StepOver && stepping && depth <= d.depth,
+		d.action == StepOut && stepping && depth < d.depth:
+		reason = ReasonStep
+	case d.breakpoints[pos.Line]:
+		reason = ReasonBreakpoint
+	}
+	if reason == "" {
+		d.mu.Unlock()
+		return
+	}
+	stop := &Stop{Reason: reason, Stmt: stmt, Frames: make([]Frame, len(frames))}
+	for i, f := range frames {
+		stop.Frames[len(frames)-1-i] = *f
+	}
+	d.mu.Unlock()
+
+	action := d.onStop(stop)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.pause = false
+	d.action = action
+	d.depth = depth
+	d.stepper = g
+	if action == Quit && d.cancel != nil {
+		d.cancel()
+	}
+}
+
+// Call pushes the frame of fn.
+func (d *Debugger) Call(fn *object.Function, env *object.Environment) {
+	d.stopMu.Lock()
+	defer d.stopMu.Unlock()
+	g := goroutineID()
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	name := fn.Name
+	if name == "" {
+		name = "func"
+	}
+	d.frames[g] = append(d.stack(g), &Frame{Name: name, Pos: fn.Body.Token.Position, Env: env})
+}
+
+// Return pops the frame of fn.
+func (d *Debugger) Return(fn *object.Function, ret object.Object) {
+	d.stopMu.Lock()
+	defer d.stopMu.Unlock()
+	g := goroutineID()
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	frames := d.frames[g]
+	if len(frames) > 1 {
+		d.frames[g] = frames[:len(frames)-1]
+	} else if g != d.main {
+		// the goroutine has returned from the function run by go()
+		delete(d.frames, g)
+	}
+}
+
+// stack returns the frames of the goroutine g, the first goroutine calling the hooks evaluates the program
+// and its outermost frame is "main".
+func (d *Debugger) stack(g int64) []*Frame {
+	if d.main == 0 {
+		d.main = g
+		d.frames[g] = []*Frame{{Name: "main"}}
+	}
+	return d.frames[g]
+}
+
+// goroutineID returns the id of the calling goroutine, Go has no API for it but the stack trace starts with it.
+func goroutineID() int64 {
+	var buf [64]byte
+	b := bytes.TrimPrefix(buf[:runtime.Stack(buf[:], false)], []byte("goroutine "))
+	if i := bytes.IndexByte(b, ' '); i >= 0 {
+		b = b[:i]
+	}
+	id, _ := strconv.ParseInt(string(b), 10, 64)
+	return id
+}
+
+// Scope is an environment of the chain from the environment of a frame to the outermost one.
+type Scope struct {
+	// Name is "locals" for the environment of the frame, "globals" for the outermost environment
+	// and "closure" for the environments between them.
+	Name string
+	Env  *object.Environment
+}
+
+// Scopes returns the scopes of env, the innermost first.
+func Scopes(env *object.Environment) []Scope {
+	var scopes []Scope
+	for e := env; e != nil; e = e.Outer() {
+		scopes = append(scopes, Scope{Name: "closure", Env: e})
+	}
+	if len(scopes) > 0 {
+		scopes[0].Name = "locals"
+		scopes[len(scopes)-1].Name = "globals"
+	}
+	return scopes
+}
+
+// Variables returns the variables defined in env in the order of their names, excluding the builtins.
+func Variables(env *object.Environment) []Variable {
+	names := env.Names()
+	vars := make([]Variable, 0, len(names))
+	for _, name := range names {
+		val, _ := env.Get(name)
+		if _, ok := val.(*object.Builtin); ok {
+			continue
+		}
+		vars = append(vars, Variable{Name: name, Value: val})
+	}
+	return vars
+}
+
+// Variable is a variable of a scope.
+type Variable struct {
+	Name  string
+	Value object.Object
+}
+
+// Inspect returns obj in a line, the functions are printed without their bodies.
+func Inspect(obj object.Object) string {
+	switch obj := obj.(type) {
+	case nil:
+		return "null"
+	case *object.Function:
+		params := make([]string, len(obj.Parameters))
+		for i, p := range obj.Parameters {
+			params[i] = p.Value
+		}
+		return fmt.Sprintf("func %s(%s)", obj.Name, strings.Join(params, ", "))
+	default:
+		return obj.Inspect()
+	}
+}
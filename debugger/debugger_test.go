@@ -0,0 +1,203 @@
+package debugger_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/thingsme/thingscript/debugger"
+	"github.com/thingsme/thingscript/eval"
+	"github.com/thingsme/thingscript/lexer"
+	"github.com/thingsme/thingscript/object"
+	"github.com/thingsme/thingscript/parser"
+	"github.com/thingsme/thingscript/stdlib"
+)
+
+const script = `func add(a, b) {
+    c := a + b
+    return c
+}
+x := 1
+y := add(x, 2)
+z := add(y, 3)
+x = z`
+
+// run evaluates script with the debugger returning actions in order,
+// it returns the stops as "reason frame:line".
+func run(t *testing.T, breakpoints []int, pause bool, actions ...debugger.Action) ([]string, *object.Environment) {
+	t.Helper()
+	var stops []string
+	d := debugger.New(func(stop *debugger.Stop) debugger.Action {
+		top := stop.Frames[0]
+		stops = append(stops, fmt.Sprintf("%s %s:%d", stop.Reason, top.Name, top.Pos.Line))
+		if len(actions) == 0 {
+			return debugger.Continue
+		}
+		action := actions[0]
+		actions = actions[1:]
+		return action
+	})
+	d.SetBreakpoints(breakpoints)
+	if pause {
+		d.Pause()
+	}
+	program := parser.New(lexer.New(script)).ParseProgram()
+	env := object.NewEnvironment()
+	env.RegisterPackages(stdlib.Packages()...)
+	env.Context = d.Context(context.Background())
+	if ret := eval.Eval(program, env); ret != nil && ret.Type() == object.ERROR_OBJ && !strings.Contains(ret.Inspect(), "canceled") {
+		t.Fatal(ret.Inspect())
+	}
+	return stops, env
+}
+
+func TestDebugger(t *testing.T) {
+	tests := []struct {
+		name        string
+		breakpoints []int
+		pause       bool
+		actions     []debugger.Action
+		expected    []string
+	}{
+		{"breakpoints", []int{2, 8}, false, nil, []string{"breakpoint add:2", "breakpoint add:2", "breakpoint main:8"}},
+		{"step in", nil, true, []debugger.Action{debugger.StepIn, debugger.StepIn, debugger.StepIn, debugger.StepIn, debugger.StepIn},
+			[]string{"pause main:1", "step main:5", "step main:6", "step add:2", "step add:3", "step main:7"}},
+		{"step over", []int{6}, false, []debugger.Action{debugger.StepOver, debugger.StepOver},
+			[]string{"breakpoint main:6", "step main:7", "step main:8"}},
+		{"step out", []int{2}, false, []debugger.Action{debugger.StepOut, debugger.Continue},
+			[]string{"breakpoint add:2", "step main:7", "breakpoint add:2"}},
+		{"quit", []int{2}, false, []debugger.Action{debugger.Quit}, []string{"breakpoint add:2"}},
+	}
+	for _, tt := range tests {
+		stops, _ := run(t, tt.breakpoints, tt.pause, tt.actions...)
+		if strings.Join(stops, ", ") != strings.Join(tt.expected, ", ") {
+			t.Errorf("%s: expected %v, got=%v", tt.name, tt.expected, stops)
+		}
+	}
+}
+
+func TestDebuggerQuit(t *testing.T) {
+	_, env := run(t, []int{7}, false, debugger.Quit)
+	if _, ok := env.Get("z"); ok {
+		t.Errorf("expected the evaluation to stop before z is defined")
+	}
+}
+
+func TestStackAndScopes(t *testing.T) {
+	var stop *debugger.Stop
+	scopes := []string{}
+	d := debugger.New(func(s *debugger.Stop) debugger.Action {
+		if stop != nil {
+			return debugger.Continue
+		}
+		stop = s
+		for _, s := range debugger.Scopes(stop.Frames[0].Env) {
+			names := []string{}
+			for _, v := range debugger.Variables(s.Env) {
+				names = append(names, v.Name)
+			}
+			scopes = append(scopes, s.Name+"("+strings.Join(names, ",")+")")
+		}
+		return debugger.Continue
+	})
+	d.SetBreakpoint(3)
+	program := parser.New(lexer.New(`x := 10
+func outer(a) {
+    inner := func(b) { return a + b + x }
+    return inner(1)
+}
+outer(2)`)).ParseProgram()
+	env := object.NewEnvironment()
+	env.RegisterPackages(stdlib.Packages()...)
+	env.Context = d.Context(context.Background())
+	eval.Eval(program, env)
+
+	if stop == nil {
+		t.Fatal("expected to stop at line 3")
+	}
+	frames := []string{}
+	for _, f := range stop.Frames {
+		frames = append(frames, fmt.Sprintf("%s:%d", f.Name, f.Pos.Line))
+	}
+	if strings.Join(frames, " ") != "outer:3 main:6" {
+		t.Errorf("expected frames outer:3 main:6, got=%v", frames)
+	}
+	if strings.Join(scopes, " ") != "locals(a) globals(outer,x)" {
+		t.Errorf("unexpected scopes %v", scopes)
+	}
+}
+
+func TestConsole(t *testing.T) {
+	in := strings.NewReader("b 3\nc\nbt\np c\np a\nv\nn\nn\np y\nq\n")
+	var out bytes.Buffer
+	console := debugger.NewConsole(in, &out, script)
+	program := parser.New(lexer.New(script)).ParseProgram()
+	env := object.NewEnvironment()
+	env.RegisterPackages(stdlib.Packages()...)
+	env.Context = console.Context(context.Background())
+	eval.Eval(program, env)
+
+	for _, expected := range []string{
+		"stopped at Ln 1, Col 1 (pause) in main",
+		"breakpoint at line 3",
+		"stopped at Ln 3, Col 5 (breakpoint) in add",
+		"=>    3      return c",
+		"#0 add at Ln 3, Col 5\n#1 main at Ln 6, Col 1",
+		"c = 3",
+		"a = 1",
+		"locals:\n  a = 1\n  b = 2\n  c = 3\nglobals:\n  add = func add(a, b)\n  x = 1",
+		"stopped at Ln 7, Col 1 (step) in main",
+		"y = 3",
+	} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("expected %q in the output:\n%s", expected, out.String())
+		}
+	}
+}
+
+func TestGoroutineFrames(t *testing.T) {
+	var stops []string
+	actions := []debugger.Action{debugger.StepOver}
+	d := debugger.New(func(s *debugger.Stop) debugger.Action {
+		frames := []string{}
+		for _, f := range s.Frames {
+			frames = append(frames, fmt.Sprintf("%s:%d", f.Name, f.Pos.Line))
+		}
+		stops = append(stops, s.Reason+" "+strings.Join(frames, " "))
+		if len(actions) == 0 {
+			return debugger.Continue
+		}
+		action := actions[0]
+		actions = actions[1:]
+		return action
+	})
+	d.SetBreakpoint(9)
+	program := parser.New(lexer.New(`sync := import("sync")
+ready := sync.channel()
+done := sync.channel()
+func worker() {
+    ready.send(true)
+    done.receive()
+}
+func f() {
+    return 1
+}
+ch := go(worker)
+ready.receive()
+f()
+done.send(true)
+ch.receive()
+f()`)).ParseProgram()
+	env := object.NewEnvironment()
+	env.RegisterPackages(stdlib.Packages()...)
+	env.Context = d.Context(context.Background())
+	if ret := eval.Eval(program, env); ret != nil && ret.Type() == object.ERROR_OBJ {
+		t.Fatal(ret.Inspect())
+	}
+	expected := []string{"breakpoint f:9 main:13", "step main:14", "breakpoint f:9 main:16"}
+	if strings.Join(stops, ", ") != strings.Join(expected, ", ") {
+		t.Errorf("expected %v, got=%v", expected, stops)
+	}
+}
@@ -0,0 +1,47 @@
+package eval
+
+import (
+	"context"
+
+	"github.com/thingsme/thingscript/ast"
+	"github.com/thingsme/thingscript/object"
+	"github.com/thingsme/thingscript/token"
+)
+
+// DebugHook is notified of the progress of the evaluation, debuggers implement it.
+// The methods are called from the goroutine evaluating the script and block the evaluation until they return,
+// the functions run by go() call them from their own goroutines.
+type DebugHook interface {
+	// Statement is called before stmt at pos is evaluated in env.
+	Statement(stmt ast.Statement, pos token.Position, env *object.Environment)
+	// Call is called when fn is called, env is the environment of its parameters.
+	Call(fn *object.Function, env *object.Environment)
+	// Return is called when fn returns ret.
+	Return(fn *object.Function, ret object.Object)
+}
+
+type debugHookKey struct{}
+
+// WithDebugHook returns the context which makes the evaluation in the environments using it call hook.
+//
+//	env.Context = eval.WithDebugHook(ctx, hook)
+func WithDebugHook(ctx context.Context, hook DebugHook) context.Context {
+	return context.WithValue(ctx, debugHookKey{}, hook)
+}
+
+func debugHook(ctx context.Context) DebugHook {
+	hook, _ := ctx.Value(debugHookKey{}).(DebugHook)
+	return hook
+}
+
+// beforeStatement calls the debug hook and returns an error if the context of env is done.
+func beforeStatement(stmt ast.Statement, env *object.Environment) *object.Error {
+	ctx := env.Ctx()
+	if hook := debugHook(ctx); hook != nil {
+		hook.Statement(stmt, ast.StatementPosition(stmt), env)
+	}
+	if err := ctx.Err(); err != nil {
+		return object.Errorf("evaluation stopped: %s", err.Error())
+	}
+	return nil
+}
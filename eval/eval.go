@@ -1,12 +1,15 @@
 package eval
 
 import (
+	"reflect"
+
 	"github.com/thingsme/thingscript/ast"
 	"github.com/thingsme/thingscript/object"
+	"github.com/thingsme/thingscript/token"
 )
 
 var (
-	NULL = &object.Null{}
+	NULL = object.NULL
 )
 
 func isError(obj object.Object) bool {
@@ -67,7 +70,7 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		if isError(evaluated) {
 			return evaluated
 		}
-		return evalAssignStatement(val, evaluated)
+		return evalAssignStatement(node.Name.Value, val, evaluated, env)
 	case *ast.OperAssignStatement:
 		left, ok := env.Get(node.Name.Value)
 		if !ok {
@@ -81,7 +84,7 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		if isError(evaluated) {
 			return evaluated
 		}
-		return evalAssignStatement(left, evaluated)
+		return evalAssignStatement(node.Name.Value, left, evaluated, env)
 	case *ast.PrefixExpression:
 		right := Eval(node.Right, env)
 		if isError(right) {
@@ -133,11 +136,10 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 	case *ast.FunctionStatement:
 		params := node.Parameters
 		body := node.Body
-		val := &object.Function{Parameters: params, Env: env, Body: body}
-		if isError(val) {
-			return val
+		val := &object.Function{Name: node.Name.Value, Parameters: params, Env: env, Body: body}
+		if ret := env.Set(node.Name.Value, val); isError(ret) {
+			return ret
 		}
-		env.Set(node.Name.Value, val)
 	case *ast.FunctionLiteral:
 		params := node.Parameters
 		body := node.Body
@@ -165,6 +167,9 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 func evalProgram(stmts []ast.Statement, env *object.Environment) object.Object {
 	var result object.Object
 	for _, statement := range stmts {
+		if err := beforeStatement(statement, env); err != nil {
+			return err
+		}
 		result = Eval(statement, env)
 
 		switch result := result.(type) {
@@ -177,9 +182,20 @@ func evalProgram(stmts []ast.Statement, env *object.Environment) object.Object {
 	return result
 }
 
+// checkContext returns an error if the context of env is done.
+func checkContext(env *object.Environment) *object.Error {
+	if err := env.Ctx().Err(); err != nil {
+		return object.Errorf("evaluation stopped: %s", err.Error())
+	}
+	return nil
+}
+
 func evalBlockStatement(block *ast.BlockStatement, env *object.Environment) object.Object {
 	var result object.Object
 	for _, statement := range block.Statements {
+		if err := beforeStatement(statement, env); err != nil {
+			return err
+		}
 		result = Eval(statement, env)
 
 		if result != nil {
@@ -214,7 +230,9 @@ func evalVarStatement(node *ast.VarStatement, env *object.Environment) object.Ob
 	}
 	if node.TypeDecl == nil {
 		// infer the var type from value
-		env.Set(node.Name.Value, evaluated)
+		if ret := env.Set(node.Name.Value, evaluated); isError(ret) {
+			return ret
+		}
 	} else {
 		// explicitly declare the type of the var
 		if node.TypeDecl.Package == nil {
@@ -225,25 +243,54 @@ func evalVarStatement(node *ast.VarStatement, env *object.Environment) object.Ob
 		if isError(evaluated) {
 			return evaluated
 		}
-		env.Set(node.Name.Value, evaluated)
+		if ret := env.Set(node.Name.Value, evaluated); isError(ret) {
+			return ret
+		}
 	}
 	return nil
 }
 
 func evalCallFunction(fn object.Object, args []object.Object) object.Object {
+	return Apply(fn, args...)
+}
+
+// Apply calls fn with args and returns the result.
+// fn can be a user function (including closures), a builtin or a bound method.
+// Missing args of a user function are an error and extra args are ignored as in a call expression.
+// It never returns nil and a panic raised while calling fn is returned as an error,
+// so that Go packages and host applications can call script callbacks safely.
+func Apply(fn object.Object, args ...object.Object) (ret object.Object) {
+	defer func() {
+		if r := recover(); r != nil {
+			ret = object.Errorf("panic in function call: %v", r)
+		}
+	}()
 	switch fn := fn.(type) {
 	case *object.Function:
+		if len(args) < len(fn.Parameters) {
+			return object.Errorf("wrong number of arguments. want=%d got=%d", len(fn.Parameters), len(args))
+		}
 		extendedEnv := extendFunctionEnv(fn, args)
+		if hook := debugHook(extendedEnv.Ctx()); hook != nil {
+			hook.Call(fn, extendedEnv)
+			// deferred to keep the calls and the returns paired on panics
+			defer func() { hook.Return(fn, ret) }()
+		}
 		evaluated := Eval(fn.Body, extendedEnv)
-		return unwrapReturnValue(evaluated)
+		ret = unwrapReturnValue(evaluated)
 	case *object.Builtin:
-		if ret := fn.Func(args...); ret != nil {
-			return ret
-		}
-		return NULL
+		ret = fn.Func(args...)
+	case *object.BoundMethod:
+		ret = fn.Func(fn.Receiver, args...)
+	case nil:
+		return object.Errorf("not a function: nil")
 	default:
 		return object.Errorf("not a function: %s", fn.Type())
 	}
+	if ret == nil {
+		return NULL
+	}
+	return ret
 }
 
 func evalAccessExpression(exp *ast.AccessExpression, env *object.Environment) object.Object {
@@ -254,7 +301,7 @@ func evalAccessExpression(exp *ast.AccessExpression, env *object.Environment) ob
 
 	switch r := exp.Right.(type) {
 	case *ast.Identifier:
-		fn := left.Member(r.Value)
+		fn := member(left, r.Value, r.Token.Position)
 		if fn == nil {
 			return object.Errorf("function %q not found in %q", r.Value, left.Type())
 		}
@@ -265,7 +312,7 @@ func evalAccessExpression(exp *ast.AccessExpression, env *object.Environment) ob
 		if !ok {
 			return object.Errorf("undefined %q in %q", r.Function.String(), left.Type())
 		}
-		fn := left.Member(fnIdent.Value)
+		fn := member(left, fnIdent.Value, fnIdent.Token.Position)
 		if fn == nil {
 			return object.Errorf("function %q not found in %q", fnIdent.Value, left.Type())
 		}
@@ -277,6 +324,14 @@ func evalAccessExpression(exp *ast.AccessExpression, env *object.Environment) ob
 	}
 }
 
+// member returns the member function of obj, which is bound to pos if obj is a CallSiteMember.
+func member(obj object.Object, name string, pos token.Position) object.MemberFunc {
+	if cs, ok := obj.(object.CallSiteMember); ok {
+		return cs.MemberAt(name, pos)
+	}
+	return obj.Member(name)
+}
+
 func extendFunctionEnv(fn *object.Function, args []object.Object) *object.Environment {
 	env := object.NewEnclosedEnvironment(fn.Env)
 	for paramIdx, param := range fn.Parameters {
@@ -294,6 +349,9 @@ func unwrapReturnValue(obj object.Object) object.Object {
 
 func evalWhileExpression(we *ast.WhileExpression, env *object.Environment) object.Object {
 	for {
+		if err := checkContext(env); err != nil {
+			return err
+		}
 		condition := Eval(we.Condition, env)
 		if isError(condition) {
 			return condition
@@ -317,6 +375,9 @@ func evalWhileExpression(we *ast.WhileExpression, env *object.Environment) objec
 
 func evalDoWhileExpression(we *ast.DoWhileExpression, env *object.Environment) object.Object {
 	for {
+		if err := checkContext(env); err != nil {
+			return err
+		}
 		ret := Eval(we.Block, env)
 		if isError(ret) {
 			return ret
@@ -451,15 +512,32 @@ func evalInfixExpression(operator string, left object.Object, right object.Objec
 	return object.Errorf("unknown operator: %s %s %s", left.Type(), operator, right.Type())
 }
 
-func evalAssignStatement(left object.Object, right object.Object) object.Object {
+// evalAssignStatement assigns right to a copy of left with its "=" operator
+// and replaces the variable with the copy, so that the other variables and the concurrent
+// evaluations which refer to the value of left are not affected.
+func evalAssignStatement(name string, left object.Object, right object.Object, env *object.Environment) object.Object {
+	var assigned object.Object
 	if assignFunc := left.Member("="); assignFunc != nil {
-		left = assignFunc(left, right)
-	} else {
-		left = nil
+		receiver := shallowCopy(left)
+		assigned = assignFunc(receiver, right)
 	}
 
-	if left == nil {
+	if assigned == nil {
 		return object.Errorf("unable to set value of %T with %T", left, right)
 	}
+	if ret := env.Assign(name, assigned); isError(ret) {
+		return ret
+	}
 	return nil
 }
+
+// shallowCopy returns a copy of the struct which obj points to.
+func shallowCopy(obj object.Object) object.Object {
+	v := reflect.ValueOf(obj)
+	if v.Kind() != reflect.Pointer || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return obj
+	}
+	c := reflect.New(v.Elem().Type())
+	c.Elem().Set(v.Elem())
+	return c.Interface().(object.Object)
+}
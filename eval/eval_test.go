@@ -2,15 +2,20 @@ package eval_test
 
 import (
 	"bytes"
+	"context"
 	"fmt"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/thingsme/thingscript/ast"
 	"github.com/thingsme/thingscript/eval"
 	"github.com/thingsme/thingscript/lexer"
 	"github.com/thingsme/thingscript/object"
 	"github.com/thingsme/thingscript/parser"
 	"github.com/thingsme/thingscript/stdlib"
+	"github.com/thingsme/thingscript/token"
 )
 
 func testEval(input string) object.Object {
@@ -656,6 +661,87 @@ func TestClosure(t *testing.T) {
 	}
 }
 
+func TestApply(t *testing.T) {
+	env := object.NewEnvironment()
+	env.RegisterPackages(stdlib.Packages()...)
+	program := parser.New(lexer.New(`
+		func add(x, y) { x + y }
+		func adder(x) { func(y) { return x + y } }
+		add3 := adder(3)
+	`)).ParseProgram()
+	eval.Eval(program, env)
+	add, _ := env.Get("add")
+	add3, _ := env.Get("add3")
+
+	tests := []struct {
+		fn       object.Object
+		args     []object.Object
+		expected any
+	}{
+		{add, []object.Object{&object.Integer{Value: 1}, &object.Integer{Value: 2}}, 3},
+		{add3, []object.Object{&object.Integer{Value: 4}}, 7},
+		{env.Builtin("int"), []object.Object{&object.Integer{Value: 5}}, 5},
+		{object.Bind(&object.String{Value: "four"}, "length"), nil, 4},
+		{add, []object.Object{&object.Integer{Value: 1}}, "wrong number of arguments. want=2 got=1"},
+		{add, []object.Object{&object.Integer{Value: 1}, &object.Integer{Value: 2}, &object.Integer{Value: 3}}, 3},
+		{&object.Integer{Value: 1}, nil, "not a function: INTEGER"},
+		{nil, nil, "not a function: nil"},
+		{&object.Builtin{Func: func(args ...object.Object) object.Object { panic("boom") }}, nil, "panic in function call: boom"},
+		{&object.Builtin{Func: func(args ...object.Object) object.Object { return nil }}, nil, nil},
+	}
+	for _, tt := range tests {
+		ret := eval.Apply(tt.fn, tt.args...)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, ret, int64(expected))
+		case string:
+			err, ok := ret.(*object.Error)
+			if !ok {
+				t.Errorf("result is not error, got=%T (%+v)", ret, ret)
+			} else if err.Message != expected {
+				t.Errorf("wrong error message. expected=%q, got=%q", expected, err.Message)
+			}
+		case nil:
+			testNullObject(t, ret)
+		}
+	}
+}
+
+func TestContext(t *testing.T) {
+	tests := []string{
+		`while true { }`,
+		`do { } while true`,
+		`func loop() { while true { x := 1 } }
+		loop()`,
+	}
+	for _, input := range tests {
+		env := object.NewEnvironment()
+		env.RegisterPackages(stdlib.Packages()...)
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		env.Context = ctx
+		program := parser.New(lexer.New(input)).ParseProgram()
+		ret := eval.Eval(program, env)
+		cancel()
+		err, ok := ret.(*object.Error)
+		if !ok {
+			t.Errorf("result is not error, got=%T (%+v)", ret, ret)
+		} else if err.Message != "evaluation stopped: context deadline exceeded" {
+			t.Errorf("wrong error message. got=%q", err.Message)
+		}
+	}
+
+	env := object.NewEnvironment()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	env.Context = ctx
+	if object.NewEnclosedEnvironment(env).Ctx() != ctx {
+		t.Errorf("enclosed environment should inherit the context")
+	}
+	if object.NewEnvironment().Ctx() != context.Background() {
+		t.Errorf("environment without context should be context.Background()")
+	}
+}
+
 func TestBuiltinFunctionError(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -792,6 +878,15 @@ func TestImports(t *testing.T) {
 			`,
 			expected: fmt.Sprintf("time: time.Time(%s)\n", timing),
 		},
+		{
+			input: `
+				func show(v) {
+					import("fmt").println("value:", v)
+				}
+				show(1)
+			`,
+			expected: "value: 1\n",
+		},
 	}
 
 	for _, tt := range tests {
@@ -814,3 +909,141 @@ func TestImports(t *testing.T) {
 		}
 	}
 }
+
+func TestAssignCopiesValue(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{`a := 1; b := a; b = 2; a`, 1},
+		{`a := 1; b := a; b += 2; a`, 1},
+		{`a := 1; b := a; b += 2; b`, 3},
+		{`a := 1; func set(){ a = 5 }; set(); a`, 5},
+		{`a := [1]; b := a; b = [1, 2]; a.length()`, 1},
+	}
+	for _, tt := range tests {
+		testIntegerObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestFrozenEnvironment(t *testing.T) {
+	parent := object.NewEnvironment()
+	parent.RegisterPackages(stdlib.Packages()...)
+	parent.Set("limit", &object.Integer{Value: 30})
+	parent.Set("config", &object.HashMap{Pairs: map[object.HashKey]object.HashPair{}})
+	parent.Freeze()
+	if !parent.Frozen() {
+		t.Fatal("environment should be frozen")
+	}
+	if ret := parent.Set("x", &object.Integer{Value: 1}); !isErrorMessage(ret, `can not define "x" in frozen environment`) {
+		t.Errorf("wrong result of Set, got=%v", ret)
+	}
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`limit = 10`, `can not assign to "limit" in frozen environment`},
+		{`limit += 10`, `can not assign to "limit" in frozen environment`},
+		{`func f() { limit = 1 }; f()`, `can not assign to "limit" in frozen environment`},
+		{`config = {"a": 1}`, `can not assign to "config" in frozen environment`},
+		{`nope = 1`, `identifier not found: nope`},
+	}
+	for _, tt := range tests {
+		program := parser.New(lexer.New(tt.input)).ParseProgram()
+		ret := eval.Eval(program, object.NewEnclosedEnvironment(parent))
+		if !isErrorMessage(ret, tt.expected) {
+			t.Errorf("expected error %q, got=%v <= %s", tt.expected, ret, tt.input)
+		}
+	}
+
+	program := parser.New(lexer.New(`x := limit; x = x + 1; limit := 5; limit + x`)).ParseProgram()
+	testIntegerObject(t, eval.Eval(program, object.NewEnclosedEnvironment(parent)), 36)
+	program = parser.New(lexer.New(`limit`)).ParseProgram()
+	testIntegerObject(t, eval.Eval(program, object.NewEnclosedEnvironment(parent)), 30)
+}
+
+func isErrorMessage(obj object.Object, msg string) bool {
+	err, ok := obj.(*object.Error)
+	return ok && err.Message == msg
+}
+
+func TestConcurrentEvaluation(t *testing.T) {
+	input := `
+	total := 0
+	i := 0
+	while (i < 100) {
+		x := limit
+		x = x + i
+		total += x
+		i += 1
+	}
+	shared = shared + 1
+	total`
+	for _, frozen := range []bool{false, true} {
+		parent := object.NewEnvironment()
+		parent.RegisterPackages(stdlib.Packages()...)
+		parent.Set("limit", &object.Integer{Value: 1})
+		if frozen {
+			parent.Freeze()
+		} else {
+			parent.Set("shared", &object.Integer{Value: 0})
+		}
+
+		program := parser.New(lexer.New(input)).ParseProgram()
+		var wg sync.WaitGroup
+		results := make([]object.Object, 8)
+		for n := range results {
+			wg.Add(1)
+			go func(n int) {
+				defer wg.Done()
+				env := object.NewEnclosedEnvironment(parent)
+				if frozen {
+					env.Set("shared", &object.Integer{Value: 0})
+				}
+				results[n] = eval.Eval(program, env)
+			}(n)
+		}
+		wg.Wait()
+		for _, ret := range results {
+			testIntegerObject(t, ret, 5050)
+		}
+		if limit, _ := parent.Get("limit"); limit.(*object.Integer).Value != 1 {
+			t.Errorf("parent value is modified, got=%s", limit.Inspect())
+		}
+	}
+}
+
+type recordingHook struct {
+	events []string
+}
+
+func (h *recordingHook) Statement(stmt ast.Statement, pos token.Position, env *object.Environment) {
+	h.events = append(h.events, fmt.Sprintf("stmt %d:%d", pos.Line, pos.Column))
+}
+
+func (h *recordingHook) Call(fn *object.Function, env *object.Environment) {
+	h.events = append(h.events, "call "+fn.Name)
+}
+
+func (h *recordingHook) Return(fn *object.Function, ret object.Object) {
+	h.events = append(h.events, "return "+ret.Inspect())
+}
+
+func TestDebugHook(t *testing.T) {
+	input := `func double(x) {
+  return x * 2
+}
+y := double(3)`
+	program := parser.New(lexer.New(input)).ParseProgram()
+	hook := &recordingHook{}
+	env := object.NewEnvironment()
+	env.RegisterPackages(stdlib.Packages()...)
+	env.Context = eval.WithDebugHook(context.Background(), hook)
+	eval.Eval(program, env)
+
+	expected := "stmt 1:1, stmt 4:1, call double, stmt 2:3, return 6"
+	if got := strings.Join(hook.events, ", "); got != expected {
+		t.Errorf("expected %s, got=%s", expected, got)
+	}
+}
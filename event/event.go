@@ -0,0 +1,203 @@
+// Package event dispatches events from a Go host to the handlers registered by a script.
+//
+// A script registers handlers with the builtin 'on' function,
+//
+//	on("temperature", func(evt) {
+//	    if evt["value"] > 30 { alert(evt["device"]) }
+//	})
+//
+// and the host dispatches events into the long-lived environment of the script.
+//
+//	d := event.NewDispatcher()
+//	env := object.NewEnvironment()
+//	env.RegisterPackages(stdlib.Packages()...)
+//	env.RegisterPackages(d)
+//	d.Eval(program)
+//	results, err := d.Dispatch("temperature", map[string]any{"device": "t1", "value": 31.5})
+package event
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/thingsme/thingscript/ast"
+	"github.com/thingsme/thingscript/eval"
+	"github.com/thingsme/thingscript/object"
+)
+
+// Dispatcher keeps the handlers of a script and calls them on events.
+// All evaluations through a Dispatcher are serialized,
+// so the handlers never run concurrently with each other.
+type Dispatcher struct {
+	mu  sync.Mutex
+	env *object.Environment
+	// handlersMu guards handlers apart from mu,
+	// since 'on' is also called by the evaluations which do not go through Eval and Dispatch.
+	handlersMu sync.Mutex
+	handlers   map[string][]object.Object
+}
+
+var _ object.BuiltinPackage = &Dispatcher{}
+
+// NewDispatcher returns a Dispatcher, it should be registered to an environment as a package.
+func NewDispatcher() *Dispatcher {
+	return &Dispatcher{
+		handlers: make(map[string][]object.Object),
+	}
+}
+
+func (d *Dispatcher) Type() object.ObjectType { return object.PACKAGE_OBJ }
+
+func (d *Dispatcher) Inspect() string { return "package event" }
+
+func (d *Dispatcher) Name() string { return "event" }
+
+func (d *Dispatcher) OnLoad(env *object.Environment) {
+	d.env = env
+}
+
+// Builtins makes 'on' a builtin, so that scripts register handlers without importing the package.
+func (d *Dispatcher) Builtins() []string { return []string{"on"} }
+
+func (d *Dispatcher) Member(name string) object.MemberFunc {
+	switch name {
+	case "on":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return object.Errorf("wrong number of arguments. want=2 got=%d", len(args))
+			}
+			event, ok := args[0].(*object.String)
+			if !ok {
+				return object.Errorf("event name must be string, got %s", args[0].Type())
+			}
+			switch args[1].(type) {
+			case *object.Function, *object.Builtin, *object.BoundMethod:
+			default:
+				return object.Errorf("event handler must be function, got %s", args[1].Type())
+			}
+			return &object.Integer{Value: int64(d.addHandler(event.Value, args[1]))}
+		}
+	case "off":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return object.Errorf("wrong number of arguments. want=1 got=%d", len(args))
+			}
+			event, ok := args[0].(*object.String)
+			if !ok {
+				return object.Errorf("event name must be string, got %s", args[0].Type())
+			}
+			return &object.Integer{Value: int64(d.removeHandlers(event.Value))}
+		}
+	case "events":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 0 {
+				return object.Errorf("wrong number of arguments. want=0 got=%d", len(args))
+			}
+			elements := []object.Object{}
+			for _, name := range d.events() {
+				elements = append(elements, &object.String{Value: name})
+			}
+			return &object.Array{Elements: elements}
+		}
+	default:
+		return nil
+	}
+}
+
+// Eval evaluates node in the environment of the dispatcher,
+// it is serialized with the event handlers.
+func (d *Dispatcher) Eval(node ast.Node) object.Object {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.env == nil {
+		return object.Errorf("dispatcher is not registered to an environment")
+	}
+	return eval.Eval(node, d.env)
+}
+
+// Events returns the names of the events which have handlers in alphabetical order.
+func (d *Dispatcher) Events() []string {
+	return d.events()
+}
+
+func (d *Dispatcher) events() []string {
+	d.handlersMu.Lock()
+	defer d.handlersMu.Unlock()
+	ret := make([]string, 0, len(d.handlers))
+	for name := range d.handlers {
+		ret = append(ret, name)
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+// addHandler appends fn to the handlers of event and returns the number of them.
+func (d *Dispatcher) addHandler(event string, fn object.Object) int {
+	d.handlersMu.Lock()
+	defer d.handlersMu.Unlock()
+	d.handlers[event] = append(d.handlers[event], fn)
+	return len(d.handlers[event])
+}
+
+// removeHandlers removes the handlers of event and returns the number of them.
+func (d *Dispatcher) removeHandlers(event string) int {
+	d.handlersMu.Lock()
+	defer d.handlersMu.Unlock()
+	n := len(d.handlers[event])
+	delete(d.handlers, event)
+	return n
+}
+
+// handlersOf returns a copy of the handlers of event.
+func (d *Dispatcher) handlersOf(event string) []object.Object {
+	d.handlersMu.Lock()
+	defer d.handlersMu.Unlock()
+	handlers := make([]object.Object, len(d.handlers[event]))
+	copy(handlers, d.handlers[event])
+	return handlers
+}
+
+// Result is the outcome of a handler.
+type Result struct {
+	// Value is the return value of the handler, NULL if the handler returns nothing.
+	Value object.Object
+	// Err is not nil if the handler failed.
+	Err error
+}
+
+// HandlerError is the error of a handler which returns an error object.
+type HandlerError struct {
+	Event   string
+	Index   int
+	Message string
+}
+
+func (e *HandlerError) Error() string {
+	return fmt.Sprintf("event %q handler #%d: %s", e.Event, e.Index, e.Message)
+}
+
+// Dispatch calls the handlers of the event in the registration order with the payload.
+// payload is converted by object.FromNative, it returns an error if the conversion fails.
+// A failure of a handler does not stop the others, it is reported in the Result of the handler.
+func (d *Dispatcher) Dispatch(event string, payload any) ([]Result, error) {
+	evt, err := object.FromNative(payload)
+	if err != nil {
+		return nil, fmt.Errorf("event %q payload, %w", event, err)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	handlers := d.handlersOf(event)
+
+	results := make([]Result, len(handlers))
+	for i, fn := range handlers {
+		ret := eval.Apply(fn, evt)
+		if errObj, ok := ret.(*object.Error); ok {
+			results[i].Err = &HandlerError{Event: event, Index: i, Message: errObj.Message}
+		} else {
+			results[i].Value = ret
+		}
+	}
+	return results, nil
+}
@@ -0,0 +1,183 @@
+package event_test
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+
+	"github.com/thingsme/thingscript/eval"
+	"github.com/thingsme/thingscript/event"
+	"github.com/thingsme/thingscript/lexer"
+	"github.com/thingsme/thingscript/object"
+	"github.com/thingsme/thingscript/parser"
+	"github.com/thingsme/thingscript/stdlib"
+)
+
+func setup(t *testing.T, input string) (*event.Dispatcher, *bytes.Buffer) {
+	t.Helper()
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	for _, err := range p.Errors() {
+		t.Fatalf("parse error: %s", err)
+	}
+	out := &bytes.Buffer{}
+	env := object.NewEnvironment()
+	env.Stdout = out
+	env.RegisterPackages(stdlib.Packages()...)
+	d := event.NewDispatcher()
+	env.RegisterPackages(d)
+	if ret := d.Eval(program); ret != nil && ret.Type() == object.ERROR_OBJ {
+		t.Fatalf("eval error: %s", ret.Inspect())
+	}
+	return d, out
+}
+
+func TestDispatch(t *testing.T) {
+	d, out := setup(t, `
+		out := import("fmt")
+		count := 0
+		on("temperature", func(evt) {
+			count += 1
+			out.println(evt["device"], evt["value"], count)
+			return evt["value"] > 30
+		})
+		on("temperature", func(evt) {
+			evt["unknown"].length
+		})
+	`)
+	if events := d.Events(); len(events) != 1 || events[0] != "temperature" {
+		t.Fatalf("unexpected events %v", events)
+	}
+	for i, value := range []float64{21.5, 31.5} {
+		results, err := d.Dispatch("temperature", map[string]any{"device": "t1", "value": value})
+		if err != nil {
+			t.Fatalf("dispatch error: %s", err)
+		}
+		if len(results) != 2 {
+			t.Fatalf("expect 2 results, got=%d", len(results))
+		}
+		ret, ok := results[0].Value.(*object.Boolean)
+		if !ok || results[0].Err != nil {
+			t.Fatalf("expect boolean result, got=%v, %v", results[0].Value, results[0].Err)
+		}
+		if ret.Value != (i == 1) {
+			t.Errorf("wrong result of %v, got=%t", value, ret.Value)
+		}
+		if results[1].Err == nil {
+			t.Errorf("the second handler should fail")
+		} else if results[1].Err.Error() != `event "temperature" handler #1: function "length" not found in "NULL"` {
+			t.Errorf("wrong error %q", results[1].Err.Error())
+		}
+	}
+	if out.String() != "t1 21.5 1\nt1 31.5 2\n" {
+		t.Errorf("wrong output %q", out.String())
+	}
+
+	results, err := d.Dispatch("humidity", 10)
+	if err != nil || len(results) != 0 {
+		t.Errorf("unknown event should have no results, got=%v, %v", results, err)
+	}
+	if _, err := d.Dispatch("temperature", struct{}{}); err == nil {
+		t.Errorf("unsupported payload should fail")
+	}
+}
+
+func TestOnErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`on("x")`, "wrong number of arguments. want=2 got=1"},
+		{`on(1, func(e){})`, "event name must be string, got INTEGER"},
+		{`on("x", 1)`, "event handler must be function, got INTEGER"},
+	}
+	for _, tt := range tests {
+		d, _ := setup(t, "")
+		ret := d.Eval(parser.New(lexer.New(tt.input)).ParseProgram())
+		if err, ok := ret.(*object.Error); !ok || err.Message != tt.expected {
+			t.Errorf("expect error %q, got=%v", tt.expected, ret)
+		}
+	}
+}
+
+func TestOff(t *testing.T) {
+	d, _ := setup(t, `
+		ev := import("event")
+		on("a", func(e) { e })
+		on("b", func(e) { e })
+		ev.off("a")
+	`)
+	results, _ := d.Dispatch("a", 1)
+	if len(results) != 0 {
+		t.Errorf("handlers of 'a' should be removed")
+	}
+	results, _ = d.Dispatch("b", 1)
+	if len(results) != 1 || results[0].Value.Inspect() != "1" {
+		t.Errorf("wrong results %v", results)
+	}
+}
+
+func TestOnBuiltin(t *testing.T) {
+	env := object.NewEnvironment()
+	env.Freeze()
+	d := event.NewDispatcher()
+	env.RegisterPackages(d)
+	enclosed := object.NewEnclosedEnvironment(env)
+	ret := eval.Eval(parser.New(lexer.New(`
+		on("a", func(e) { e })
+		on := 1
+		on
+	`)).ParseProgram(), enclosed)
+	if ret == nil || ret.Inspect() != "1" {
+		t.Fatalf("unexpected result %v", ret)
+	}
+	if events := d.Events(); len(events) != 1 || events[0] != "a" {
+		t.Errorf("'on' of frozen environment should register the handler, got=%v", events)
+	}
+	if _, ok := env.Get("on"); ok {
+		t.Errorf("'on' should not be a variable")
+	}
+}
+
+func TestSerializedDispatch(t *testing.T) {
+	d, _ := setup(t, `
+		count := 0
+		on("tick", func(e) { count += e; count })
+	`)
+	wg := sync.WaitGroup{}
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			d.Dispatch("tick", 1)
+		}()
+	}
+	wg.Wait()
+	results, _ := d.Dispatch("tick", 0)
+	if results[0].Value.Inspect() != "50" {
+		t.Errorf("expect 50, got=%s", results[0].Value.Inspect())
+	}
+}
+
+func TestOnFromGoroutines(t *testing.T) {
+	d, _ := setup(t, `
+		sync := import("sync")
+		wg := sync.wait_group()
+		wg.add(10)
+		[1, 2, 3, 4, 5, 6, 7, 8, 9, 10].foreach(func(idx, n) {
+			go(func() {
+				on("tick", func(e) { e })
+				wg.done()
+			})
+		})
+	`)
+	for i := 0; i < 100; i++ {
+		d.Dispatch("tick", i)
+		d.Events()
+	}
+	d.Eval(parser.New(lexer.New(`wg.wait()`)).ParseProgram())
+	results, _ := d.Dispatch("tick", 1)
+	if len(results) != 10 {
+		t.Errorf("expect 10 handlers, got=%d", len(results))
+	}
+}
@@ -0,0 +1,124 @@
+// Package format formats the source of scripts.
+//
+// The statements keep their lines and tokens, the lines are indented by 4 spaces per bracket
+// opened at the previous lines, and the repeated blank lines are collapsed.
+// The lines inside the multi-line strings and comments are left as they are.
+package format
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+
+	"github.com/thingsme/thingscript/lexer"
+	"github.com/thingsme/thingscript/parser"
+)
+
+const indent = "    "
+
+// Source returns the formatted src, or the syntax errors of src.
+func Source(src []byte) ([]byte, error) {
+	p := parser.New(lexer.New(string(src)))
+	p.ParseProgram()
+	if errs := p.Errors(); len(errs) > 0 {
+		return nil, errors.New(strings.Join(errs, "\n"))
+	}
+
+	var out bytes.Buffer
+	s := &scanner{}
+	blank := false
+	lines := strings.Split(strings.ReplaceAll(string(src), "\r\n", "\n"), "\n")
+	for i, line := range lines {
+		if s.inString || s.inComment {
+			// the line continues a string or a comment
+			s.scan(line, i)
+			out.WriteString(line)
+			out.WriteByte('\n')
+			blank = false
+			continue
+		}
+		text := strings.TrimLeft(line, " \t")
+		if strings.TrimSpace(text) == "" {
+			blank = out.Len() > 0
+			continue
+		}
+		if blank {
+			out.WriteByte('\n')
+			blank = false
+		}
+		level := s.level(leadingClosers(text))
+		s.scan(text, i)
+		if !s.inString && !s.inComment {
+			text = strings.TrimRight(text, " \t")
+		}
+		out.WriteString(strings.Repeat(indent, level))
+		out.WriteString(text)
+		out.WriteByte('\n')
+	}
+	return out.Bytes(), nil
+}
+
+// leadingClosers returns the number of the closing brackets at the beginning of text.
+func leadingClosers(text string) int {
+	n := 0
+	for _, c := range text {
+		switch c {
+		case ')', ']', '}':
+			n++
+		case ' ', '\t':
+		default:
+			return n
+		}
+	}
+	return n
+}
+
+// scanner tracks the open brackets, strings and comments across the lines.
+type scanner struct {
+	open      []int // the lines of the open brackets
+	inString  bool
+	inComment bool
+}
+
+// level returns the indent level of a line starting with closers closing brackets,
+// which is the number of the lines having the brackets remaining open.
+func (s *scanner) level(closers int) int {
+	open := s.open[:max(len(s.open)-closers, 0)]
+	level := 0
+	for i, line := range open {
+		if i == 0 || open[i-1] != line {
+			level++
+		}
+	}
+	return level
+}
+
+func (s *scanner) scan(line string, lineNo int) {
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case s.inString:
+			if c == '"' {
+				s.inString = false
+			}
+		case s.inComment:
+			if c == '*' && i+1 < len(line) && line[i+1] == '/' {
+				s.inComment = false
+				i++
+			}
+		case c == '"':
+			s.inString = true
+		case c == '/' && i+1 < len(line) && line[i+1] == '/':
+			return
+		case c == '/' && i+1 < len(line) && line[i+1] == '*':
+			s.inComment = true
+			i++
+		case c == '(' || c == '[' || c == '{':
+			s.open = append(s.open, lineNo)
+		case c == ')' || c == ']' || c == '}':
+			if len(s.open) > 0 {
+				s.open = s.open[:len(s.open)-1]
+			}
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package format_test
+
+import (
+	"testing"
+
+	"github.com/thingsme/thingscript/format"
+)
+
+func TestSource(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"x := 1", "x := 1\n"},
+		{"\n\nx := 1  \n\n\n\ny := 2\n\n", "x := 1\n\ny := 2\n"},
+		{`func f(a) {
+if (a > 1) {
+return a
+} else {
+	return 0 // {
+}
+}`, `func f(a) {
+    if (a > 1) {
+        return a
+    } else {
+        return 0 // {
+    }
+}
+`},
+		{`[1, 2].foreach(func(idx, elm) {
+  println(elm)
+        })`, `[1, 2].foreach(func(idx, elm) {
+    println(elm)
+})
+`},
+		{`x := {
+"a": [
+1,
+2
+]
+}`, `x := {
+    "a": [
+        1,
+        2
+    ]
+}
+`},
+		{`s := "a
+   { b"
+/* c
+   } */
+  s`, `s := "a
+   { b"
+/* c
+   } */
+s
+`},
+	}
+
+	for _, tt := range tests {
+		got, err := format.Source([]byte(tt.input))
+		if err != nil {
+			t.Errorf("unexpected error %v <= %s", err, tt.input)
+			continue
+		}
+		if string(got) != tt.expected {
+			t.Errorf("expected %q, got=%q", tt.expected, got)
+		}
+	}
+}
+
+func TestSourceError(t *testing.T) {
+	if _, err := format.Source([]byte("x := (1")); err == nil {
+		t.Errorf("expected a syntax error")
+	}
+}
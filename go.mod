@@ -1,5 +1,5 @@
 module github.com/thingsme/thingscript
 
-go 1.20
+go 1.21
 
 require github.com/magefile/mage v1.15.0
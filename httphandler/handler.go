@@ -0,0 +1,234 @@
+// Package httphandler serves HTTP routes implemented in script.
+//
+// A script registers the routes with the handle function of the http package,
+//
+//	http := import("http")
+//	http.handle("/hook", func(req, res) {
+//	    evt := req.json()
+//	    res.status(202)
+//	    res.json({"accepted": evt["id"]})
+//	})
+//
+// and the host serves them with a Handler.
+//
+//	h, err := httphandler.Load(script)
+//	h.Timeout = 5 * time.Second
+//	http.ListenAndServe(":8080", h)
+//
+// Every request is served in a new environment where the script is evaluated again,
+// so nothing is shared between requests and the limits apply to each request separately.
+package httphandler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/thingsme/thingscript/ast"
+	"github.com/thingsme/thingscript/eval"
+	"github.com/thingsme/thingscript/lexer"
+	"github.com/thingsme/thingscript/object"
+	"github.com/thingsme/thingscript/parser"
+	"github.com/thingsme/thingscript/stdlib"
+)
+
+const (
+	// DefaultTimeout is the Timeout of a Handler by NewHandler.
+	DefaultTimeout = 10 * time.Second
+	// DefaultMaxBodySize is the MaxBodySize of a Handler by NewHandler.
+	DefaultMaxBodySize = 1 << 20
+)
+
+// Handler is an http.Handler which serves the routes registered by a script.
+type Handler struct {
+	program *ast.Program
+
+	// Timeout limits the evaluation of each request, unlimited if it is zero.
+	Timeout time.Duration
+	// MaxBodySize is the maximum size of a request body, unlimited if it is zero.
+	MaxBodySize int64
+	// Setup configures the environment of each request before the packages are registered,
+	// e.g. to set Environment.HTTPTransport or to register host packages.
+	Setup func(env *object.Environment)
+	// OnError is called with the errors of the script,
+	// the client receives "500 Internal Server Error" without the details.
+	OnError func(r *http.Request, err error)
+}
+
+var _ http.Handler = &Handler{}
+
+// NewHandler returns a Handler which serves the routes of program.
+func NewHandler(program *ast.Program) *Handler {
+	return &Handler{
+		program:     program,
+		Timeout:     DefaultTimeout,
+		MaxBodySize: DefaultMaxBodySize,
+	}
+}
+
+// Load parses script and returns a Handler of it.
+func Load(script string) (*Handler, error) {
+	p := parser.New(lexer.New(script))
+	program := p.ParseProgram()
+	if len(p.Errors()) > 0 {
+		return nil, errors.New(strings.Join(p.Errors(), "\n"))
+	}
+	return NewHandler(program), nil
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if h.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, h.Timeout)
+		defer cancel()
+	}
+
+	env := object.NewEnvironment()
+	env.Context = ctx
+	if h.Setup != nil {
+		h.Setup(env)
+	}
+	env.RegisterPackages(stdlib.Packages()...)
+	router := &routerPkg{}
+	if client, ok := env.Import("http"); ok {
+		router.client = client
+	}
+	env.RegisterPackages(router)
+
+	if ret := h.eval(func() object.Object { return eval.Eval(h.program, env) }); isError(ret) {
+		h.fail(w, r, ret.(*object.Error))
+		return
+	}
+	router.serving = true
+
+	mux := http.NewServeMux()
+	for _, rt := range router.routes {
+		fn := rt.fn
+		mux.HandleFunc(rt.pattern, func(w http.ResponseWriter, r *http.Request) {
+			h.serveRoute(w, r, fn)
+		})
+	}
+	mux.ServeHTTP(w, r)
+}
+
+func (h *Handler) serveRoute(w http.ResponseWriter, r *http.Request, fn object.Object) {
+	body := r.Body
+	if h.MaxBodySize > 0 {
+		body = http.MaxBytesReader(w, r.Body, h.MaxBodySize)
+	}
+	b, err := io.ReadAll(body)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			http.Error(w, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+
+	req := &Request{r: r, body: b}
+	res := &ResponseWriter{header: http.Header{}}
+	ret := h.eval(func() object.Object { return eval.Apply(fn, req, res) })
+	if isError(ret) {
+		h.fail(w, r, ret.(*object.Error))
+		return
+	}
+	if !res.written && ret != nil && ret != eval.NULL {
+		// the return value is the body if the route did not write
+		if s, ok := ret.(*object.String); ok {
+			res.writeString(s.Value)
+		} else if errObj := res.writeJSON(ret); errObj != nil {
+			h.fail(w, r, errObj)
+			return
+		}
+	}
+	res.flush(w)
+}
+
+// eval calls fn and converts a panic into an error.
+func (h *Handler) eval(fn func() object.Object) (ret object.Object) {
+	defer func() {
+		if p := recover(); p != nil {
+			ret = object.Errorf("panic: %v", p)
+		}
+	}()
+	return fn()
+}
+
+func (h *Handler) fail(w http.ResponseWriter, r *http.Request, errObj *object.Error) {
+	if h.OnError != nil {
+		h.OnError(r, fmt.Errorf("%s %s: %s", r.Method, r.URL.Path, errObj.Message))
+	}
+	http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+}
+
+func isError(obj object.Object) bool {
+	return obj != nil && obj.Type() == object.ERROR_OBJ
+}
+
+type route struct {
+	pattern string
+	fn      object.Object
+}
+
+// routerPkg replaces the http package of the request environment,
+// it adds handle to the members of the http client.
+type routerPkg struct {
+	client  object.Package
+	routes  []route
+	serving bool
+}
+
+var _ object.Package = &routerPkg{}
+
+func (rp *routerPkg) Type() object.ObjectType { return object.PACKAGE_OBJ }
+
+func (rp *routerPkg) Inspect() string { return "package http" }
+
+func (rp *routerPkg) Name() string { return "http" }
+
+func (rp *routerPkg) OnLoad(env *object.Environment) {}
+
+func (rp *routerPkg) Member(name string) object.MemberFunc {
+	switch name {
+	case "handle":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return object.Errorf("wrong number of arguments. want=2 got=%d", len(args))
+			}
+			if rp.serving {
+				return object.Errorf("routes can not be registered while serving")
+			}
+			pattern, ok := args[0].(*object.String)
+			if !ok {
+				return object.Errorf("route pattern must be string, got %s", args[0].Type())
+			}
+			if !strings.HasPrefix(pattern.Value, "/") {
+				return object.Errorf("route pattern must start with '/', got %q", pattern.Value)
+			}
+			switch args[1].(type) {
+			case *object.Function, *object.Builtin, *object.BoundMethod:
+			default:
+				return object.Errorf("route handler must be function, got %s", args[1].Type())
+			}
+			for _, rt := range rp.routes {
+				if rt.pattern == pattern.Value {
+					return object.Errorf("route %q is already registered", pattern.Value)
+				}
+			}
+			rp.routes = append(rp.routes, route{pattern: pattern.Value, fn: args[1]})
+			return nil
+		}
+	default:
+		if rp.client != nil {
+			return rp.client.Member(name)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,176 @@
+package httphandler_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/thingsme/thingscript/httphandler"
+	"github.com/thingsme/thingscript/object"
+)
+
+const script = `
+http := import("http")
+count := 0
+
+http.handle("/hook", func(req, res) {
+    count += 1
+    evt := req.json()
+    res.status(202)
+    res.header("X-Hook", "1")
+    res.json({"accepted": evt["id"], "method": req.method, "count": count})
+})
+
+http.handle("/hello", func(req, res) {
+    "hello " + (req.query()["name"] ?? "world")
+})
+
+http.handle("/echo", func(req, res) {
+    res.header("Content-Type", "text/plain")
+    res.write(req.header("X-Msg"))
+    res.write(" " + req.body)
+})
+
+http.handle("/list/", func(req, res) {
+    [req.path, req.url]
+})
+
+http.handle("/fail", func(req, res) {
+    res.write("partial")
+    undefined_func()
+})
+
+http.handle("/loop", func(req, res) {
+    while true { }
+})
+
+http.handle("/fetch", func(req, res) {
+    http.get(req.query()["url"]).body
+})
+`
+
+func newHandler(t *testing.T) (*httphandler.Handler, *[]string) {
+	h, err := httphandler.Load(script)
+	if err != nil {
+		t.Fatalf("load error: %s", err)
+	}
+	errs := []string{}
+	h.OnError = func(r *http.Request, err error) {
+		errs = append(errs, err.Error())
+	}
+	return h, &errs
+}
+
+func serve(h http.Handler, method, target, body string, header map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w
+}
+
+func TestHandler(t *testing.T) {
+	h, errs := newHandler(t)
+	tests := []struct {
+		method   string
+		target   string
+		body     string
+		header   map[string]string
+		status   int
+		expected string
+	}{
+		{"POST", "/hook", `{"id": 7}`, nil, 202, `{"accepted":7,"count":1,"method":"POST"}`},
+		{"GET", "/hello", "", nil, 200, "hello world"},
+		{"GET", "/hello?name=thing", "", nil, 200, "hello thing"},
+		{"PUT", "/echo", "body", map[string]string{"X-Msg": "msg"}, 200, "msg body"},
+		{"GET", "/list/a?x=1", "", nil, 200, `["/list/a","/list/a?x=1"]`},
+		{"GET", "/unknown", "", nil, 404, "404 page not found\n"},
+		{"GET", "/fail", "", nil, 500, "Internal Server Error\n"},
+		{"POST", "/hook", `{`, nil, 500, "Internal Server Error\n"},
+	}
+	for _, tt := range tests {
+		w := serve(h, tt.method, tt.target, tt.body, tt.header)
+		if w.Code != tt.status || w.Body.String() != tt.expected {
+			t.Errorf("%s %s expected %d %q, got=%d %q", tt.method, tt.target, tt.status, tt.expected, w.Code, w.Body.String())
+		}
+	}
+	expectedErrs := []string{
+		"GET /fail: identifier not found: undefined_func",
+		"POST /hook: invalid json body: unexpected EOF",
+	}
+	if strings.Join(*errs, "\n") != strings.Join(expectedErrs, "\n") {
+		t.Errorf("unexpected errors %q", *errs)
+	}
+}
+
+func TestHandlerIsolation(t *testing.T) {
+	h, _ := newHandler(t)
+	for i := 0; i < 3; i++ {
+		w := serve(h, "POST", "/hook", `{"id": 1}`, nil)
+		if body := w.Body.String(); body != `{"accepted":1,"count":1,"method":"POST"}` {
+			t.Errorf("state should not be shared between requests, got=%s", body)
+		}
+		if w.Header().Get("Content-Type") != "application/json" || w.Header().Get("X-Hook") != "1" {
+			t.Errorf("unexpected headers %v", w.Header())
+		}
+	}
+}
+
+func TestHandlerLimits(t *testing.T) {
+	h, errs := newHandler(t)
+	h.Timeout = 20 * time.Millisecond
+	h.MaxBodySize = 8
+
+	start := time.Now()
+	w := serve(h, "GET", "/loop", "", nil)
+	if w.Code != 500 || time.Since(start) > time.Second {
+		t.Errorf("infinite loop should be stopped, got=%d after %s", w.Code, time.Since(start))
+	}
+	if len(*errs) != 1 || (*errs)[0] != "GET /loop: evaluation stopped: context deadline exceeded" {
+		t.Errorf("unexpected errors %q", *errs)
+	}
+
+	w = serve(h, "POST", "/hook", `{"id": 123456789}`, nil)
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("large body should be rejected, got=%d", w.Code)
+	}
+}
+
+func TestHandlerSetup(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "from upstream")
+	}))
+	defer upstream.Close()
+
+	h, errs := newHandler(t)
+	w := serve(h, "GET", "/fetch?url="+upstream.URL, "", nil)
+	if w.Code != 500 || (*errs)[0] != "GET /fetch: http transport is not available" {
+		t.Errorf("http client should be denied without transport, got=%d %q", w.Code, *errs)
+	}
+
+	h.Setup = func(env *object.Environment) {
+		env.HTTPTransport = http.DefaultTransport
+	}
+	w = serve(h, "GET", "/fetch?url="+upstream.URL, "", nil)
+	if w.Code != 200 || w.Body.String() != "from upstream" {
+		t.Errorf("unexpected response %d %q", w.Code, w.Body.String())
+	}
+}
+
+func TestLoad(t *testing.T) {
+	if _, err := httphandler.Load(`x := `); err == nil {
+		t.Errorf("Load should fail on parse errors")
+	}
+	h, _ := httphandler.Load(`import("http").handle("hook", func(req, res) { 1 })`)
+	var got error
+	h.OnError = func(r *http.Request, err error) { got = err }
+	serve(h, "GET", "/hook", "", nil)
+	if got == nil || got.Error() != `GET /hook: route pattern must start with '/', got "hook"` {
+		t.Errorf("unexpected error %v", got)
+	}
+}
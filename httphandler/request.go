@@ -0,0 +1,201 @@
+package httphandler
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/thingsme/thingscript/eval"
+	"github.com/thingsme/thingscript/object"
+)
+
+// Request is the request object passed to the routes.
+type Request struct {
+	r    *http.Request
+	body []byte
+}
+
+var _ object.Object = &Request{}
+
+func (req *Request) Type() object.ObjectType { return "http.Request" }
+
+func (req *Request) Inspect() string {
+	return fmt.Sprintf("http.Request(%s %s)", req.r.Method, req.r.URL.RequestURI())
+}
+
+func (req *Request) Member(name string) object.MemberFunc {
+	switch name {
+	case "method", "path", "url", "remote_addr", "body":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 0 {
+				return object.Errorf("wrong number of arguments. want=0 got=%d", len(args))
+			}
+			var ret string
+			switch name {
+			case "method":
+				ret = req.r.Method
+			case "path":
+				ret = req.r.URL.Path
+			case "url":
+				ret = req.r.URL.RequestURI()
+			case "remote_addr":
+				ret = req.r.RemoteAddr
+			case "body":
+				ret = string(req.body)
+			}
+			return &object.String{Value: ret}
+		}
+	case "query", "headers":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 0 {
+				return object.Errorf("wrong number of arguments. want=0 got=%d", len(args))
+			}
+			var values map[string][]string
+			if name == "query" {
+				values = req.r.URL.Query()
+			} else {
+				values = req.r.Header
+			}
+			m := make(map[string]any, len(values))
+			for k, vs := range values {
+				m[k] = strings.Join(vs, ", ")
+			}
+			ret, _ := object.FromNative(m)
+			return ret
+		}
+	case "header":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return object.Errorf("wrong number of arguments. want=1 got=%d", len(args))
+			}
+			key, ok := args[0].(*object.String)
+			if !ok {
+				return object.Errorf("argument to header must be string, got %s", args[0].Type())
+			}
+			vs := req.r.Header.Values(key.Value)
+			if len(vs) == 0 {
+				return eval.NULL
+			}
+			return &object.String{Value: strings.Join(vs, ", ")}
+		}
+	case "json":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 0 {
+				return object.Errorf("wrong number of arguments. want=0 got=%d", len(args))
+			}
+			ret, err := object.DecodeJSON(req.body)
+			if err != nil {
+				return object.Errorf("invalid json body: %s", err.Error())
+			}
+			return ret
+		}
+	default:
+		return nil
+	}
+}
+
+// ResponseWriter is the response object passed to the routes.
+// The response is buffered and sent after the route returns,
+// so a failing route results in "500 Internal Server Error" instead of a partial response.
+type ResponseWriter struct {
+	status  int
+	header  http.Header
+	body    bytes.Buffer
+	written bool
+}
+
+var _ object.Object = &ResponseWriter{}
+
+func (res *ResponseWriter) Type() object.ObjectType { return "http.ResponseWriter" }
+
+func (res *ResponseWriter) Inspect() string { return "http.ResponseWriter" }
+
+func (res *ResponseWriter) Member(name string) object.MemberFunc {
+	switch name {
+	case "status":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return object.Errorf("wrong number of arguments. want=1 got=%d", len(args))
+			}
+			code, ok := args[0].(*object.Integer)
+			if !ok {
+				return object.Errorf("status must be int, got %s", args[0].Type())
+			}
+			if code.Value < 100 || code.Value > 999 {
+				return object.Errorf("invalid status %d", code.Value)
+			}
+			res.status = int(code.Value)
+			return nil
+		}
+	case "header":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return object.Errorf("wrong number of arguments. want=2 got=%d", len(args))
+			}
+			key, ok := args[0].(*object.String)
+			if !ok {
+				return object.Errorf("header name must be string, got %s", args[0].Type())
+			}
+			value, ok := args[1].(*object.String)
+			if !ok {
+				return object.Errorf("header value must be string, got %s", args[1].Type())
+			}
+			res.header.Set(key.Value, value.Value)
+			return nil
+		}
+	case "write":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return object.Errorf("wrong number of arguments. want=1 got=%d", len(args))
+			}
+			s, ok := args[0].(*object.String)
+			if !ok {
+				return object.Errorf("argument to write must be string, got %s", args[0].Type())
+			}
+			return &object.Integer{Value: int64(res.writeString(s.Value))}
+		}
+	case "json":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return object.Errorf("wrong number of arguments. want=1 got=%d", len(args))
+			}
+			if errObj := res.writeJSON(args[0]); errObj != nil {
+				return errObj
+			}
+			return nil
+		}
+	default:
+		return nil
+	}
+}
+
+func (res *ResponseWriter) writeString(s string) int {
+	res.written = true
+	n, _ := res.body.WriteString(s)
+	return n
+}
+
+func (res *ResponseWriter) writeJSON(obj object.Object) *object.Error {
+	b, err := object.EncodeJSON(obj)
+	if err != nil {
+		return object.Errorf("%s", err)
+	}
+	if res.header.Get("Content-Type") == "" {
+		res.header.Set("Content-Type", "application/json")
+	}
+	res.written = true
+	res.body.Write(b)
+	return nil
+}
+
+func (res *ResponseWriter) flush(w http.ResponseWriter) {
+	for k, vs := range res.header {
+		w.Header()[k] = vs
+	}
+	if res.status == 0 {
+		res.status = http.StatusOK
+	}
+	w.WriteHeader(res.status)
+	w.Write(res.body.Bytes())
+}
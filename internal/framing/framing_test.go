@@ -0,0 +1,58 @@
+package framing_test
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/thingsme/thingscript/internal/framing"
+)
+
+func TestReadWrite(t *testing.T) {
+	var buf bytes.Buffer
+	for _, msg := range []string{`{"seq":1}`, ``, `{"text":"line1\nline2"}`} {
+		if err := framing.Write(&buf, []byte(msg)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	r := bufio.NewReader(&buf)
+	for _, expected := range []string{`{"seq":1}`, ``, `{"text":"line1\nline2"}`} {
+		msg, err := framing.Read(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(msg) != expected {
+			t.Errorf("expected %s, got=%s", expected, msg)
+		}
+	}
+	if _, err := framing.Read(r); err != io.EOF {
+		t.Errorf("expected EOF, got=%v", err)
+	}
+}
+
+func TestReadHeaders(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"Content-Type: application/json\r\ncontent-length: 2\r\n\r\n{}", `{}`},
+		{"Content-Length: 2\n\n{}", `{}`},
+		{"\r\n{}", `ERROR: missing Content-Length`},
+		{"Content-Length: x\r\n\r\n", `ERROR: invalid Content-Length "x"`},
+		{"Content-Length\r\n\r\n", `ERROR: invalid header "Content-Length"`},
+		{"Content-Length: 5\r\n\r\n{}", `ERROR: reading content: unexpected EOF`},
+		{"Content-Length: 2", `ERROR: reading header: EOF`},
+	}
+	for _, tt := range tests {
+		msg, err := framing.Read(bufio.NewReader(strings.NewReader(tt.input)))
+		got := string(msg)
+		if err != nil {
+			got = "ERROR: " + err.Error()
+		}
+		if got != tt.expected {
+			t.Errorf("expected %s, got=%s <= %q", tt.expected, got, tt.input)
+		}
+	}
+}
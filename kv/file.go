@@ -0,0 +1,146 @@
+package kv
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// FileStore is a Store persisted in a JSON file.
+// The whole file is rewritten atomically on every change, so it fits the small states of scripts.
+type FileStore struct {
+	// Now returns the current time for TTL, it defaults to time.Now.
+	Now func() time.Time
+
+	mu      sync.Mutex
+	path    string
+	entries map[string]entry
+}
+
+var _ Store = &FileStore{}
+
+// OpenFileStore loads the store from the file at path, the file is created on the first change.
+func OpenFileStore(path string) (*FileStore, error) {
+	store := &FileStore{path: path, entries: make(map[string]entry)}
+	b, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, &store.entries); err != nil {
+		return nil, err
+	}
+	if store.entries == nil {
+		// the file holds null
+		store.entries = make(map[string]entry)
+	}
+	return store, nil
+}
+
+func (f *FileStore) now() time.Time {
+	if f.Now != nil {
+		return f.Now()
+	}
+	return time.Now()
+}
+
+func (f *FileStore) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	e, ok := f.entries[key]
+	if !ok || e.expired(f.now()) {
+		return nil, false, nil
+	}
+	return append([]byte(nil), e.Value...), true, nil
+}
+
+func (f *FileStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	prev, existed := f.entries[key]
+	f.entries[key] = newEntry(value, ttl, f.now())
+	if err := f.save(); err != nil {
+		f.restore(key, prev, existed)
+		return err
+	}
+	return nil
+}
+
+func (f *FileStore) Delete(ctx context.Context, key string) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	prev, ok := f.entries[key]
+	if !ok {
+		return false, nil
+	}
+	delete(f.entries, key)
+	if err := f.save(); err != nil {
+		f.restore(key, prev, true)
+		return false, err
+	}
+	return !prev.expired(f.now()), nil
+}
+
+func (f *FileStore) Keys(ctx context.Context, prefix string) ([]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return keys(f.entries, prefix, f.now()), nil
+}
+
+func (f *FileStore) CompareAndSet(ctx context.Context, key string, old []byte, value []byte, ttl time.Duration) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	now := f.now()
+	if !matches(f.entries, key, old, now) {
+		return false, nil
+	}
+	prev, existed := f.entries[key]
+	f.entries[key] = newEntry(value, ttl, now)
+	if err := f.save(); err != nil {
+		f.restore(key, prev, existed)
+		return false, err
+	}
+	return true, nil
+}
+
+func (f *FileStore) restore(key string, prev entry, existed bool) {
+	if existed {
+		f.entries[key] = prev
+	} else {
+		delete(f.entries, key)
+	}
+}
+
+// save writes the entries except the expired ones to a temporary file and renames it to the path.
+func (f *FileStore) save() error {
+	now := f.now()
+	for k, e := range f.entries {
+		if e.expired(now) {
+			delete(f.entries, k)
+		}
+	}
+	b, err := json.Marshal(f.entries)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(f.path), filepath.Base(f.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), f.path)
+}
@@ -0,0 +1,124 @@
+// Package kv implements object.KVStore, the key-value store behind the kv package of scripts,
+// with an in-memory store and a file-backed store.
+//
+// The values are opaque bytes for the stores, the kv package of scripts encodes them in JSON.
+package kv
+
+import (
+	"bytes"
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/thingsme/thingscript/object"
+)
+
+// Store is the key-value store which the host provides through Environment.KV.
+type Store = object.KVStore
+
+type entry struct {
+	Value   []byte `json:"value"`
+	Expires int64  `json:"expires,omitempty"` // unix nano, 0 if the entry does not expire
+}
+
+func (e entry) expired(now time.Time) bool {
+	return e.Expires != 0 && now.UnixNano() >= e.Expires
+}
+
+func newEntry(value []byte, ttl time.Duration, now time.Time) entry {
+	e := entry{Value: append([]byte(nil), value...)}
+	if ttl > 0 {
+		e.Expires = now.Add(ttl).UnixNano()
+	}
+	return e
+}
+
+// MemoryStore is a Store in memory, it is useful for tests and short-lived hosts.
+type MemoryStore struct {
+	// Now returns the current time for TTL, it defaults to time.Now.
+	Now func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]entry
+}
+
+var _ Store = &MemoryStore{}
+
+// NewMemoryStore returns an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: make(map[string]entry)}
+}
+
+func (m *MemoryStore) now() time.Time {
+	if m.Now != nil {
+		return m.Now()
+	}
+	return time.Now()
+}
+
+func (m *MemoryStore) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, ok := m.entries[key]
+	if !ok || e.expired(m.now()) {
+		return nil, false, nil
+	}
+	return append([]byte(nil), e.Value...), true, nil
+}
+
+func (m *MemoryStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries[key] = newEntry(value, ttl, m.now())
+	return nil
+}
+
+func (m *MemoryStore) Delete(ctx context.Context, key string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, ok := m.entries[key]
+	delete(m.entries, key)
+	return ok && !e.expired(m.now()), nil
+}
+
+func (m *MemoryStore) Keys(ctx context.Context, prefix string) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return keys(m.entries, prefix, m.now()), nil
+}
+
+func (m *MemoryStore) CompareAndSet(ctx context.Context, key string, old []byte, value []byte, ttl time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.now()
+	if !matches(m.entries, key, old, now) {
+		return false, nil
+	}
+	m.entries[key] = newEntry(value, ttl, now)
+	return true, nil
+}
+
+func keys(entries map[string]entry, prefix string, now time.Time) []string {
+	ret := []string{}
+	for k, e := range entries {
+		if strings.HasPrefix(k, prefix) && !e.expired(now) {
+			ret = append(ret, k)
+		}
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+// matches reports whether the value of key is old, or key does not exist if old is nil.
+func matches(entries map[string]entry, key string, old []byte, now time.Time) bool {
+	e, ok := entries[key]
+	if ok && e.expired(now) {
+		ok = false
+	}
+	if old == nil {
+		return !ok
+	}
+	return ok && bytes.Equal(e.Value, old)
+}
@@ -0,0 +1,154 @@
+package kv_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/thingsme/thingscript/kv"
+)
+
+type clock struct {
+	mu sync.Mutex
+	tm time.Time
+}
+
+func (c *clock) now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.tm
+}
+
+func (c *clock) advance(d time.Duration) {
+	c.mu.Lock()
+	c.tm = c.tm.Add(d)
+	c.mu.Unlock()
+}
+
+func testStore(t *testing.T, store kv.Store, c *clock) {
+	ctx := context.Background()
+	if _, ok, _ := store.Get(ctx, "a"); ok {
+		t.Error("empty store should not have a")
+	}
+	store.Set(ctx, "a", []byte("1"), 0)
+	store.Set(ctx, "b/1", []byte("2"), time.Minute)
+	store.Set(ctx, "b/2", []byte("3"), 0)
+	if v, ok, _ := store.Get(ctx, "a"); !ok || string(v) != "1" {
+		t.Errorf("wrong value of a, got=%q", v)
+	}
+	if keys, _ := store.Keys(ctx, "b/"); !reflect.DeepEqual(keys, []string{"b/1", "b/2"}) {
+		t.Errorf("wrong keys, got=%v", keys)
+	}
+
+	c.advance(time.Minute)
+	if _, ok, _ := store.Get(ctx, "b/1"); ok {
+		t.Error("b/1 should be expired")
+	}
+	if keys, _ := store.Keys(ctx, ""); !reflect.DeepEqual(keys, []string{"a", "b/2"}) {
+		t.Errorf("wrong keys, got=%v", keys)
+	}
+	if ok, _ := store.Delete(ctx, "b/1"); ok {
+		t.Error("expired key should not be deleted")
+	}
+	if ok, _ := store.Delete(ctx, "b/2"); !ok {
+		t.Error("b/2 should be deleted")
+	}
+
+	if ok, _ := store.CompareAndSet(ctx, "a", []byte("2"), []byte("3"), 0); ok {
+		t.Error("CompareAndSet should fail with different value")
+	}
+	if ok, _ := store.CompareAndSet(ctx, "a", []byte("1"), []byte("3"), 0); !ok {
+		t.Error("CompareAndSet should succeed")
+	}
+	if ok, _ := store.CompareAndSet(ctx, "c", nil, []byte("1"), time.Second); !ok {
+		t.Error("CompareAndSet should create c")
+	}
+	if ok, _ := store.CompareAndSet(ctx, "c", nil, []byte("2"), 0); ok {
+		t.Error("CompareAndSet should not overwrite c")
+	}
+	c.advance(time.Second)
+	if ok, _ := store.CompareAndSet(ctx, "c", nil, []byte("2"), 0); !ok {
+		t.Error("CompareAndSet should create expired c")
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	c := &clock{tm: time.Unix(0, 0)}
+	store := kv.NewMemoryStore()
+	store.Now = c.now
+	testStore(t, store, c)
+}
+
+func TestFileStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	c := &clock{tm: time.Unix(0, 0)}
+	store, err := kv.OpenFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.Now = c.now
+	testStore(t, store, c)
+
+	reopened, err := kv.OpenFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reopened.Now = c.now
+	if keys, _ := reopened.Keys(context.Background(), ""); !reflect.DeepEqual(keys, []string{"a", "c"}) {
+		t.Errorf("wrong keys of reopened store, got=%v", keys)
+	}
+	if v, _, _ := reopened.Get(context.Background(), "a"); string(v) != "3" {
+		t.Errorf("wrong value of reopened store, got=%q", v)
+	}
+
+	os.WriteFile(path, []byte("{"), 0o644)
+	if _, err := kv.OpenFileStore(path); err == nil {
+		t.Error("broken file should not be opened")
+	}
+	os.WriteFile(path, []byte("null"), 0o644)
+	store, err = kv.OpenFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Set(context.Background(), "a", []byte("1"), 0); err != nil {
+		t.Errorf("Set to the store of null failed, %v", err)
+	}
+	missing := filepath.Join(t.TempDir(), "missing", "state.json")
+	store, _ = kv.OpenFileStore(missing)
+	if err := store.Set(context.Background(), "a", []byte("1"), 0); err == nil {
+		t.Error("Set should fail without the directory")
+	}
+	if _, ok, _ := store.Get(context.Background(), "a"); ok {
+		t.Error("failed Set should not change the store")
+	}
+}
+
+func TestConcurrentCompareAndSet(t *testing.T) {
+	store := kv.NewMemoryStore()
+	ctx := context.Background()
+	var wg sync.WaitGroup
+	succeeded := make(chan bool, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ok, _ := store.CompareAndSet(ctx, "lock", nil, []byte("1"), 0)
+			succeeded <- ok
+		}()
+	}
+	wg.Wait()
+	close(succeeded)
+	n := 0
+	for ok := range succeeded {
+		if ok {
+			n++
+		}
+	}
+	if n != 1 {
+		t.Errorf("only one CompareAndSet should succeed, got=%d", n)
+	}
+}
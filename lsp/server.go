@@ -0,0 +1,621 @@
+// Package lsp implements the Language Server Protocol for the scripts, so that editors can check,
+// navigate, complete and format them.
+//
+//	s := lsp.NewServer(os.Stdin, os.Stdout)
+//	s.NewEnvironment = func() *object.Environment { ... }
+//	err := s.Serve()
+//
+// The documents are synchronized in full and checked by the checker package on every change.
+// The completion uses the last version of a document without syntax errors,
+// since the document being typed often has them.
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf16"
+
+	"github.com/thingsme/thingscript/ast"
+	"github.com/thingsme/thingscript/checker"
+	"github.com/thingsme/thingscript/format"
+	"github.com/thingsme/thingscript/internal/framing"
+	"github.com/thingsme/thingscript/object"
+	"github.com/thingsme/thingscript/token"
+)
+
+// tabSize is the width of a tab in the columns of the lexer.
+const tabSize = 4
+
+// The error codes of JSON-RPC.
+const (
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+	codeInternalError  = -32603
+)
+
+// The kinds of the protocol.
+const (
+	severityError = 1
+
+	completionFunction = 3
+	completionVariable = 6
+	completionModule   = 9
+
+	symbolFunction = 12
+	symbolVariable = 13
+)
+
+// Server is the language server serving a client.
+type Server struct {
+	// NewEnvironment returns the environment providing the builtins, the packages and the predefined variables.
+	// It is called once and defaults to object.NewEnvironment.
+	NewEnvironment func() *object.Environment
+
+	in  *bufio.Reader
+	out io.Writer
+	wmu sync.Mutex
+
+	env      *object.Environment
+	docs     map[string]*document
+	shutdown bool
+}
+
+type document struct {
+	uri    string
+	text   string
+	lines  []string
+	result *checker.Result
+	// valid is the result of the last version without syntax errors
+	valid *checker.Result
+}
+
+// NewServer returns the server reading the requests from in and writing the responses and the notifications to out.
+func NewServer(in io.Reader, out io.Writer) *Server {
+	return &Server{
+		in:   bufio.NewReader(in),
+		out:  out,
+		docs: make(map[string]*document),
+	}
+}
+
+type message struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result"`
+	Error   *responseError  `json:"error,omitempty"`
+}
+
+type responseError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *responseError) Error() string { return e.Message }
+
+type notification struct {
+	JSONRPC string `json:"jsonrpc"`
+	Method  string `json:"method"`
+	Params  any    `json:"params"`
+}
+
+type position struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+type rng struct {
+	Start position `json:"start"`
+	End   position `json:"end"`
+}
+
+type textDocumentPosition struct {
+	TextDocument struct {
+		URI string `json:"uri"`
+	} `json:"textDocument"`
+	Position position `json:"position"`
+}
+
+// Serve serves the requests until the "exit" notification or the end of the input.
+func (s *Server) Serve() error {
+	if s.NewEnvironment == nil {
+		s.env = object.NewEnvironment()
+	} else {
+		s.env = s.NewEnvironment()
+	}
+	for {
+		content, err := framing.Read(s.in)
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		var msg message
+		if err := json.Unmarshal(content, &msg); err != nil {
+			return fmt.Errorf("invalid message: %w", err)
+		}
+		if msg.Method == "exit" {
+			return nil
+		}
+		result, err := s.handle(&msg)
+		if len(msg.ID) == 0 {
+			// a notification has no response
+			continue
+		}
+		res := &response{JSONRPC: "2.0", ID: msg.ID, Result: result}
+		if err != nil {
+			var resErr *responseError
+			if !errors.As(err, &resErr) {
+				resErr = &responseError{Code: codeInternalError, Message: err.Error()}
+			}
+			res.Result = nil
+			res.Error = resErr
+		}
+		if err := s.send(res); err != nil {
+			return err
+		}
+	}
+}
+
+// handle returns the result of the response to msg.
+func (s *Server) handle(msg *message) (any, error) {
+	if s.shutdown && len(msg.ID) > 0 {
+		return nil, &responseError{Code: codeInvalidRequest, Message: "server is shut down"}
+	}
+	switch msg.Method {
+	case "initialize":
+		return map[string]any{
+			"capabilities": map[string]any{
+				"textDocumentSync":           1, // full
+				"hoverProvider":              true,
+				"definitionProvider":         true,
+				"completionProvider":         map[string]any{"triggerCharacters": []string{"."}},
+				"documentSymbolProvider":     true,
+				"documentFormattingProvider": true,
+			},
+			"serverInfo": map[string]any{"name": "thingscript"},
+		}, nil
+	case "shutdown":
+		s.shutdown = true
+		return nil, nil
+	case "textDocument/didOpen":
+		var params struct {
+			TextDocument struct {
+				URI  string `json:"uri"`
+				Text string `json:"text"`
+			} `json:"textDocument"`
+		}
+		if err := unmarshalParams(msg, &params); err != nil {
+			return nil, err
+		}
+		return nil, s.update(params.TextDocument.URI, params.TextDocument.Text)
+	case "textDocument/didChange":
+		var params struct {
+			TextDocument struct {
+				URI string `json:"uri"`
+			} `json:"textDocument"`
+			ContentChanges []struct {
+				Text string `json:"text"`
+			} `json:"contentChanges"`
+		}
+		if err := unmarshalParams(msg, &params); err != nil {
+			return nil, err
+		}
+		if len(params.ContentChanges) == 0 {
+			return nil, nil
+		}
+		return nil, s.update(params.TextDocument.URI, params.ContentChanges[len(params.ContentChanges)-1].Text)
+	case "textDocument/didClose":
+		var params textDocumentPosition
+		if err := unmarshalParams(msg, &params); err != nil {
+			return nil, err
+		}
+		delete(s.docs, params.TextDocument.URI)
+		return nil, s.publishDiagnostics(params.TextDocument.URI, nil)
+	case "textDocument/hover":
+		return s.withPosition(msg, s.hover)
+	case "textDocument/definition":
+		return s.withPosition(msg, s.definition)
+	case "textDocument/completion":
+		return s.withPosition(msg, s.completion)
+	case "textDocument/documentSymbol":
+		var params textDocumentPosition
+		if err := unmarshalParams(msg, &params); err != nil {
+			return nil, err
+		}
+		doc, err := s.document(params.TextDocument.URI)
+		if err != nil {
+			return nil, err
+		}
+		return documentSymbols(doc), nil
+	case "textDocument/formatting":
+		var params textDocumentPosition
+		if err := unmarshalParams(msg, &params); err != nil {
+			return nil, err
+		}
+		doc, err := s.document(params.TextDocument.URI)
+		if err != nil {
+			return nil, err
+		}
+		return formatting(doc), nil
+	default:
+		if len(msg.ID) > 0 && !strings.HasPrefix(msg.Method, "$/") {
+			return nil, &responseError{Code: codeMethodNotFound, Message: fmt.Sprintf("method %q not found", msg.Method)}
+		}
+		return nil, nil
+	}
+}
+
+func unmarshalParams(msg *message, v any) error {
+	if err := json.Unmarshal(msg.Params, v); err != nil {
+		return &responseError{Code: codeInvalidParams, Message: fmt.Sprintf("invalid params of %s: %s", msg.Method, err)}
+	}
+	return nil
+}
+
+func (s *Server) document(uri string) (*document, error) {
+	doc, ok := s.docs[uri]
+	if !ok {
+		return nil, &responseError{Code: codeInvalidParams, Message: fmt.Sprintf("document %q is not open", uri)}
+	}
+	return doc, nil
+}
+
+// withPosition calls f with the document and the position of the params of msg.
+func (s *Server) withPosition(msg *message, f func(doc *document, pos token.Position) any) (any, error) {
+	var params textDocumentPosition
+	if err := unmarshalParams(msg, &params); err != nil {
+		return nil, err
+	}
+	doc, err := s.document(params.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+	return f(doc, fromPosition(doc.lines, params.Position)), nil
+}
+
+// update checks the new text of the document and publishes the diagnostics.
+func (s *Server) update(uri, text string) error {
+	doc, ok := s.docs[uri]
+	if !ok {
+		doc = &document{uri: uri}
+		s.docs[uri] = doc
+	}
+	doc.text = text
+	doc.lines = strings.Split(text, "\n")
+	doc.result = checker.Check(text, s.env)
+	if doc.result.Scopes != nil {
+		doc.valid = doc.result
+	}
+	diagnostics := []map[string]any{}
+	for _, d := range doc.result.Diagnostics {
+		diagnostics = append(diagnostics, map[string]any{
+			"range":    wordRange(doc.lines, d.Pos),
+			"severity": severityError,
+			"source":   "thingscript",
+			"message":  d.Message,
+		})
+	}
+	return s.publishDiagnostics(uri, diagnostics)
+}
+
+func (s *Server) publishDiagnostics(uri string, diagnostics []map[string]any) error {
+	if diagnostics == nil {
+		diagnostics = []map[string]any{}
+	}
+	return s.send(&notification{JSONRPC: "2.0", Method: "textDocument/publishDiagnostics", Params: map[string]any{
+		"uri":         uri,
+		"diagnostics": diagnostics,
+	}})
+}
+
+// identifierAt returns the identifier of result at pos.
+func identifierAt(result *checker.Result, pos token.Position) *ast.Identifier {
+	at := func(id *ast.Identifier) bool {
+		p := id.Token.Position
+		return p.Line == pos.Line && p.Column <= pos.Column && pos.Column <= p.Column+len([]rune(id.Value))
+	}
+	for id := range result.Defs {
+		if at(id) {
+			return id
+		}
+	}
+	for id := range result.Uses {
+		if at(id) {
+			return id
+		}
+	}
+	for id := range result.Members {
+		if at(id) {
+			return id
+		}
+	}
+	return nil
+}
+
+func (s *Server) hover(doc *document, pos token.Position) any {
+	id := identifierAt(doc.result, pos)
+	if id == nil {
+		return nil
+	}
+	var text string
+	if m, ok := doc.result.Members[id]; ok {
+		text = fmt.Sprintf("%s.%s", m.Package.Name(), id.Value)
+		if m.Doc != nil {
+			text = describe(m.Package.Name()+".", m.Doc)
+		}
+	} else {
+		sym := doc.result.Defs[id]
+		if sym == nil {
+			sym = doc.result.Uses[id]
+		}
+		if sym == nil {
+			return nil
+		}
+		text = describeSymbol(sym)
+	}
+	return map[string]any{
+		"contents": map[string]any{"kind": "markdown", "value": text},
+		"range":    identifierRange(doc.lines, id.Token.Position, id.Value),
+	}
+}
+
+// describe returns the markdown of the member, prefix is prepended to its signature.
+func describe(prefix string, doc *object.MemberDoc) string {
+	signature := doc.Signature
+	if signature == "" {
+		signature = doc.Name
+	}
+	text := "```thingscript\n" + prefix + signature + "\n```"
+	if doc.Doc != "" {
+		text += "\n\n" + doc.Doc
+	}
+	return text
+}
+
+func describeSymbol(sym *checker.Symbol) string {
+	switch sym.Kind {
+	case checker.Function:
+		return fmt.Sprintf("```thingscript\nfunc %s(%s)\n```", sym.Name, strings.Join(sym.Params, ", "))
+	case checker.Package:
+		return fmt.Sprintf("```thingscript\nvar %s\n```\n\npackage %s", sym.Name, sym.Package.Name())
+	case checker.Builtin:
+		if sym.Doc != nil {
+			return describe("", sym.Doc)
+		}
+	}
+	return fmt.Sprintf("```thingscript\n%s %s\n```", sym.Kind, sym.Name)
+}
+
+func (s *Server) definition(doc *document, pos token.Position) any {
+	id := identifierAt(doc.result, pos)
+	if id == nil {
+		return nil
+	}
+	sym := doc.result.Defs[id]
+	if sym == nil {
+		sym = doc.result.Uses[id]
+	}
+	if sym == nil || sym.Pos.Line == 0 {
+		return nil
+	}
+	return []map[string]any{{"uri": doc.uri, "range": identifierRange(doc.lines, sym.Pos, sym.Name)}}
+}
+
+// memberAccess matches the member being typed, like "fmt.pr".
+var memberAccess = regexp.MustCompile(`([A-Za-z_][A-Za-z0-9_]*)\s*\.\s*([A-Za-z0-9_]*)$`)
+
+func (s *Server) completion(doc *document, pos token.Position) any {
+	items := []map[string]any{}
+	result := doc.valid
+	if result == nil {
+		return items
+	}
+	scope := result.ScopeAt(pos)
+	if pos.Line >= 1 && pos.Line <= len(doc.lines) {
+		line := []rune(doc.lines[pos.Line-1])
+		before := string(line[:min(runeIndex(line, pos.Column), len(line))])
+		if m := memberAccess.FindStringSubmatch(before); m != nil {
+			sym := scope.Lookup(m[1])
+			if sym == nil || sym.Kind != checker.Package {
+				return items
+			}
+			d, ok := sym.Package.(object.Describer)
+			if !ok {
+				return items
+			}
+			for _, member := range d.Members() {
+				item := map[string]any{"label": member.Name, "kind": completionFunction, "detail": member.Signature, "documentation": member.Doc}
+				if member.Signature == "" {
+					item["kind"] = completionVariable
+				}
+				items = append(items, item)
+			}
+			return items
+		}
+	}
+
+	seen := make(map[string]bool)
+	add := func(name string, kind int, detail, documentation string) {
+		if seen[name] {
+			return
+		}
+		seen[name] = true
+		items = append(items, map[string]any{"label": name, "kind": kind, "detail": detail, "documentation": documentation})
+	}
+	for sc := scope; sc != nil; sc = sc.Outer {
+		for _, name := range sortedNames(sc.Symbols) {
+			sym := sc.Symbols[name]
+			switch sym.Kind {
+			case checker.Function:
+				add(name, completionFunction, fmt.Sprintf("func %s(%s)", name, strings.Join(sym.Params, ", ")), "")
+			case checker.Package:
+				add(name, completionModule, "package "+sym.Package.Name(), "")
+			default:
+				add(name, completionVariable, sym.Kind.String(), "")
+			}
+		}
+	}
+	for _, name := range s.env.Names() {
+		add(name, completionVariable, checker.Predefined.String(), "")
+	}
+	add("import", completionFunction, "import(name)", "returns the package of name")
+	if pkg, ok := s.env.Import(""); ok {
+		if d, ok := pkg.(object.Describer); ok {
+			for _, member := range d.Members() {
+				add(member.Name, completionFunction, member.Signature, member.Doc)
+			}
+		}
+	}
+	return items
+}
+
+func sortedNames(symbols map[string]*checker.Symbol) []string {
+	names := make([]string, 0, len(symbols))
+	for name := range symbols {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// documentSymbols returns the symbols declared in the program and the functions, in the order of their positions.
+func documentSymbols(doc *document) []map[string]any {
+	symbols := []map[string]any{}
+	if doc.result.Scopes == nil {
+		return symbols
+	}
+	var ids []*ast.Identifier
+	for id, sym := range doc.result.Defs {
+		if sym != nil && sym.Kind != checker.Parameter && sym.Pos == id.Token.Position {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		a, b := ids[i].Token.Position, ids[j].Token.Position
+		return a.Line < b.Line || a.Line == b.Line && a.Column < b.Column
+	})
+	for _, id := range ids {
+		sym := doc.result.Defs[id]
+		kind := symbolVariable
+		detail := sym.Kind.String()
+		if sym.Kind == checker.Function {
+			kind = symbolFunction
+			detail = fmt.Sprintf("func(%s)", strings.Join(sym.Params, ", "))
+		}
+		r := identifierRange(doc.lines, sym.Pos, sym.Name)
+		symbols = append(symbols, map[string]any{"name": sym.Name, "detail": detail, "kind": kind, "range": r, "selectionRange": r})
+	}
+	return symbols
+}
+
+// formatting returns the edit replacing the document by the formatted one,
+// it returns no edits if the document is formatted or has syntax errors.
+func formatting(doc *document) []map[string]any {
+	edits := []map[string]any{}
+	formatted, err := format.Source([]byte(doc.text))
+	if err != nil || string(formatted) == doc.text {
+		return edits
+	}
+	last := doc.lines[len(doc.lines)-1]
+	end := position{Line: len(doc.lines) - 1, Character: len(utf16.Encode([]rune(last)))}
+	return append(edits, map[string]any{"range": rng{End: end}, "newText": string(formatted)})
+}
+
+// runeIndex returns the index of the rune at column of the lexer in line.
+func runeIndex(line []rune, column int) int {
+	col := 0
+	for i, r := range line {
+		if col+1 >= column {
+			return i
+		}
+		col += width(r)
+	}
+	return len(line)
+}
+
+func width(r rune) int {
+	switch r {
+	case '\t':
+		return tabSize
+	case '\r':
+		return 0
+	default:
+		return 1
+	}
+}
+
+// toPosition converts the position of the lexer to the position of the protocol,
+// whose characters are counted in UTF-16.
+func toPosition(lines []string, pos token.Position) position {
+	if pos.Line < 1 || pos.Line > len(lines) {
+		return position{Line: max(pos.Line-1, 0)}
+	}
+	line := []rune(lines[pos.Line-1])
+	return position{Line: pos.Line - 1, Character: len(utf16.Encode(line[:runeIndex(line, pos.Column)]))}
+}
+
+// fromPosition converts the position of the protocol to the position of the lexer.
+func fromPosition(lines []string, pos position) token.Position {
+	ret := token.Position{Line: pos.Line + 1, Column: 1}
+	if pos.Line < 0 || pos.Line >= len(lines) {
+		return ret
+	}
+	units := 0
+	for _, r := range lines[pos.Line] {
+		if units >= pos.Character {
+			break
+		}
+		units += len(utf16.Encode([]rune{r}))
+		ret.Column += width(r)
+	}
+	return ret
+}
+
+func identifierRange(lines []string, pos token.Position, name string) rng {
+	start := toPosition(lines, pos)
+	return rng{Start: start, End: position{Line: start.Line, Character: start.Character + len(utf16.Encode([]rune(name)))}}
+}
+
+// wordRange returns the range of the word at pos, or of the character at pos if it is not in a word.
+func wordRange(lines []string, pos token.Position) rng {
+	start := toPosition(lines, pos)
+	end := position{Line: start.Line, Character: start.Character + 1}
+	if pos.Line >= 1 && pos.Line <= len(lines) {
+		line := []rune(lines[pos.Line-1])
+		i := runeIndex(line, pos.Column)
+		j := i
+		for j < len(line) && (line[j] == '_' || 'a' <= line[j] && line[j] <= 'z' || 'A' <= line[j] && line[j] <= 'Z' || '0' <= line[j] && line[j] <= '9') {
+			j++
+		}
+		if j > i {
+			end.Character = start.Character + len(utf16.Encode(line[i:j]))
+		}
+	}
+	return rng{Start: start, End: end}
+}
+
+func (s *Server) send(msg any) error {
+	s.wmu.Lock()
+	defer s.wmu.Unlock()
+	content, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	return framing.Write(s.out, content)
+}
@@ -0,0 +1,303 @@
+package lsp_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/thingsme/thingscript/internal/framing"
+	"github.com/thingsme/thingscript/lsp"
+	"github.com/thingsme/thingscript/object"
+	"github.com/thingsme/thingscript/stdlib"
+)
+
+const uri = "file:///work/main.txs"
+
+const script = `out := import("fmt")
+func add(a, b) {
+	sum := a + b
+	return sum
+}
+out.println(add(1, 2))
+`
+
+type message struct {
+	ID     int             `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+	Result json.RawMessage `json:"result"`
+	Error  *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+type client struct {
+	t        *testing.T
+	w        io.Writer
+	messages chan message
+	id       int
+	// notifications are the notifications received while waiting for the responses
+	notifications []message
+}
+
+func newClient(t *testing.T) *client {
+	inR, inW := io.Pipe()
+	outR, outW := io.Pipe()
+	s := lsp.NewServer(inR, outW)
+	s.NewEnvironment = func() *object.Environment {
+		env := object.NewEnvironment()
+		env.RegisterPackages(stdlib.Packages()...)
+		return env
+	}
+	done := make(chan error, 1)
+	go func() { done <- s.Serve() }()
+	c := &client{t: t, w: inW, messages: make(chan message, 100)}
+	go func() {
+		r := bufio.NewReader(outR)
+		for {
+			content, err := framing.Read(r)
+			if err != nil {
+				close(c.messages)
+				return
+			}
+			var msg message
+			json.Unmarshal(content, &msg)
+			c.messages <- msg
+		}
+	}()
+	t.Cleanup(func() {
+		c.notify("exit", nil)
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Errorf("unexpected error %v", err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Errorf("server did not exit")
+		}
+		outW.Close()
+	})
+	return c
+}
+
+func (c *client) write(msg map[string]any) {
+	c.t.Helper()
+	msg["jsonrpc"] = "2.0"
+	b, _ := json.Marshal(msg)
+	if err := framing.Write(c.w, b); err != nil {
+		c.t.Fatal(err)
+	}
+}
+
+func (c *client) notify(method string, params any) {
+	c.t.Helper()
+	c.write(map[string]any{"method": method, "params": params})
+}
+
+func (c *client) request(method string, params any, result any) {
+	c.t.Helper()
+	c.id++
+	c.write(map[string]any{"id": c.id, "method": method, "params": params})
+	for {
+		msg := c.read()
+		if msg.Method != "" {
+			c.notifications = append(c.notifications, msg)
+			continue
+		}
+		if msg.ID != c.id {
+			continue
+		}
+		if msg.Error != nil {
+			c.t.Fatalf("%s: %s", method, msg.Error.Message)
+		}
+		if err := json.Unmarshal(msg.Result, result); err != nil {
+			c.t.Fatalf("%s: %v", method, err)
+		}
+		return
+	}
+}
+
+// diagnostics returns the messages of the next diagnostics.
+func (c *client) diagnostics() []string {
+	c.t.Helper()
+	var msg message
+	if len(c.notifications) > 0 {
+		msg, c.notifications = c.notifications[0], c.notifications[1:]
+	} else {
+		msg = c.read()
+	}
+	if msg.Method != "textDocument/publishDiagnostics" {
+		c.t.Fatalf("expected diagnostics, got=%v", msg)
+	}
+	var params struct {
+		Diagnostics []struct {
+			Range   map[string]map[string]int `json:"range"`
+			Message string                    `json:"message"`
+		} `json:"diagnostics"`
+	}
+	json.Unmarshal(msg.Params, &params)
+	ret := []string{}
+	for _, d := range params.Diagnostics {
+		ret = append(ret, d.Message)
+	}
+	return ret
+}
+
+func (c *client) read() message {
+	c.t.Helper()
+	select {
+	case msg, ok := <-c.messages:
+		if !ok {
+			c.t.Fatal("server closed")
+		}
+		return msg
+	case <-time.After(5 * time.Second):
+		c.t.Fatal("timeout")
+	}
+	return message{}
+}
+
+func at(line, character int) map[string]any {
+	return map[string]any{
+		"textDocument": map[string]any{"uri": uri},
+		"position":     map[string]any{"line": line, "character": character},
+	}
+}
+
+func open(c *client, text string) {
+	c.notify("textDocument/didOpen", map[string]any{"textDocument": map[string]any{"uri": uri, "languageId": "thingscript", "version": 1, "text": text}})
+}
+
+func change(c *client, text string) {
+	c.notify("textDocument/didChange", map[string]any{
+		"textDocument":   map[string]any{"uri": uri, "version": 2},
+		"contentChanges": []map[string]any{{"text": text}},
+	})
+}
+
+func TestDiagnostics(t *testing.T) {
+	c := newClient(t)
+	var init map[string]any
+	c.request("initialize", map[string]any{}, &init)
+	if _, ok := init["capabilities"]; !ok {
+		t.Fatalf("expected capabilities, got=%v", init)
+	}
+	c.notify("initialized", map[string]any{})
+
+	open(c, script)
+	if d := c.diagnostics(); len(d) != 0 {
+		t.Errorf("unexpected diagnostics %v", d)
+	}
+	change(c, script+"out.printx(x)\n")
+	if d := c.diagnostics(); strings.Join(d, "\n") != "undefined: x\n\"printx\" is not a member of package \"fmt\"" {
+		t.Errorf("unexpected diagnostics %q", d)
+	}
+	change(c, script+"x := (\n")
+	if d := c.diagnostics(); len(d) == 0 {
+		t.Errorf("expected syntax errors")
+	}
+}
+
+func TestNavigation(t *testing.T) {
+	c := newClient(t)
+	var init map[string]any
+	c.request("initialize", map[string]any{}, &init)
+	open(c, script)
+	c.diagnostics()
+
+	type rng struct {
+		Start struct{ Line, Character int }
+		End   struct{ Line, Character int }
+	}
+	var hover struct {
+		Contents struct{ Value string }
+		Range    rng
+	}
+	// sum in "return sum", after a tab
+	c.request("textDocument/hover", at(3, 9), &hover)
+	if !strings.Contains(hover.Contents.Value, "var sum") || hover.Range.Start.Character != 8 {
+		t.Errorf("unexpected hover %+v", hover)
+	}
+	c.request("textDocument/hover", at(5, 5), &hover)
+	if !strings.Contains(hover.Contents.Value, "fmt.println(args...)") {
+		t.Errorf("unexpected hover %+v", hover)
+	}
+
+	var locations []struct {
+		URI   string
+		Range rng
+	}
+	c.request("textDocument/definition", at(5, 13), &locations)
+	if len(locations) != 1 || locations[0].URI != uri || locations[0].Range.Start.Line != 1 || locations[0].Range.Start.Character != 5 {
+		t.Errorf("unexpected definition %+v", locations)
+	}
+
+	var symbols []struct {
+		Name   string
+		Detail string
+	}
+	c.request("textDocument/documentSymbol", at(0, 0), &symbols)
+	var names []string
+	for _, s := range symbols {
+		names = append(names, s.Name)
+	}
+	if strings.Join(names, ",") != "out,add,sum" {
+		t.Errorf("unexpected symbols %v", names)
+	}
+}
+
+func TestCompletion(t *testing.T) {
+	c := newClient(t)
+	var init map[string]any
+	c.request("initialize", map[string]any{}, &init)
+	open(c, script)
+	c.diagnostics()
+	change(c, script+"out.\n")
+	c.diagnostics()
+
+	var items []struct {
+		Label  string
+		Detail string
+	}
+	c.request("textDocument/completion", at(6, 4), &items)
+	labels := make(map[string]string)
+	for _, item := range items {
+		labels[item.Label] = item.Detail
+	}
+	if labels["println"] != "println(args...)" || labels["add"] != "" {
+		t.Errorf("unexpected members %v", labels)
+	}
+
+	c.request("textDocument/completion", at(3, 1), &items)
+	labels = make(map[string]string)
+	for _, item := range items {
+		labels[item.Label] = item.Detail
+	}
+	for _, name := range []string{"sum", "a", "add", "out", "import", "int"} {
+		if _, ok := labels[name]; !ok {
+			t.Errorf("expected %s in %v", name, labels)
+		}
+	}
+}
+
+func TestFormatting(t *testing.T) {
+	c := newClient(t)
+	var init map[string]any
+	c.request("initialize", map[string]any{}, &init)
+	open(c, "func f() {\nreturn 1\n}")
+	c.diagnostics()
+
+	var edits []struct {
+		NewText string
+	}
+	c.request("textDocument/formatting", map[string]any{"textDocument": map[string]any{"uri": uri}, "options": map[string]any{"tabSize": 4}}, &edits)
+	if len(edits) != 1 || edits[0].NewText != "func f() {\n    return 1\n}\n" {
+		t.Errorf("unexpected edits %+v", edits)
+	}
+	var shutdown any
+	c.request("shutdown", nil, &shutdown)
+}
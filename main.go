@@ -2,36 +2,106 @@ package main
 
 import (
 	"bufio"
+	"context"
 	"flag"
 	"fmt"
 	"io"
+	"log/slog"
+	"net/http"
 	"os"
 	"strings"
 
+	"github.com/thingsme/thingscript/dap"
+	"github.com/thingsme/thingscript/debugger"
 	"github.com/thingsme/thingscript/eval"
+	"github.com/thingsme/thingscript/kv"
 	"github.com/thingsme/thingscript/lexer"
+	"github.com/thingsme/thingscript/lsp"
 	"github.com/thingsme/thingscript/object"
 	"github.com/thingsme/thingscript/parser"
+	"github.com/thingsme/thingscript/scripttest"
 	"github.com/thingsme/thingscript/stdlib"
 )
 
 func main() {
 	var verbose = false
+	var kvPath string
 	var content string
 
 	flag.BoolVar(&verbose, "verbose", false, "verbose")
+	flag.StringVar(&kvPath, "kv", "", "file of the kv package store")
 	flag.Parse()
 
 	args := flag.Args()
-	if len(args) == 1 {
+	if len(args) >= 1 && args[0] == "dap" {
+		server := dap.NewServer(os.Stdin, os.Stdout)
+		server.NewEnvironment = func(path string, args []string, stdout io.Writer) *object.Environment {
+			return newEnvironment(path, args, stdout, verbose, kvPath)
+		}
+		if err := server.Serve(); err != nil {
+			fmt.Fprintln(os.Stderr, "DAP", err.Error())
+			os.Exit(2)
+		}
+		return
+	}
+	if len(args) >= 1 && args[0] == "lsp" {
+		server := lsp.NewServer(os.Stdin, os.Stdout)
+		server.NewEnvironment = func() *object.Environment {
+			// the scripts are not evaluated, the environment only provides the packages,
+			// including assert for the test files
+			env := newEnvironment("", nil, io.Discard, verbose, "")
+			env.RegisterPackages(stdlib.AssertPackage())
+			return env
+		}
+		if err := server.Serve(); err != nil {
+			fmt.Fprintln(os.Stderr, "LSP", err.Error())
+			os.Exit(2)
+		}
+		return
+	}
+	if len(args) >= 1 && args[0] == "doc" {
+		env := newEnvironment("", nil, io.Discard, verbose, "")
+		if err := printDoc(os.Stdout, env, args[1:]); err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+		return
+	}
+	if len(args) >= 1 && args[0] == "test" {
+		roots := args[1:]
+		if len(roots) == 0 {
+			roots = []string{"./test"}
+		}
+		runner := &scripttest.Runner{
+			NewEnvironment: func(path string, stdout io.Writer) *object.Environment {
+				return newEnvironment(path, nil, stdout, verbose, "")
+			},
+			Out:     os.Stdout,
+			Verbose: verbose,
+		}
+		passed, err := runner.Run(roots...)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(2)
+		}
+		if !passed {
+			os.Exit(1)
+		}
+		return
+	}
+	debug := len(args) >= 1 && args[0] == "debug"
+	if debug {
+		args = args[1:]
+	}
+	if len(args) >= 1 {
 		b, err := os.ReadFile(args[0])
 		if err != nil {
 			fmt.Println("File not found", err.Error())
 			os.Exit(2)
 		}
 		content = string(b)
-	} else if len(args) != 1 {
-		fmt.Println("Usage: thingscript <flags> filename")
+	} else if len(args) == 0 {
+		fmt.Println("Usage: thingscript <flags> [debug] filename [args...] | dap | lsp | doc [package[.member]] | test [path...]")
 		os.Exit(1)
 	} else {
 		reader := bufio.NewReader(os.Stdin)
@@ -59,7 +129,88 @@ func main() {
 		}
 		os.Exit(3)
 	}
+	var env *object.Environment
+	if len(args) >= 1 {
+		env = newEnvironment(args[0], args[1:], nil, verbose, kvPath)
+	} else {
+		env = newEnvironment("", nil, nil, verbose, kvPath)
+	}
+	if debug {
+		console := debugger.NewConsole(os.Stdin, os.Stdout, content)
+		env.Context = console.Context(context.Background())
+		if ret := eval.Eval(program, env); ret != nil && ret.Type() == object.ERROR_OBJ {
+			fmt.Println(ret.Inspect())
+		}
+		fmt.Println("program exited")
+	} else {
+		eval.Eval(program, env)
+	}
+	if code := env.ExitCode(); code != 0 {
+		os.Exit(code)
+	}
+}
+
+// newEnvironment returns the environment evaluating the script at path with args,
+// the script writes to stdout if it is not nil.
+func newEnvironment(path string, args []string, stdout io.Writer, verbose bool, kvPath string) *object.Environment {
 	env := object.NewEnvironment()
+	env.Stdout = stdout
+	env.HTTPTransport = http.DefaultTransport
+	env.ScriptName = path
+	env.Args = args
+	env.EnvVars = make(map[string]string)
+	for _, pair := range os.Environ() {
+		if k, v, ok := strings.Cut(pair, "="); ok {
+			env.EnvVars[k] = v
+		}
+	}
+	env.Hostname, _ = os.Hostname()
+	logLevel := slog.LevelInfo
+	if verbose {
+		logLevel = slog.LevelDebug
+	}
+	env.LogHandler = slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: logLevel})
+	if kvPath != "" {
+		store, err := kv.OpenFileStore(kvPath)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "KV store", err.Error())
+			os.Exit(2)
+		}
+		env.KV = store
+	}
 	env.RegisterPackages(stdlib.Packages()...)
-	eval.Eval(program, env)
+	return env
+}
+
+// printDoc prints the description of the package or its member named like "fmt" or "fmt.println",
+// or the builtins and the names of the packages without args.
+func printDoc(w io.Writer, env *object.Environment, args []string) error {
+	name, member := "", ""
+	switch len(args) {
+	case 0:
+	case 1:
+		name, member, _ = strings.Cut(args[0], ".")
+	case 2:
+		name, member = args[0], args[1]
+	default:
+		return fmt.Errorf("usage: thingscript doc [package[.member]]")
+	}
+	pkg, ok := env.Import(name)
+	if !ok {
+		return fmt.Errorf("package %q not found", name)
+	}
+	text, err := stdlib.Help(pkg, member)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(w, text)
+	if len(args) == 0 {
+		fmt.Fprintln(w, "\npackages")
+		for _, p := range stdlib.Packages() {
+			if p.Name() != "" {
+				fmt.Fprintf(w, "    %s\n", p.Name())
+			}
+		}
+	}
+	return nil
 }
@@ -0,0 +1,198 @@
+package object
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// NativeValuer is implemented by objects which can be converted into a Go value by ToNative.
+type NativeValuer interface {
+	NativeValue() any
+}
+
+// FromNative converts a Go value into an object.
+// It supports nil, booleans, numbers, strings, []byte, slices, arrays and maps of the supported values.
+// An Object is returned as it is.
+func FromNative(v any) (Object, error) {
+	switch v := v.(type) {
+	case nil:
+		return NULL, nil
+	case Object:
+		return v, nil
+	case bool:
+		return &Boolean{Value: v}, nil
+	case string:
+		return &String{Value: v}, nil
+	case []byte:
+		return &Bytes{Value: v}, nil
+	case int:
+		return &Integer{Value: int64(v)}, nil
+	case int8:
+		return &Integer{Value: int64(v)}, nil
+	case int16:
+		return &Integer{Value: int64(v)}, nil
+	case int32:
+		return &Integer{Value: int64(v)}, nil
+	case int64:
+		return &Integer{Value: v}, nil
+	case uint:
+		return &Integer{Value: int64(v)}, nil
+	case uint8:
+		return &Integer{Value: int64(v)}, nil
+	case uint16:
+		return &Integer{Value: int64(v)}, nil
+	case uint32:
+		return &Integer{Value: int64(v)}, nil
+	case uint64:
+		return &Integer{Value: int64(v)}, nil
+	case float32:
+		return &Float{Value: float64(v)}, nil
+	case float64:
+		return &Float{Value: v}, nil
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return &Integer{Value: i}, nil
+		}
+		f, err := v.Float64()
+		if err != nil {
+			return nil, err
+		}
+		return &Float{Value: f}, nil
+	case []any:
+		elements := make([]Object, len(v))
+		for i, e := range v {
+			obj, err := FromNative(e)
+			if err != nil {
+				return nil, err
+			}
+			elements[i] = obj
+		}
+		return &Array{Elements: elements}, nil
+	case map[string]any:
+		pairs := make(map[HashKey]HashPair, len(v))
+		for k, e := range v {
+			obj, err := FromNative(e)
+			if err != nil {
+				return nil, err
+			}
+			key := &String{Value: k}
+			pairs[key.HashKey()] = HashPair{Key: key, Value: obj}
+		}
+		return &HashMap{Pairs: pairs}, nil
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Pointer:
+		if rv.IsNil() {
+			return NULL, nil
+		}
+		return FromNative(rv.Elem().Interface())
+	case reflect.Slice, reflect.Array:
+		elements := make([]Object, rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			obj, err := FromNative(rv.Index(i).Interface())
+			if err != nil {
+				return nil, err
+			}
+			elements[i] = obj
+		}
+		return &Array{Elements: elements}, nil
+	case reflect.Map:
+		pairs := make(map[HashKey]HashPair, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			key, err := FromNative(iter.Key().Interface())
+			if err != nil {
+				return nil, err
+			}
+			hashKey, ok := key.(Hashable)
+			if !ok {
+				return nil, fmt.Errorf("unusable as hash key: %s", key.Type())
+			}
+			obj, err := FromNative(iter.Value().Interface())
+			if err != nil {
+				return nil, err
+			}
+			pairs[hashKey.HashKey()] = HashPair{Key: key, Value: obj}
+		}
+		return &HashMap{Pairs: pairs}, nil
+	case reflect.Bool:
+		return &Boolean{Value: rv.Bool()}, nil
+	case reflect.String:
+		return &String{Value: rv.String()}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &Integer{Value: rv.Int()}, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Integer{Value: int64(rv.Uint())}, nil
+	case reflect.Float32, reflect.Float64:
+		return &Float{Value: rv.Float()}, nil
+	}
+	return nil, fmt.Errorf("unsupported native type %T", v)
+}
+
+// ToNative converts an object into a Go value.
+// Arrays are converted into []any and maps into map[string]any,
+// the keys of a map are converted into strings.
+func ToNative(obj Object) (any, error) {
+	switch obj := obj.(type) {
+	case nil, *Null:
+		return nil, nil
+	case *Boolean:
+		return obj.Value, nil
+	case *Integer:
+		return obj.Value, nil
+	case *Float:
+		return obj.Value, nil
+	case *String:
+		return obj.Value, nil
+	case *Bytes:
+		return obj.Value, nil
+	case *Array:
+		ret := make([]any, len(obj.Elements))
+		for i, e := range obj.Elements {
+			v, err := ToNative(e)
+			if err != nil {
+				return nil, err
+			}
+			ret[i] = v
+		}
+		return ret, nil
+	case *HashMap:
+		ret := make(map[string]any, len(obj.Pairs))
+		for _, pair := range obj.Pairs {
+			v, err := ToNative(pair.Value)
+			if err != nil {
+				return nil, err
+			}
+			ret[pair.Key.Inspect()] = v
+		}
+		return ret, nil
+	case NativeValuer:
+		return obj.NativeValue(), nil
+	}
+	return nil, fmt.Errorf("%s can not be converted into native value", obj.Type())
+}
+
+// EncodeJSON encodes obj in JSON through ToNative.
+func EncodeJSON(obj Object) ([]byte, error) {
+	v, err := ToNative(obj)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(v)
+}
+
+// DecodeJSON decodes JSON into an object through FromNative,
+// integral numbers are decoded into Integer.
+func DecodeJSON(data []byte) (Object, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return FromNative(v)
+}
@@ -0,0 +1,87 @@
+package object
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestFromNative(t *testing.T) {
+	tests := []struct {
+		input    any
+		expected string
+	}{
+		{nil, "null"},
+		{true, "true"},
+		{"text", "text"},
+		{42, "42"},
+		{uint8(7), "7"},
+		{1.5, "1.500000"},
+		{[]any{1, "a", false}, "[1, a, false]"},
+		{[]int{1, 2}, "[1, 2]"},
+		{map[string]any{"k": []string{"v"}}, "{k: [v]}"},
+		{map[int]string{1: "one"}, "{1: one}"},
+		{&Integer{Value: 3}, "3"},
+		{[]byte{1, 0xff}, "0x01ff"},
+		{json.Number("12"), "12"},
+		{json.Number("1.25"), "1.250000"},
+	}
+	for _, tt := range tests {
+		obj, err := FromNative(tt.input)
+		if err != nil {
+			t.Errorf("FromNative(%v) error: %s", tt.input, err)
+			continue
+		}
+		if obj.Inspect() != tt.expected {
+			t.Errorf("FromNative(%v) expected %q, got=%q", tt.input, tt.expected, obj.Inspect())
+		}
+	}
+
+	if _, err := FromNative(struct{}{}); err == nil {
+		t.Errorf("FromNative(struct) should fail")
+	}
+	if _, err := FromNative(map[bool][]func(){true: {func() {}}}); err == nil {
+		t.Errorf("FromNative(func) should fail")
+	}
+}
+
+func TestToNative(t *testing.T) {
+	key := &String{Value: "list"}
+	obj := &HashMap{Pairs: map[HashKey]HashPair{
+		key.HashKey(): {Key: key, Value: &Array{Elements: []Object{
+			&Integer{Value: 1}, &Float{Value: 2.5}, &Boolean{Value: true}, NULL, &Bytes{Value: []byte("ab")},
+		}}},
+	}}
+	v, err := ToNative(obj)
+	if err != nil {
+		t.Fatalf("ToNative error: %s", err)
+	}
+	expected := map[string]any{"list": []any{int64(1), 2.5, true, nil, []byte("ab")}}
+	if !reflect.DeepEqual(v, expected) {
+		t.Errorf("ToNative expected %v, got=%v", expected, v)
+	}
+
+	if _, err := ToNative(&Function{}); err == nil {
+		t.Errorf("ToNative(function) should fail")
+	}
+}
+
+func TestJSON(t *testing.T) {
+	obj, err := DecodeJSON([]byte(`{"a": [1, 2.5, "x", null, true]}`))
+	if err != nil {
+		t.Fatalf("DecodeJSON error: %s", err)
+	}
+	if obj.Inspect() != `{a: [1, 2.500000, x, null, true]}` {
+		t.Errorf("DecodeJSON unexpected %s", obj.Inspect())
+	}
+	b, err := EncodeJSON(obj)
+	if err != nil {
+		t.Fatalf("EncodeJSON error: %s", err)
+	}
+	if string(b) != `{"a":[1,2.5,"x",null,true]}` {
+		t.Errorf("EncodeJSON unexpected %s", b)
+	}
+	if _, err := DecodeJSON([]byte(`{`)); err == nil {
+		t.Errorf("DecodeJSON(invalid) should fail")
+	}
+}
@@ -1,24 +1,93 @@
 package object
 
 import (
+	"context"
 	"fmt"
 	"io"
+	"io/fs"
+	"log/slog"
+	"math/rand"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
+// Environment is the scope of the variables and the packages.
+// It is safe for concurrent use, the functions run by go() share the environments of their closures
+// and the scripts evaluated in the goroutines of a host can share a parent environment.
 type Environment struct {
+	mu       sync.RWMutex
+	frozen   bool
 	outer    *Environment
 	store    map[string]Object
 	packages map[string]Package
+	builtins map[string]Package
 
-	Stdout       io.Writer
-	TimeProvider func() time.Time
+	Stdout io.Writer
+	// Context limits the evaluation, it stops at the next statement once the context is done.
+	// Enclosed environments inherit it from the outer environment.
+	Context       context.Context
+	TimeProvider  func() time.Time
+	SleepProvider func(time.Duration)
+	RandSource    rand.Source
+
+	// FS is the file system which scripts can read,
+	// it defaults to WritableRoot if it is nil.
+	FS fs.FS
+	// WritableRoot is the directory which scripts can write, writing is denied if it is empty.
+	WritableRoot string
+	// FSQuota is the maximum of the bytes scripts write in total, unlimited if it is zero.
+	// It counts every byte written, including the bytes overwritten later or in the files removed later,
+	// and it is not the disk usage of WritableRoot.
+	FSQuota int64
+	// HTTPTransport sends the requests of the http package, requests fail if it is nil.
+	// Hosts can restrict the reachable endpoints by wrapping http.DefaultTransport.
+	HTTPTransport http.RoundTripper
+	// HTTPMaxBodySize is the maximum size of the response bodies the http package reads,
+	// the larger responses are errors. It defaults to 32 MiB if it is zero.
+	HTTPMaxBodySize int64
+
+	// ScriptName is the name of the script, it is reported by the log package.
+	ScriptName string
+	// LogHandler receives the records of the log package, it defaults to slog.Default().Handler().
+	LogHandler slog.Handler
+
+	// Args, EnvVars and Hostname are exposed to scripts by the os package,
+	// they are empty unless the host sets them, so that the host environment does not leak.
+	Args     []string
+	EnvVars  map[string]string
+	Hostname string
+	// exitCode is set by os.set_exit_code() which can be called by the functions run by go().
+	exitCode atomic.Int32
+
+	// KV is the store of the kv package, kv.NewMemoryStore or kv.OpenFileStore for example.
+	KV KVStore
+}
+
+// KVStore is the key-value store which the host provides through Environment.KV.
+// The values are opaque bytes for the stores, the kv package of scripts encodes them in JSON.
+// The implementations must be safe for concurrent use.
+type KVStore interface {
+	// Get returns the value of key, ok is false if key does not exist or it is expired.
+	Get(ctx context.Context, key string) (value []byte, ok bool, err error)
+	// Set sets the value of key, the key expires after ttl if ttl is positive.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// Delete deletes key and reports whether it existed.
+	Delete(ctx context.Context, key string) (bool, error)
+	// Keys returns the sorted keys which start with prefix.
+	Keys(ctx context.Context, prefix string) ([]string, error)
+	// CompareAndSet sets the value of key to value only if the current value is old,
+	// a nil old means that key must not exist. It reports whether the value is set.
+	CompareAndSet(ctx context.Context, key string, old []byte, value []byte, ttl time.Duration) (bool, error)
 }
 
 func NewEnvironment() *Environment {
 	env := &Environment{
 		store:    make(map[string]Object),
 		packages: make(map[string]Package),
+		builtins: make(map[string]Package),
 	}
 	return env
 }
@@ -29,6 +98,21 @@ func NewEnclosedEnvironment(outer *Environment) *Environment {
 	return env
 }
 
+// ExitCode returns the exit code set by os.set_exit_code(), the host decides how to use it.
+func (e *Environment) ExitCode() int {
+	return int(e.exitCode.Load())
+}
+
+// SetExitCode sets the exit code, it is safe to call from the functions run by go().
+func (e *Environment) SetExitCode(code int) {
+	e.exitCode.Store(int32(code))
+}
+
+// Outer returns the outer environment of e, it is nil for the outermost environment.
+func (e *Environment) Outer() *Environment {
+	return e.outer
+}
+
 func (e *Environment) Builtin(name string) *Builtin {
 	switch name {
 	case "import":
@@ -40,7 +124,7 @@ func (e *Environment) Builtin(name string) *Builtin {
 			if !ok {
 				return Errorf("argument to import must be string, got %s", args[0].Type())
 			}
-			if pkg, ok := e.packages[name.Value]; ok {
+			if pkg, ok := e.Import(name.Value); ok {
 				return pkg
 			} else {
 				return Errorf("package %q not found", name.Value)
@@ -48,6 +132,9 @@ func (e *Environment) Builtin(name string) *Builtin {
 		}}
 	default:
 		pkg, ok := e.Import("")
+		if !ok || pkg.Member(name) == nil {
+			pkg, ok = e.builtinPackage(name)
+		}
 		if !ok {
 			return nil
 		}
@@ -62,7 +149,9 @@ func (e *Environment) Builtin(name string) *Builtin {
 }
 
 func (e *Environment) Type(pkgName string, name string, initial Object) Object {
+	e.mu.RLock()
 	pkg, ok := e.packages[pkgName]
+	e.mu.RUnlock()
 	if !ok {
 		return Errorf("unknown %q", pkgName)
 	}
@@ -91,33 +180,120 @@ func (e *Environment) Type(pkgName string, name string, initial Object) Object {
 	return ret
 }
 
+// Ctx returns the Context of e or its outer environments,
+// it is context.Background() if none of them has a Context.
+func (e *Environment) Ctx() context.Context {
+	for env := e; env != nil; env = env.outer {
+		if env.Context != nil {
+			return env.Context
+		}
+	}
+	return context.Background()
+}
+
 func (e *Environment) Get(name string) (Object, bool) {
+	e.mu.RLock()
 	obj, ok := e.store[name]
+	e.mu.RUnlock()
 	if !ok && e.outer != nil {
 		obj, ok = e.outer.Get(name)
 	}
 	if !ok {
+		e.mu.RLock()
 		if pkg, ok := e.packages[name]; ok {
 			obj = pkg
 		}
+		e.mu.RUnlock()
 	}
 	return obj, ok
 }
 
+// Set defines the variable name in e, it returns an error if e is frozen.
 func (e *Environment) Set(name string, val Object) Object {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.frozen {
+		return Errorf("can not define %q in frozen environment", name)
+	}
 	e.store[name] = val
 	return val
 }
 
+// Names returns the sorted names of the variables defined in e, excluding the outer environments.
+func (e *Environment) Names() []string {
+	e.mu.RLock()
+	names := make([]string, 0, len(e.store))
+	for name := range e.store {
+		names = append(names, name)
+	}
+	e.mu.RUnlock()
+	sort.Strings(names)
+	return names
+}
+
+// Assign replaces the value of the variable name in e or the outer environment which defines it,
+// it returns an error if the variable is not defined or it is defined in a frozen environment.
+func (e *Environment) Assign(name string, val Object) Object {
+	for env := e; env != nil; env = env.outer {
+		env.mu.Lock()
+		if _, ok := env.store[name]; ok {
+			defer env.mu.Unlock()
+			if env.frozen {
+				return Errorf("can not assign to %q in frozen environment", name)
+			}
+			env.store[name] = val
+			return val
+		}
+		env.mu.Unlock()
+	}
+	return Errorf("identifier not found: %s", name)
+}
+
+// Freeze makes the variables of e read-only, so that the environments enclosing e
+// can be used by the concurrent evaluations without copying e.
+// The scripts can still define their own variables in the enclosed environments.
+func (e *Environment) Freeze() {
+	e.mu.Lock()
+	e.frozen = true
+	e.mu.Unlock()
+}
+
+// Frozen reports whether e is frozen.
+func (e *Environment) Frozen() bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.frozen
+}
+
 func (e *Environment) RegisterPackages(pkgs ...Package) {
 	for _, p := range pkgs {
 		p.OnLoad(e)
+		e.mu.Lock()
 		e.packages[p.Name()] = p
+		if bp, ok := p.(BuiltinPackage); ok {
+			for _, name := range bp.Builtins() {
+				e.builtins[name] = p
+			}
+		}
+		e.mu.Unlock()
 	}
 }
 
+// builtinPackage returns the BuiltinPackage which provides the builtin name.
+func (e *Environment) builtinPackage(name string) (Package, bool) {
+	e.mu.RLock()
+	p, ok := e.builtins[name]
+	e.mu.RUnlock()
+	if !ok && e.outer != nil {
+		p, ok = e.outer.builtinPackage(name)
+	}
+	return p, ok
+}
+
 func (e *Environment) Import(name string) (Package, bool) {
+	e.mu.RLock()
 	p, ok := e.packages[name]
+	e.mu.RUnlock()
 	if !ok && e.outer != nil {
 		p, ok = e.outer.Import(name)
 	}
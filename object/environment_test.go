@@ -1,6 +1,8 @@
 package object_test
 
 import (
+	"fmt"
+	"sync"
 	"testing"
 
 	"github.com/thingsme/thingscript/object"
@@ -130,3 +132,58 @@ func TestImpor(t *testing.T) {
 		t.Errorf("wrong pkg %q, got=%q", "fmt", pkg.Name())
 	}
 }
+
+func TestConcurrentEnvironment(t *testing.T) {
+	env := object.NewEnvironment()
+	env.RegisterPackages(stdlib.Packages()...)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			inner := object.NewEnclosedEnvironment(env)
+			for j := 0; j < 100; j++ {
+				env.Set(fmt.Sprintf("v%d", j%10), &object.Integer{Value: int64(i)})
+				inner.Set("local", &object.Integer{Value: int64(j)})
+				inner.Get(fmt.Sprintf("v%d", j%10))
+				inner.Import("fmt")
+			}
+		}(i)
+	}
+	wg.Wait()
+	if _, ok := env.Get("v9"); !ok {
+		t.Error("identifier not found")
+	}
+}
+
+func TestAssign(t *testing.T) {
+	env := object.NewEnvironment()
+	env.Set("x", &object.Integer{Value: 1})
+	inner := object.NewEnclosedEnvironment(env)
+
+	inner.Assign("x", &object.Integer{Value: 2})
+	if obj, _ := env.Get("x"); obj.(*object.Integer).Value != 2 {
+		t.Errorf("x should be assigned in the outer environment, got=%s", obj.Inspect())
+	}
+	if ret := inner.Assign("y", &object.Integer{Value: 1}); ret.Type() != object.ERROR_OBJ {
+		t.Errorf("undefined variable should not be assigned, got=%s", ret.Inspect())
+	}
+
+	env.Freeze()
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			inner := object.NewEnclosedEnvironment(env)
+			inner.Set("x", &object.Integer{Value: int64(i)})
+			inner.Assign("x", &object.Integer{Value: int64(i + 1)})
+			env.Assign("x", &object.Integer{Value: int64(i)})
+		}(i)
+	}
+	wg.Wait()
+	if obj, _ := env.Get("x"); obj.(*object.Integer).Value != 2 {
+		t.Errorf("frozen environment is modified, got=%s", obj.Inspect())
+	}
+}
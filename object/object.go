@@ -10,6 +10,7 @@ import (
 	"strings"
 
 	"github.com/thingsme/thingscript/ast"
+	"github.com/thingsme/thingscript/token"
 )
 
 type ObjectType string
@@ -19,10 +20,12 @@ const (
 	FLOAT_OBJ        = "FLOAT"
 	BOOLEAN_OBJ      = "BOOLEAN"
 	STRING_OBJ       = "STRING"
+	BYTES_OBJ        = "BYTES"
 	ARRAY_OBJ        = "ARRAY"
 	HASHMAP_OBJ      = "HASHMAP"
 	FUNCTION_OBJ     = "FUNCTION"
 	BUILTIN_OBJ      = "BUILTIN"
+	METHOD_OBJ       = "METHOD"
 	PACKAGE_OBJ      = "PACKAGE"
 	NULL_OBJ         = "NULL"
 	RETURN_VALUE_OBJ = "RETURN_VALUE"
@@ -41,6 +44,9 @@ type Object interface {
 
 type Null struct{}
 
+// NULL is the only instance of Null, which is the value of 'nil'.
+var NULL = &Null{}
+
 func (n *Null) Type() ObjectType              { return NULL_OBJ }
 func (n *Null) Inspect() string               { return "null" }
 func (n *Null) Member(name string) MemberFunc { return nil }
@@ -72,6 +78,15 @@ func (i *Integer) Member(name string) MemberFunc {
 
 var IntegerMemberFunc func(string) MemberFunc
 
+func (i *Integer) Members() []MemberDoc {
+	if IntegerMemberDocs != nil {
+		return IntegerMemberDocs()
+	}
+	return nil
+}
+
+var IntegerMemberDocs func() []MemberDoc
+
 type Float struct {
 	Value float64
 }
@@ -87,6 +102,15 @@ func (f *Float) Member(name string) MemberFunc {
 
 var FloatMemberFunc func(string) MemberFunc
 
+func (f *Float) Members() []MemberDoc {
+	if FloatMemberDocs != nil {
+		return FloatMemberDocs()
+	}
+	return nil
+}
+
+var FloatMemberDocs func() []MemberDoc
+
 type Boolean struct {
 	Value bool
 }
@@ -102,6 +126,15 @@ func (b *Boolean) Member(name string) MemberFunc {
 
 var BooleanMemberFunc func(string) MemberFunc
 
+func (b *Boolean) Members() []MemberDoc {
+	if BooleanMemberDocs != nil {
+		return BooleanMemberDocs()
+	}
+	return nil
+}
+
+var BooleanMemberDocs func() []MemberDoc
+
 type String struct {
 	Value string
 }
@@ -117,6 +150,39 @@ func (b *String) Member(name string) MemberFunc {
 
 var StringMemberFunc func(string) MemberFunc
 
+func (b *String) Members() []MemberDoc {
+	if StringMemberDocs != nil {
+		return StringMemberDocs()
+	}
+	return nil
+}
+
+var StringMemberDocs func() []MemberDoc
+
+type Bytes struct {
+	Value []byte
+}
+
+func (b *Bytes) Type() ObjectType { return BYTES_OBJ }
+func (b *Bytes) Inspect() string  { return fmt.Sprintf("0x%x", b.Value) }
+func (b *Bytes) Member(name string) MemberFunc {
+	if BytesMemberFunc != nil {
+		return BytesMemberFunc(name)
+	}
+	return nil
+}
+
+var BytesMemberFunc func(string) MemberFunc
+
+func (b *Bytes) Members() []MemberDoc {
+	if BytesMemberDocs != nil {
+		return BytesMemberDocs()
+	}
+	return nil
+}
+
+var BytesMemberDocs func() []MemberDoc
+
 type ReturnValue struct {
 	Value Object
 }
@@ -133,6 +199,8 @@ func (br *Break) Inspect() string               { return "break" }
 func (br *Break) Member(name string) MemberFunc { return nil }
 
 type Function struct {
+	// Name is the name of the function statement, it is empty for the function literals.
+	Name       string
 	Parameters []*ast.Identifier
 	Body       *ast.BlockStatement
 	Env        *Environment
@@ -163,6 +231,30 @@ func (b *Builtin) Type() ObjectType              { return BUILTIN_OBJ }
 func (b *Builtin) Inspect() string               { return "builtin" }
 func (b *Builtin) Member(name string) MemberFunc { return nil }
 
+// BoundMethod is a member function bound to its receiver,
+// it can be passed around and called like a function.
+type BoundMethod struct {
+	Receiver Object
+	Name     string
+	Func     MemberFunc
+}
+
+func (bm *BoundMethod) Type() ObjectType { return METHOD_OBJ }
+func (bm *BoundMethod) Inspect() string {
+	return fmt.Sprintf("method %s of %s", bm.Name, bm.Receiver.Type())
+}
+func (bm *BoundMethod) Member(name string) MemberFunc { return nil }
+
+// Bind returns the member function of receiver as a callable object,
+// it returns nil if receiver does not have the member.
+func Bind(receiver Object, name string) *BoundMethod {
+	fn := receiver.Member(name)
+	if fn == nil {
+		return nil
+	}
+	return &BoundMethod{Receiver: receiver, Name: name, Func: fn}
+}
+
 type Array struct {
 	Elements []Object
 }
@@ -189,6 +281,15 @@ func (ao *Array) Member(name string) MemberFunc {
 
 var ArrayMemberFunc func(name string) MemberFunc
 
+func (ao *Array) Members() []MemberDoc {
+	if ArrayMemberDocs != nil {
+		return ArrayMemberDocs()
+	}
+	return nil
+}
+
+var ArrayMemberDocs func() []MemberDoc
+
 type HashKey struct {
 	Type  ObjectType
 	Value uint64
@@ -236,15 +337,49 @@ type HashPair struct {
 	Value Object
 }
 
+// HashMap is a map of objects.
+// It keeps the insertion order of the keys in Keys if Keys is not nil,
+// which is the case of the maps created by NewOrderedHashMap.
 type HashMap struct {
 	Pairs map[HashKey]HashPair
+	Keys  []HashKey
+}
+
+// NewOrderedHashMap returns an empty HashMap which keeps the insertion order of the keys.
+func NewOrderedHashMap() *HashMap {
+	return &HashMap{Pairs: make(map[HashKey]HashPair), Keys: []HashKey{}}
+}
+
+// Set sets the value of key, key must be Hashable.
+func (h *HashMap) Set(key Object, value Object) {
+	hashKey := key.(Hashable).HashKey()
+	if _, ok := h.Pairs[hashKey]; !ok && h.Keys != nil {
+		h.Keys = append(h.Keys, hashKey)
+	}
+	h.Pairs[hashKey] = HashPair{Key: key, Value: value}
+}
+
+// OrderedPairs returns the pairs in the insertion order,
+// or in arbitrary order if h does not keep the order.
+func (h *HashMap) OrderedPairs() []HashPair {
+	ret := make([]HashPair, 0, len(h.Pairs))
+	if h.Keys != nil {
+		for _, k := range h.Keys {
+			ret = append(ret, h.Pairs[k])
+		}
+		return ret
+	}
+	for _, pair := range h.Pairs {
+		ret = append(ret, pair)
+	}
+	return ret
 }
 
 func (h *HashMap) Type() ObjectType { return HASHMAP_OBJ }
 func (h *HashMap) Inspect() string {
 	var out bytes.Buffer
 	pairs := []string{}
-	for _, pair := range h.Pairs {
+	for _, pair := range h.OrderedPairs() {
 		pairs = append(pairs, fmt.Sprintf("%s: %s",
 			pair.Key.Inspect(), pair.Value.Inspect()))
 	}
@@ -263,9 +398,76 @@ func (h *HashMap) Member(name string) MemberFunc {
 
 var HashMapMemberFunc func(name string) MemberFunc
 
+func (h *HashMap) Members() []MemberDoc {
+	if HashMapMemberDocs != nil {
+		return HashMapMemberDocs()
+	}
+	return nil
+}
+
+var HashMapMemberDocs func() []MemberDoc
+
 type Package interface {
 	Object
 	Name() string
 	Member(name string) MemberFunc
 	OnLoad(*Environment)
 }
+
+// BuiltinPackage is implemented by the packages which also provide some of their members as builtins,
+// like the 'on' function of the event package.
+type BuiltinPackage interface {
+	Package
+	// Builtins returns the names of the members which are called without importing the package.
+	Builtins() []string
+}
+
+// CallSiteMember is implemented by objects whose members need the position of the call site,
+// the evaluator calls MemberAt instead of Member for the access expressions like "log.info(msg)".
+type CallSiteMember interface {
+	MemberAt(name string, pos token.Position) MemberFunc
+}
+
+// MemberDoc describes a member of a package or an object.
+type MemberDoc struct {
+	Name string
+	// Signature is the call of the member, like "get(key, default?)",
+	// the optional parameters end with "?" and the variadic ones with "...".
+	// It is empty for the members which are not called.
+	Signature string
+	Doc       string
+}
+
+// Describer is implemented by the packages and the objects which list their members,
+// which the editors complete and the checkers verify.
+// The members of the primitive objects are described by the hooks like StringMemberDocs,
+// as their members are provided by the hooks like StringMemberFunc.
+type Describer interface {
+	Members() []MemberDoc
+}
+
+// Arity returns the minimum and the maximum numbers of the arguments of the member,
+// the maximum is -1 for the variadic members. It returns -1, -1 if the member is not called.
+func (d MemberDoc) Arity() (min, max int) {
+	open := strings.IndexByte(d.Signature, '(')
+	if open < 0 || !strings.HasSuffix(d.Signature, ")") {
+		return -1, -1
+	}
+	params := strings.TrimSpace(d.Signature[open+1 : len(d.Signature)-1])
+	if params == "" {
+		return 0, 0
+	}
+	for _, p := range strings.Split(params, ",") {
+		p = strings.TrimSpace(p)
+		switch {
+		case strings.HasSuffix(p, "..."):
+			return min, -1
+		case strings.HasSuffix(p, "?"):
+			max++
+		default:
+			min++
+			max++
+		}
+	}
+	return min, max
+}
@@ -191,3 +191,13 @@ func TestBooleanHashKey(t *testing.T) {
 		t.Errorf("boolean with different value have same hash keys")
 	}
 }
+
+func TestOrderedHashMap(t *testing.T) {
+	h := NewOrderedHashMap()
+	h.Set(&String{Value: "z"}, &Integer{Value: 1})
+	h.Set(&String{Value: "a"}, &Integer{Value: 2})
+	h.Set(&String{Value: "z"}, &Integer{Value: 3})
+	if h.Inspect() != "{z: 3, a: 2}" {
+		t.Errorf("wrong order, got=%s", h.Inspect())
+	}
+}
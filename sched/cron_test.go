@@ -0,0 +1,55 @@
+package sched
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCronNext(t *testing.T) {
+	base := time.Date(2024, 1, 31, 10, 7, 30, 0, time.UTC)
+	tests := []struct {
+		expr     string
+		expected time.Time
+	}{
+		{"* * * * *", time.Date(2024, 1, 31, 10, 8, 0, 0, time.UTC)},
+		{"*/5 * * * *", time.Date(2024, 1, 31, 10, 10, 0, 0, time.UTC)},
+		{"0 * * * *", time.Date(2024, 1, 31, 11, 0, 0, 0, time.UTC)},
+		{"30 9 * * *", time.Date(2024, 2, 1, 9, 30, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 12 * * 0", time.Date(2024, 2, 4, 12, 0, 0, 0, time.UTC)},
+		{"0 12 * * 7", time.Date(2024, 2, 4, 12, 0, 0, 0, time.UTC)},
+		{"0 12 * * 1-5", time.Date(2024, 1, 31, 12, 0, 0, 0, time.UTC)},
+		{"15,45 10 * * *", time.Date(2024, 1, 31, 10, 15, 0, 0, time.UTC)},
+		{"10-20/5 10 * * *", time.Date(2024, 1, 31, 10, 10, 0, 0, time.UTC)},
+		{"0 0 1 * 6", time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)}, // day of month or day of week
+		{"0 0 30 2 *", time.Time{}},
+	}
+	for _, tt := range tests {
+		c, err := ParseCron(tt.expr)
+		if err != nil {
+			t.Errorf("parse %q error: %s", tt.expr, err)
+			continue
+		}
+		if next := c.Next(base); !next.Equal(tt.expected) {
+			t.Errorf("next of %q expected %s, got=%s", tt.expr, tt.expected, next)
+		}
+	}
+}
+
+func TestCronParseError(t *testing.T) {
+	tests := []string{
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"*/0 * * * *",
+		"a * * * *",
+		"5-1 * * * *",
+	}
+	for _, tt := range tests {
+		if _, err := ParseCron(tt); err == nil {
+			t.Errorf("parse %q should fail", tt)
+		}
+	}
+}
@@ -0,0 +1,396 @@
+// Package sched provides timers, intervals and cron jobs to scripts.
+//
+//	s := import("sched")
+//	job := s.every("10s", func() { poll() })
+//	s.after("1m", func() { job.cancel() })
+//	s.cron("*/5 * * * *", func() { report() })
+//
+// The jobs are executed by the host, either by calling RunPending
+// which runs the jobs due at Environment.TimeProvider, so that tests can advance a fake clock deterministically,
+// or by Run which is an event loop on the wall clock.
+package sched
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/thingsme/thingscript/eval"
+	"github.com/thingsme/thingscript/object"
+)
+
+// ErrShutdown is returned by Run when the scheduler is shut down.
+var ErrShutdown = errors.New("scheduler shut down")
+
+// Scheduler keeps the jobs registered by a script.
+type Scheduler struct {
+	// OnError is called with the error of a job executed by Run, optional.
+	OnError func(err error)
+
+	mu       sync.Mutex
+	execMu   sync.Mutex
+	env      *object.Environment
+	jobs     []*Job
+	seq      int64
+	shutdown bool
+	wakeup   chan struct{}
+	done     chan struct{}
+}
+
+var _ object.Package = &Scheduler{}
+
+// NewScheduler returns a Scheduler, it should be registered to an environment as a package.
+func NewScheduler() *Scheduler {
+	return &Scheduler{
+		wakeup: make(chan struct{}, 1),
+		done:   make(chan struct{}),
+	}
+}
+
+func (s *Scheduler) Type() object.ObjectType { return object.PACKAGE_OBJ }
+
+func (s *Scheduler) Inspect() string { return "package sched" }
+
+func (s *Scheduler) Name() string { return "sched" }
+
+func (s *Scheduler) OnLoad(env *object.Environment) {
+	s.env = env
+}
+
+// now returns the current time of the TimeProvider of the environment, which is read at each call
+// so that the host can replace it after registering the scheduler.
+func (s *Scheduler) now() time.Time {
+	if s.env != nil && s.env.TimeProvider != nil {
+		return s.env.TimeProvider()
+	}
+	return time.Now()
+}
+
+func (s *Scheduler) Member(name string) object.MemberFunc {
+	switch name {
+	case "after", "every":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return object.Errorf("wrong number of arguments. want=2 got=%d", len(args))
+			}
+			d, err := toDuration(args[0])
+			if err != nil {
+				return object.Errorf("%s: %s", name, err.Error())
+			}
+			if d <= 0 && name == "every" {
+				return object.Errorf("every: interval must be positive, got %s", d)
+			}
+			job := &Job{fn: args[1], sched: s}
+			if name == "every" {
+				job.interval = d
+			}
+			return s.add(job, s.now().Add(d))
+		}
+	case "cron":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return object.Errorf("wrong number of arguments. want=2 got=%d", len(args))
+			}
+			expr, ok := args[0].(*object.String)
+			if !ok {
+				return object.Errorf("cron: expression must be string, got %s", args[0].Type())
+			}
+			c, err := ParseCron(expr.Value)
+			if err != nil {
+				return object.Errorf("%s", err)
+			}
+			job := &Job{fn: args[1], sched: s, cron: c}
+			next := c.Next(s.now())
+			if next.IsZero() {
+				return object.Errorf("cron %q never matches", expr.Value)
+			}
+			return s.add(job, next)
+		}
+	case "cancel_all":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 0 {
+				return object.Errorf("wrong number of arguments. want=0 got=%d", len(args))
+			}
+			return &object.Integer{Value: int64(s.CancelAll())}
+		}
+	default:
+		return nil
+	}
+}
+
+func (s *Scheduler) add(job *Job, next time.Time) object.Object {
+	switch job.fn.(type) {
+	case *object.Function, *object.Builtin, *object.BoundMethod:
+	default:
+		return object.Errorf("job must be function, got %s", job.fn.Type())
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.shutdown {
+		return object.Errorf("scheduler shut down")
+	}
+	s.seq++
+	job.id = s.seq
+	job.next = next
+	s.jobs = append(s.jobs, job)
+	s.notify()
+	return job
+}
+
+func (s *Scheduler) notify() {
+	select {
+	case s.wakeup <- struct{}{}:
+	default:
+	}
+}
+
+// Len returns the number of the scheduled jobs.
+func (s *Scheduler) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.jobs)
+}
+
+// Next returns the time of the earliest job, false if there is no job.
+func (s *Scheduler) Next() (time.Time, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.jobs) == 0 {
+		return time.Time{}, false
+	}
+	next := s.jobs[0].next
+	for _, j := range s.jobs[1:] {
+		if j.next.Before(next) {
+			next = j.next
+		}
+	}
+	return next, true
+}
+
+// popDue removes and returns the earliest job due at now.
+func (s *Scheduler) popDue(now time.Time) *Job {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	idx := -1
+	for i, j := range s.jobs {
+		if j.next.After(now) {
+			continue
+		}
+		if idx < 0 || j.next.Before(s.jobs[idx].next) {
+			idx = i
+		}
+	}
+	if idx < 0 {
+		return nil
+	}
+	job := s.jobs[idx]
+	s.jobs = append(s.jobs[:idx], s.jobs[idx+1:]...)
+	return job
+}
+
+// RunPending executes the jobs due at the current time of the TimeProvider in the order of their schedule.
+// A job which missed several periods, after the clock jumped for example,
+// runs once and then it is scheduled at its first period after the current time.
+// It returns the errors of the jobs.
+func (s *Scheduler) RunPending() []error {
+	s.execMu.Lock()
+	defer s.execMu.Unlock()
+	now := s.now()
+	var errs []error
+	for {
+		job := s.popDue(now)
+		if job == nil {
+			break
+		}
+		ret := eval.Apply(job.fn)
+		if errObj, ok := ret.(*object.Error); ok {
+			errs = append(errs, fmt.Errorf("job #%d: %s", job.id, errObj.Message))
+		}
+		s.reschedule(job, now)
+	}
+	return errs
+}
+
+// reschedule adds job back at its next period after now, unless it is finished.
+func (s *Scheduler) reschedule(job *Job, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if job.cancelled || s.shutdown {
+		job.cancelled = true
+		return
+	}
+	switch {
+	case job.interval > 0:
+		job.next = job.next.Add(job.interval)
+		if !job.next.After(now) {
+			job.next = job.next.Add((now.Sub(job.next)/job.interval + 1) * job.interval)
+		}
+	case job.cron != nil:
+		job.next = job.cron.Next(now)
+		if job.next.IsZero() {
+			job.cancelled = true
+			return
+		}
+	default:
+		job.cancelled = true
+		return
+	}
+	s.jobs = append(s.jobs, job)
+}
+
+// Run executes the jobs on the wall clock until ctx is done or the scheduler is shut down.
+// The errors of the jobs are reported to OnError.
+func (s *Scheduler) Run(ctx context.Context) (err error) {
+	for {
+		for _, err := range s.RunPending() {
+			if s.OnError != nil {
+				s.OnError(err)
+			}
+		}
+		var timer *time.Timer
+		var expired <-chan time.Time
+		if next, ok := s.Next(); ok {
+			timer = time.NewTimer(next.Sub(s.now()))
+			expired = timer.C
+		}
+		select {
+		case <-ctx.Done():
+			err = ctx.Err()
+		case <-s.done:
+			err = ErrShutdown
+		case <-s.wakeup:
+		case <-expired:
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// CancelAll cancels all of the scheduled jobs and returns the number of them.
+func (s *Scheduler) CancelAll() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := len(s.jobs)
+	for _, j := range s.jobs {
+		j.cancelled = true
+	}
+	s.jobs = nil
+	return n
+}
+
+// Shutdown cancels all of the jobs, stops Run and rejects new jobs.
+// It waits for the running job to finish.
+func (s *Scheduler) Shutdown() {
+	s.CancelAll()
+	s.mu.Lock()
+	if !s.shutdown {
+		s.shutdown = true
+		close(s.done)
+	}
+	s.mu.Unlock()
+	s.execMu.Lock()
+	defer s.execMu.Unlock()
+}
+
+// Jobs returns the scheduled jobs in the order of their schedule.
+func (s *Scheduler) Jobs() []*Job {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ret := make([]*Job, len(s.jobs))
+	copy(ret, s.jobs)
+	sort.SliceStable(ret, func(i, j int) bool { return ret[i].next.Before(ret[j].next) })
+	return ret
+}
+
+// Job is a scheduled function, which is the handle to cancel it in scripts.
+type Job struct {
+	id        int64
+	fn        object.Object
+	next      time.Time
+	interval  time.Duration
+	cron      *Cron
+	cancelled bool
+	sched     *Scheduler
+}
+
+var _ object.Object = &Job{}
+
+func (j *Job) Type() object.ObjectType { return "sched.Job" }
+
+func (j *Job) Inspect() string { return fmt.Sprintf("sched.Job(#%d)", j.id) }
+
+// ID returns the identifier of the job.
+func (j *Job) ID() int64 { return j.id }
+
+// NextTime returns the time when the job runs next.
+func (j *Job) NextTime() time.Time {
+	j.sched.mu.Lock()
+	defer j.sched.mu.Unlock()
+	return j.next
+}
+
+// Cancel removes the job from the scheduler,
+// it returns false if the job has been already cancelled or finished.
+func (j *Job) Cancel() bool {
+	s := j.sched
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if j.cancelled {
+		return false
+	}
+	j.cancelled = true
+	for i, other := range s.jobs {
+		if other == j {
+			s.jobs = append(s.jobs[:i], s.jobs[i+1:]...)
+			break
+		}
+	}
+	return true
+}
+
+func (j *Job) Member(name string) object.MemberFunc {
+	switch name {
+	case "cancel":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 0 {
+				return object.Errorf("wrong number of arguments. want=0 got=%d", len(args))
+			}
+			return &object.Boolean{Value: j.Cancel()}
+		}
+	case "active":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 0 {
+				return object.Errorf("wrong number of arguments. want=0 got=%d", len(args))
+			}
+			j.sched.mu.Lock()
+			defer j.sched.mu.Unlock()
+			return &object.Boolean{Value: !j.cancelled}
+		}
+	default:
+		return nil
+	}
+}
+
+// toDuration accepts a time.Duration, an integer of nanoseconds or a string like "1m30s".
+func toDuration(obj object.Object) (time.Duration, error) {
+	if nv, ok := obj.(object.NativeValuer); ok {
+		if d, ok := nv.NativeValue().(time.Duration); ok {
+			return d, nil
+		}
+	}
+	switch v := obj.(type) {
+	case *object.Integer:
+		return time.Duration(v.Value), nil
+	case *object.String:
+		return time.ParseDuration(v.Value)
+	default:
+		return 0, fmt.Errorf("duration must be time.Duration, int or string, got %s", obj.Type())
+	}
+}
@@ -0,0 +1,199 @@
+package sched_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/thingsme/thingscript/eval"
+	"github.com/thingsme/thingscript/lexer"
+	"github.com/thingsme/thingscript/object"
+	"github.com/thingsme/thingscript/parser"
+	"github.com/thingsme/thingscript/sched"
+	"github.com/thingsme/thingscript/stdlib"
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func (c *fakeClock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+func setup(t *testing.T, clock *fakeClock, input string) (*sched.Scheduler, *bytes.Buffer) {
+	t.Helper()
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	for _, err := range p.Errors() {
+		t.Fatalf("parse error: %s", err)
+	}
+	out := &bytes.Buffer{}
+	env := object.NewEnvironment()
+	env.Stdout = out
+	if clock != nil {
+		env.TimeProvider = clock.Now
+	}
+	env.RegisterPackages(stdlib.Packages()...)
+	s := sched.NewScheduler()
+	env.RegisterPackages(s)
+	if ret := eval.Eval(program, env); ret != nil && ret.Type() == object.ERROR_OBJ {
+		t.Fatalf("eval error: %s", ret.Inspect())
+	}
+	return s, out
+}
+
+func TestFakeClock(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	s, out := setup(t, clock, `
+		out := import("fmt")
+		s := import("sched")
+		n := 0
+		time := import("time")
+		job := s.every(time.seconds(10), func() { n += 1; out.println("every", n) })
+		s.after("25s", func() { out.println("after"); job.cancel() })
+		s.cron("*/1 * * * *", func() { out.println("cron") })
+	`)
+	if s.Len() != 3 {
+		t.Fatalf("expect 3 jobs, got=%d", s.Len())
+	}
+	if errs := s.RunPending(); len(errs) != 0 || out.Len() != 0 {
+		t.Fatalf("nothing should run, got %v %q", errs, out.String())
+	}
+	clock.Advance(10 * time.Second)
+	s.RunPending()
+	clock.Advance(20 * time.Second)
+	s.RunPending()
+	clock.Advance(30 * time.Second)
+	s.RunPending()
+	expected := "every 1\nevery 2\nafter\ncron\n"
+	if out.String() != expected {
+		t.Errorf("expected %q, got=%q", expected, out.String())
+	}
+	if next, ok := s.Next(); !ok || !next.Equal(time.Date(2024, 1, 1, 0, 2, 0, 0, time.UTC)) {
+		t.Errorf("wrong next schedule %s", next)
+	}
+	if s.Len() != 1 {
+		t.Errorf("only cron job should remain, got=%d", s.Len())
+	}
+}
+
+func TestClockJump(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	s, out := setup(t, clock, `
+		out := import("fmt")
+		s := import("sched")
+		s.every("10s", func() { out.println("every") })
+		s.cron("*/1 * * * *", func() { out.println("cron") })
+	`)
+	clock.Advance(time.Hour + 5*time.Second)
+	s.RunPending()
+	if out.String() != "every\ncron\n" {
+		t.Errorf("missed periods should run once, got=%q", out.String())
+	}
+	jobs := s.Jobs()
+	if len(jobs) != 2 || !jobs[0].NextTime().Equal(time.Date(2024, 1, 1, 1, 0, 10, 0, time.UTC)) ||
+		!jobs[1].NextTime().Equal(time.Date(2024, 1, 1, 1, 1, 0, 0, time.UTC)) {
+		t.Errorf("jobs should be scheduled after the current time, got=%v", jobs)
+	}
+}
+
+func TestTimeProviderAfterLoad(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	env := object.NewEnvironment()
+	s := sched.NewScheduler()
+	env.RegisterPackages(stdlib.Packages()...)
+	env.RegisterPackages(s)
+	env.TimeProvider = clock.Now
+	eval.Eval(parser.New(lexer.New(`import("sched").after("1m", func() { 1 })`)).ParseProgram(), env)
+	if next, ok := s.Next(); !ok || !next.Equal(clock.now.Add(time.Minute)) {
+		t.Errorf("the TimeProvider set after loading should be used, got=%s", next)
+	}
+}
+
+func TestJobError(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	s, _ := setup(t, clock, `
+		s := import("sched")
+		s.after(1000, func() { undefined_var })
+	`)
+	clock.Advance(time.Second)
+	errs := s.RunPending()
+	if len(errs) != 1 || errs[0].Error() != "job #1: identifier not found: undefined_var" {
+		t.Errorf("unexpected errors %v", errs)
+	}
+	if s.Len() != 0 {
+		t.Errorf("one-shot job should be removed")
+	}
+}
+
+func TestSchedErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`import("sched").after("x", func(){})`, `after: time: invalid duration "x"`},
+		{`import("sched").every(0, func(){})`, `every: interval must be positive, got 0s`},
+		{`import("sched").after(true, func(){})`, `after: duration must be time.Duration, int or string, got BOOLEAN`},
+		{`import("sched").after("1s", 1)`, `job must be function, got INTEGER`},
+		{`import("sched").cron("* *", func(){})`, `cron "* *": expected 5 fields, got 2`},
+		{`import("sched").cron("0 0 31 2 *", func(){})`, `cron "0 0 31 2 *" never matches`},
+	}
+	for _, tt := range tests {
+		env := object.NewEnvironment()
+		env.RegisterPackages(stdlib.Packages()...)
+		env.RegisterPackages(sched.NewScheduler())
+		ret := eval.Eval(parser.New(lexer.New(tt.input)).ParseProgram(), env)
+		if err, ok := ret.(*object.Error); !ok || err.Message != tt.expected {
+			t.Errorf("expected error %q, got=%v", tt.expected, ret)
+		}
+	}
+}
+
+func TestShutdown(t *testing.T) {
+	s, _ := setup(t, nil, `
+		s := import("sched")
+		s.every("1h", func() {})
+	`)
+	done := make(chan error)
+	go func() {
+		done <- s.Run(context.Background())
+	}()
+	s.Shutdown()
+	if err := <-done; !errors.Is(err, sched.ErrShutdown) {
+		t.Errorf("expect ErrShutdown, got=%v", err)
+	}
+	if s.Len() != 0 {
+		t.Errorf("jobs should be cancelled")
+	}
+	env := object.NewEnvironment()
+	env.RegisterPackages(s)
+	ret := eval.Eval(parser.New(lexer.New(`import("sched").after("1s", func(){})`)).ParseProgram(), env)
+	if ret.Type() != object.ERROR_OBJ {
+		t.Errorf("new jobs should be rejected after shutdown")
+	}
+}
+
+func TestRun(t *testing.T) {
+	s, out := setup(t, nil, `
+		out := import("fmt")
+		s := import("sched")
+		s.after("10ms", func() { out.println("fired") })
+	`)
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	done := make(chan error)
+	go func() {
+		done <- s.Run(ctx)
+	}()
+	for s.Len() > 0 {
+		time.Sleep(5 * time.Millisecond)
+	}
+	s.Shutdown()
+	<-done
+	if out.String() != "fired\n" {
+		t.Errorf("expected fired, got=%q", out.String())
+	}
+}
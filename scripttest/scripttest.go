@@ -0,0 +1,291 @@
+// Package scripttest runs the tests written in the scripts.
+//
+// The test files are named like "math_test.txs", and their functions named like "test_add" are the tests.
+// A test fails if it returns an error, like the failures of the assert package.
+// The output of a test is compared with the "// output:" comment in its body if any,
+// the lines of the expected output follow "output:" and the next line comments.
+//
+//	func test_hello() {
+//	    import("fmt").println("hello")
+//	    // output:
+//	    // hello
+//	}
+package scripttest
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/thingsme/thingscript/ast"
+	"github.com/thingsme/thingscript/eval"
+	"github.com/thingsme/thingscript/lexer"
+	"github.com/thingsme/thingscript/object"
+	"github.com/thingsme/thingscript/parser"
+	"github.com/thingsme/thingscript/stdlib"
+	"github.com/thingsme/thingscript/token"
+)
+
+// Suffix is the suffix of the names of the test files.
+const Suffix = "_test.txs"
+
+// Prefix is the prefix of the names of the test functions.
+const Prefix = "test_"
+
+// Result is the result of a test.
+type Result struct {
+	Name string
+	// Pos is the position of the failure, or of the test function if the failure has no position.
+	Pos      token.Position
+	Passed   bool
+	Message  string
+	Duration time.Duration
+}
+
+// Runner runs the test files and reports the results.
+type Runner struct {
+	// NewEnvironment returns the environment evaluating the test file at path.
+	// Its Stdout must be stdout, which captures the output of the tests, before the packages are registered.
+	// The runner registers the assert package itself.
+	NewEnvironment func(path string, stdout io.Writer) *object.Environment
+	// Out receives the report, it defaults to os.Stdout.
+	Out io.Writer
+	// Verbose reports the passed tests as well.
+	Verbose bool
+}
+
+// Discover returns the test files in the directory root and its subdirectories in the order of their paths,
+// or root itself if it is a file.
+func Discover(root string) ([]string, error) {
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{root}, nil
+	}
+	var files []string
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && strings.HasSuffix(d.Name(), Suffix) {
+			files = append(files, path)
+		}
+		return nil
+	})
+	sort.Strings(files)
+	return files, err
+}
+
+// Run runs the test files under the roots and returns true if all the tests passed.
+func (r *Runner) Run(roots ...string) (bool, error) {
+	out := r.Out
+	if out == nil {
+		out = os.Stdout
+	}
+	var files []string
+	for _, root := range roots {
+		found, err := Discover(root)
+		if err != nil {
+			return false, err
+		}
+		files = append(files, found...)
+	}
+	if len(files) == 0 {
+		return false, fmt.Errorf("no test files in %s", strings.Join(roots, ", "))
+	}
+	passed := true
+	for _, path := range files {
+		start := time.Now()
+		results, err := r.RunFile(path)
+		if err != nil {
+			passed = false
+			fmt.Fprintf(out, "FAIL\t%s\n\t%s\n", path, err)
+			continue
+		}
+		filePassed := true
+		for _, res := range results {
+			if res.Passed {
+				if r.Verbose {
+					fmt.Fprintf(out, "--- PASS: %s (%.2fs)\n", res.Name, res.Duration.Seconds())
+				}
+				continue
+			}
+			filePassed = false
+			fmt.Fprintf(out, "--- FAIL: %s (%.2fs)\n", res.Name, res.Duration.Seconds())
+			fmt.Fprintf(out, "    %s:%d:%d: %s\n", path, res.Pos.Line, res.Pos.Column, indent(res.Message))
+		}
+		elapsed := time.Since(start).Seconds()
+		switch {
+		case !filePassed:
+			passed = false
+			fmt.Fprintf(out, "FAIL\t%s\t%.3fs\n", path, elapsed)
+		case len(results) == 0:
+			fmt.Fprintf(out, "ok  \t%s\t%.3fs [no tests]\n", path, elapsed)
+		default:
+			fmt.Fprintf(out, "ok  \t%s\t%.3fs\n", path, elapsed)
+		}
+	}
+	return passed, nil
+}
+
+func indent(s string) string {
+	return strings.ReplaceAll(s, "\n", "\n        ")
+}
+
+// errorPosition matches the errors prefixed with their positions.
+var errorPosition = regexp.MustCompile(`^\[Ln (\d+), Col (\d+)\] ((?s).*)$`)
+
+// RunFile evaluates the test file at path and runs its tests in the order of their declarations.
+// It returns an error if the file can not be parsed or evaluated.
+func (r *Runner) RunFile(path string) ([]Result, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	source := string(b)
+	p := parser.New(lexer.New(source))
+	program := p.ParseProgram()
+	if errs := p.Errors(); len(errs) > 0 {
+		return nil, fmt.Errorf("%s", strings.Join(errs, "\n\t"))
+	}
+
+	stdout := &buffer{}
+	var env *object.Environment
+	if r.NewEnvironment != nil {
+		env = r.NewEnvironment(path, stdout)
+	} else {
+		env = object.NewEnvironment()
+		env.Stdout = stdout
+	}
+	env.RegisterPackages(stdlib.AssertPackage())
+	if ret := eval.Eval(program, env); ret != nil && ret.Type() == object.ERROR_OBJ {
+		return nil, fmt.Errorf("%s", ret.Inspect())
+	}
+
+	outputs := expectedOutputs(source)
+	var results []Result
+	for _, stmt := range program.Statements {
+		decl, ok := stmt.(*ast.FunctionStatement)
+		if !ok || !strings.HasPrefix(decl.Name.Value, Prefix) {
+			continue
+		}
+		fn, ok := env.Get(decl.Name.Value)
+		if !ok {
+			continue
+		}
+		stdout.Reset()
+		start := time.Now()
+		ret := eval.Apply(fn)
+		res := Result{Name: decl.Name.Value, Pos: decl.Name.Token.Position, Passed: true, Duration: time.Since(start)}
+		if errObj, ok := ret.(*object.Error); ok {
+			res.Passed = false
+			res.Message = errObj.Message
+			if m := errorPosition.FindStringSubmatch(errObj.Message); m != nil {
+				res.Pos.Line, _ = strconv.Atoi(m[1])
+				res.Pos.Column, _ = strconv.Atoi(m[2])
+				res.Message = m[3]
+			}
+		} else if want, ok := outputs[decl.Body.Token.Position]; ok {
+			if got := normalize(stdout.String()); got != want.text {
+				res.Passed = false
+				res.Pos = want.pos
+				res.Message = fmt.Sprintf("output mismatch\ngot:\n%s\nwant:\n%s", got, want.text)
+			}
+		}
+		results = append(results, res)
+	}
+	return results, nil
+}
+
+// normalize removes the trailing spaces of the lines and the blank lines at the beginning and the end.
+func normalize(s string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t\r")
+	}
+	return strings.Trim(strings.Join(lines, "\n"), "\n")
+}
+
+type output struct {
+	pos  token.Position
+	text string
+}
+
+// expectedOutputs returns the expected outputs by the positions of the opening braces of the blocks
+// which have the "// output:" comments.
+func expectedOutputs(source string) map[token.Position]output {
+	outputs := make(map[token.Position]output)
+	var open []token.Position
+	var lines []string
+	var cur *output
+	var block token.Position
+	flush := func() {
+		if cur != nil {
+			cur.text = normalize(strings.Join(lines, "\n"))
+			outputs[block] = *cur
+			cur, lines = nil, nil
+		}
+	}
+	l := lexer.New(source)
+	prevLine := 0
+	for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+		if tok.Type != token.COMMENT || tok.Position.Line != prevLine+1 {
+			flush()
+		}
+		prevLine = tok.Position.Line
+		switch tok.Type {
+		case token.LBRACE:
+			open = append(open, tok.Position)
+		case token.RBRACE:
+			if len(open) > 0 {
+				open = open[:len(open)-1]
+			}
+		case token.COMMENT:
+			text := strings.TrimPrefix(tok.Literal, " ")
+			if cur != nil {
+				lines = append(lines, text)
+			} else if rest, ok := strings.CutPrefix(strings.TrimSpace(text), "output:"); ok && len(open) > 0 {
+				cur = &output{pos: tok.Position}
+				block = open[0]
+				lines = []string{strings.TrimSpace(rest)}
+			}
+		}
+	}
+	flush()
+	return outputs
+}
+
+// buffer is the bytes.Buffer written by the goroutines of the scripts as well.
+type buffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *buffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *buffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func (b *buffer) Reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.buf.Reset()
+}
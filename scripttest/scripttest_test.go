@@ -0,0 +1,159 @@
+package scripttest_test
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/thingsme/thingscript/object"
+	"github.com/thingsme/thingscript/scripttest"
+	"github.com/thingsme/thingscript/stdlib"
+)
+
+func newRunner(out io.Writer) *scripttest.Runner {
+	return &scripttest.Runner{
+		NewEnvironment: func(path string, stdout io.Writer) *object.Environment {
+			env := object.NewEnvironment()
+			env.Stdout = stdout
+			env.RegisterPackages(stdlib.Packages()...)
+			return env
+		},
+		Out:     out,
+		Verbose: true,
+	}
+}
+
+func writeFiles(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(path), 0o755)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestDiscover(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"b_test.txs":     ``,
+		"a_test.txs":     ``,
+		"main.txs":       ``,
+		"sub/c_test.txs": ``,
+	})
+	files, err := scripttest.Discover(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, f := range files {
+		rel, _ := filepath.Rel(dir, f)
+		names = append(names, filepath.ToSlash(rel))
+	}
+	if strings.Join(names, ",") != "a_test.txs,b_test.txs,sub/c_test.txs" {
+		t.Errorf("unexpected files %v", names)
+	}
+}
+
+func TestRunFile(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"math_test.txs": `assert := import("assert")
+fmt := import("fmt")
+
+func add(a, b) { a + b }
+
+func test_add() {
+    assert.equal(add(1, 2), 3)
+}
+
+func test_sub() {
+    assert.equal(add(1, -1), 1, "sum")
+}
+
+func test_output() {
+    fmt.println("a:", 1)
+    fmt.println("b:", 2)
+    // output:
+    // a: 1
+    // b: 2
+}
+
+func test_output_mismatch() {
+    if true {
+        fmt.println("x")
+    }
+    // output: y
+}
+
+func test_runtime_error() {
+    1 + "a"
+}
+
+func helper() {
+    // output: not a test
+}
+`})
+	results, err := newRunner(io.Discard).RunFile(filepath.Join(dir, "math_test.txs"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []struct {
+		name    string
+		passed  bool
+		pos     string
+		message string
+	}{
+		{"test_add", true, "Ln 6, Col 6", ""},
+		{"test_sub", false, "Ln 11, Col 12", "sum: not equal: got 0, want 1"},
+		{"test_output", true, "Ln 14, Col 6", ""},
+		{"test_output_mismatch", false, "Ln 26, Col 5", "output mismatch\ngot:\nx\nwant:\ny"},
+		{"test_runtime_error", false, "Ln 29, Col 6", "type mismatch: INTEGER + STRING"},
+	}
+	if len(results) != len(expected) {
+		t.Fatalf("expected %d results, got=%+v", len(expected), results)
+	}
+	for i, tt := range expected {
+		res := results[i]
+		if res.Name != tt.name || res.Passed != tt.passed || res.Pos.String() != tt.pos || res.Message != tt.message {
+			t.Errorf("expected %+v, got=%+v", tt, res)
+		}
+	}
+}
+
+func TestRun(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"ok_test.txs": `func test_ok() { import("assert").is_true(true) }`,
+		"ng_test.txs": `func test_ng() {
+    import("assert").is_true(false)
+}`,
+		"syntax_test.txs": `func test_syntax( {`,
+	})
+	var out bytes.Buffer
+	passed, err := newRunner(&out).Run(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if passed {
+		t.Errorf("expected failures")
+	}
+	report := regexp.MustCompile(`\d+\.\d+s`).ReplaceAllString(out.String(), "0s")
+	report = strings.ReplaceAll(report, dir+string(filepath.Separator), "")
+	expected := `--- FAIL: test_ng (0s)
+    ng_test.txs:2:22: not true: got false
+FAIL	ng_test.txs	0s
+--- PASS: test_ok (0s)
+ok  	ok_test.txs	0s
+FAIL	syntax_test.txs
+`
+	if !strings.HasPrefix(report, expected) {
+		t.Errorf("unexpected report %q", report)
+	}
+
+	if _, err := newRunner(&out).Run(t.TempDir()); err == nil {
+		t.Errorf("expected an error without test files")
+	}
+}
@@ -0,0 +1,316 @@
+// Package snapshot saves the variables of an environment and restores them later,
+// so that the state of long-running scripts survives the restart of the host.
+//
+//	program := parser.New(lexer.New(script)).ParseProgram()
+//	eval.Eval(program, env)
+//	if data, err := os.ReadFile("state.json"); err == nil {
+//		err = snapshot.New().Restore(data, env, program)
+//	}
+//	...
+//	data, err := snapshot.New().Save(env)
+//
+// The snapshot is versioned JSON. Integers, floats, booleans, strings, bytes, nil, arrays and maps are saved
+// by value, and the functions are saved by the position of their source in the program,
+// so they are restored from the same program. The other objects are saved by the codecs registered for their types.
+package snapshot
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"strconv"
+
+	"github.com/thingsme/thingscript/ast"
+	"github.com/thingsme/thingscript/object"
+	"github.com/thingsme/thingscript/token"
+)
+
+// Version is the version of the snapshot format written by Save.
+const Version = 1
+
+// maxDepth limits the nesting of the arrays and maps.
+const maxDepth = 100
+
+// Codec saves and restores the objects of a Go-defined type.
+type Codec struct {
+	Encode func(obj object.Object) (json.RawMessage, error)
+	Decode func(data json.RawMessage) (object.Object, error)
+}
+
+// Snapshotter saves and restores the variables of environments.
+type Snapshotter struct {
+	codecs map[object.ObjectType]Codec
+}
+
+// New returns a Snapshotter without codecs.
+func New() *Snapshotter {
+	return &Snapshotter{codecs: make(map[object.ObjectType]Codec)}
+}
+
+// RegisterCodec registers the codec of the objects of typ.
+func (s *Snapshotter) RegisterCodec(typ object.ObjectType, codec Codec) {
+	s.codecs[typ] = codec
+}
+
+type document struct {
+	Version   int               `json:"version"`
+	Variables map[string]*value `json:"variables"`
+}
+
+type value struct {
+	Type     object.ObjectType `json:"type"`
+	Value    json.RawMessage   `json:"value,omitempty"`
+	Elements []*value          `json:"elements,omitempty"`
+	Pairs    []pair            `json:"pairs,omitempty"`
+	Ordered  bool              `json:"ordered,omitempty"`
+	Function *functionRef      `json:"function,omitempty"`
+}
+
+type pair struct {
+	Key   *value `json:"key"`
+	Value *value `json:"value"`
+}
+
+// functionRef refers to a function by the position of its body in the program,
+// Hash detects the change of the source.
+type functionRef struct {
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
+	Hash   string `json:"hash"`
+}
+
+// Save returns the snapshot of the variables defined in env, excluding the outer environments.
+// Builtins are skipped since they are provided by the host.
+func (s *Snapshotter) Save(env *object.Environment) ([]byte, error) {
+	doc := document{Version: Version, Variables: make(map[string]*value)}
+	for _, name := range env.Names() {
+		obj, _ := env.Get(name)
+		if _, ok := obj.(*object.Builtin); ok {
+			continue
+		}
+		v, err := s.encode(obj, env, 0)
+		if err != nil {
+			return nil, fmt.Errorf("variable %q: %w", name, err)
+		}
+		doc.Variables[name] = v
+	}
+	return json.Marshal(doc)
+}
+
+func (s *Snapshotter) encode(obj object.Object, env *object.Environment, depth int) (*value, error) {
+	if depth > maxDepth {
+		return nil, errors.New("value is nested too deeply")
+	}
+	if obj == nil {
+		obj = object.NULL
+	}
+	v := &value{Type: obj.Type()}
+	var err error
+	switch obj := obj.(type) {
+	case *object.Null:
+	case *object.Integer:
+		v.Value, err = json.Marshal(obj.Value)
+	case *object.Float:
+		// the float is saved as a string to keep the values which JSON can not represent, like +Inf
+		v.Value, err = json.Marshal(strconv.FormatFloat(obj.Value, 'g', -1, 64))
+	case *object.Boolean:
+		v.Value, err = json.Marshal(obj.Value)
+	case *object.String:
+		v.Value, err = json.Marshal(obj.Value)
+	case *object.Bytes:
+		v.Value, err = json.Marshal(obj.Value)
+	case *object.Array:
+		v.Elements = make([]*value, len(obj.Elements))
+		for i, e := range obj.Elements {
+			if v.Elements[i], err = s.encode(e, env, depth+1); err != nil {
+				return nil, fmt.Errorf("[%d]: %w", i, err)
+			}
+		}
+	case *object.HashMap:
+		v.Ordered = obj.Keys != nil
+		for _, p := range obj.OrderedPairs() {
+			key, err := s.encode(p.Key, env, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			val, err := s.encode(p.Value, env, depth+1)
+			if err != nil {
+				return nil, fmt.Errorf("[%s]: %w", p.Key.Inspect(), err)
+			}
+			v.Pairs = append(v.Pairs, pair{Key: key, Value: val})
+		}
+	case *object.Function:
+		pos := obj.Body.Token.Position
+		if obj.Env != env {
+			return nil, fmt.Errorf("function at %s is a closure of a local environment", pos)
+		}
+		v.Function = &functionRef{Line: pos.Line, Column: pos.Column, Hash: functionHash(obj.Parameters, obj.Body)}
+	default:
+		codec, ok := s.codecs[obj.Type()]
+		if !ok {
+			return nil, fmt.Errorf("%s is not serializable, it has no codec", obj.Type())
+		}
+		if v.Value, err = codec.Encode(obj); err != nil {
+			return nil, fmt.Errorf("%s: %w", obj.Type(), err)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// Restore defines the variables of the snapshot in env, replacing the existing variables.
+// The functions are restored from program, which should be the program evaluated in env.
+func (s *Snapshotter) Restore(data []byte, env *object.Environment, program *ast.Program) error {
+	var doc document
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("invalid snapshot: %w", err)
+	}
+	if doc.Version != Version {
+		return fmt.Errorf("unsupported snapshot version %d", doc.Version)
+	}
+	r := &restorer{s: s, env: env, program: program}
+	objects := make(map[string]object.Object, len(doc.Variables))
+	for name, v := range doc.Variables {
+		obj, err := r.decode(v, 0)
+		if err != nil {
+			return fmt.Errorf("variable %q: %w", name, err)
+		}
+		objects[name] = obj
+	}
+	// the variables are defined after all of them are decoded, so that an error does not leave env half restored
+	for name, obj := range objects {
+		if ret := env.Set(name, obj); ret != nil && ret.Type() == object.ERROR_OBJ {
+			return errors.New(ret.(*object.Error).Message)
+		}
+	}
+	return nil
+}
+
+type restorer struct {
+	s         *Snapshotter
+	env       *object.Environment
+	program   *ast.Program
+	functions map[token.Position]*object.Function
+}
+
+func (r *restorer) decode(v *value, depth int) (object.Object, error) {
+	if v == nil {
+		return nil, errors.New("missing value")
+	}
+	if depth > maxDepth {
+		return nil, errors.New("value is nested too deeply")
+	}
+	var err error
+	switch v.Type {
+	case object.NULL_OBJ:
+		return object.NULL, nil
+	case object.INTEGER_OBJ:
+		ret := &object.Integer{}
+		err = json.Unmarshal(v.Value, &ret.Value)
+		return ret, err
+	case object.FLOAT_OBJ:
+		var s string
+		if err = json.Unmarshal(v.Value, &s); err != nil {
+			return nil, err
+		}
+		ret := &object.Float{}
+		ret.Value, err = strconv.ParseFloat(s, 64)
+		return ret, err
+	case object.BOOLEAN_OBJ:
+		ret := &object.Boolean{}
+		err = json.Unmarshal(v.Value, &ret.Value)
+		return ret, err
+	case object.STRING_OBJ:
+		ret := &object.String{}
+		err = json.Unmarshal(v.Value, &ret.Value)
+		return ret, err
+	case object.BYTES_OBJ:
+		ret := &object.Bytes{}
+		err = json.Unmarshal(v.Value, &ret.Value)
+		if ret.Value == nil {
+			ret.Value = []byte{}
+		}
+		return ret, err
+	case object.ARRAY_OBJ:
+		elements := make([]object.Object, len(v.Elements))
+		for i, e := range v.Elements {
+			if elements[i], err = r.decode(e, depth+1); err != nil {
+				return nil, fmt.Errorf("[%d]: %w", i, err)
+			}
+		}
+		return &object.Array{Elements: elements}, nil
+	case object.HASHMAP_OBJ:
+		ret := &object.HashMap{Pairs: make(map[object.HashKey]object.HashPair, len(v.Pairs))}
+		if v.Ordered {
+			ret = object.NewOrderedHashMap()
+		}
+		for _, p := range v.Pairs {
+			key, err := r.decode(p.Key, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := key.(object.Hashable); !ok {
+				return nil, fmt.Errorf("unusable as hash key: %s", key.Type())
+			}
+			val, err := r.decode(p.Value, depth+1)
+			if err != nil {
+				return nil, fmt.Errorf("[%s]: %w", key.Inspect(), err)
+			}
+			ret.Set(key, val)
+		}
+		return ret, nil
+	case object.FUNCTION_OBJ:
+		if v.Function == nil {
+			return nil, errors.New("missing function reference")
+		}
+		return r.function(v.Function)
+	default:
+		codec, ok := r.s.codecs[v.Type]
+		if !ok {
+			return nil, fmt.Errorf("%s is not serializable, it has no codec", v.Type)
+		}
+		ret, err := codec.Decode(v.Value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", v.Type, err)
+		}
+		return ret, nil
+	}
+}
+
+// function returns the function of the program at the position of ref.
+func (r *restorer) function(ref *functionRef) (object.Object, error) {
+	pos := token.Position{Line: ref.Line, Column: ref.Column}
+	if r.program == nil {
+		return nil, fmt.Errorf("function at %s can not be restored without the program", pos)
+	}
+	if r.functions == nil {
+		r.functions = make(map[token.Position]*object.Function)
+		ast.Inspect(r.program, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.FunctionStatement:
+				r.functions[n.Body.Token.Position] = &object.Function{Name: n.Name.Value, Parameters: n.Parameters, Body: n.Body, Env: r.env}
+			case *ast.FunctionLiteral:
+				r.functions[n.Body.Token.Position] = &object.Function{Parameters: n.Parameters, Body: n.Body, Env: r.env}
+			}
+			return true
+		})
+	}
+	fn, ok := r.functions[pos]
+	if !ok || functionHash(fn.Parameters, fn.Body) != ref.Hash {
+		return nil, fmt.Errorf("function at %s is not found in the program", pos)
+	}
+	return fn, nil
+}
+
+func functionHash(params []*ast.Identifier, body *ast.BlockStatement) string {
+	h := fnv.New64a()
+	for _, p := range params {
+		h.Write([]byte(p.Value))
+		h.Write([]byte{0})
+	}
+	h.Write([]byte(body.String()))
+	return strconv.FormatUint(h.Sum64(), 16)
+}
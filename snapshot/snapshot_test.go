@@ -0,0 +1,214 @@
+package snapshot_test
+
+import (
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/thingsme/thingscript/ast"
+	"github.com/thingsme/thingscript/eval"
+	"github.com/thingsme/thingscript/lexer"
+	"github.com/thingsme/thingscript/object"
+	"github.com/thingsme/thingscript/parser"
+	"github.com/thingsme/thingscript/snapshot"
+	"github.com/thingsme/thingscript/stdlib"
+)
+
+func load(t *testing.T, input string) (*object.Environment, *ast.Program) {
+	t.Helper()
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	if len(p.Errors()) > 0 {
+		t.Fatalf("parse errors %v", p.Errors())
+	}
+	env := object.NewEnvironment()
+	env.RegisterPackages(stdlib.Packages()...)
+	if ret := eval.Eval(program, env); ret != nil && ret.Type() == object.ERROR_OBJ {
+		t.Fatalf("eval error %s", ret.Inspect())
+	}
+	return env, program
+}
+
+func evalIn(env *object.Environment, input string) object.Object {
+	return eval.Eval(parser.New(lexer.New(input)).ParseProgram(), env)
+}
+
+const script = `
+count := 0
+ratio := 0.5
+name := "gateway"
+on := true
+none := nil
+raw := bytes([1, 2, 255])
+readings := [1, 2.5, "three", [4]]
+state := {"t1": {"temp": 21.5}, 2: false}
+rows := import("csv").parse("z,a
+1,2", {"header": true})
+func inc(n) { count = count + n }
+double := func(x) { x * 2 }
+handlers := {"double": double, "inc": inc}
+`
+
+func TestSaveRestore(t *testing.T) {
+	env, program := load(t, script)
+	evalIn(env, `inc(5); ratio = 0.75; state = {"t1": {"temp": 30}}`)
+
+	s := snapshot.New()
+	data, err := s.Save(env)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	restored, _ := load(t, script)
+	if err := s.Restore(data, restored, program); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`count`, `5`},
+		{`ratio`, `0.750000`},
+		{`name`, `gateway`},
+		{`on`, `true`},
+		{`none`, `null`},
+		{`raw`, `0x0102ff`},
+		{`readings`, `[1, 2.500000, three, [4]]`},
+		{`state["t1"]["temp"]`, `30`},
+		{`rows[0].keys()`, `[z, a]`},
+		{`inc(2); count`, `7`},
+		{`double(4)`, `8`},
+		{`handlers["inc"](1); count`, `8`},
+		{`handlers["double"](5)`, `10`},
+	}
+	for _, tt := range tests {
+		ret := evalIn(restored, tt.input)
+		if ret == nil || ret.Inspect() != tt.expected {
+			t.Errorf("expected %s, got=%v <= %s", tt.expected, ret, tt.input)
+		}
+	}
+
+	var doc struct {
+		Version   int                        `json:"version"`
+		Variables map[string]json.RawMessage `json:"variables"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.Version != snapshot.Version {
+		t.Errorf("wrong version %d", doc.Version)
+	}
+	if _, ok := doc.Variables["import"]; ok {
+		t.Errorf("packages should not be saved")
+	}
+}
+
+func TestSaveFloats(t *testing.T) {
+	env := object.NewEnvironment()
+	env.Set("inf", &object.Float{Value: math.Inf(1)})
+	env.Set("pi", &object.Float{Value: math.Pi})
+	s := snapshot.New()
+	data, err := s.Save(env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	restored := object.NewEnvironment()
+	if err := s.Restore(data, restored, nil); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := restored.Get("inf"); !math.IsInf(v.(*object.Float).Value, 1) {
+		t.Errorf("expected +Inf, got=%s", v.Inspect())
+	}
+	if v, _ := restored.Get("pi"); v.(*object.Float).Value != math.Pi {
+		t.Errorf("expected pi, got=%s", v.Inspect())
+	}
+}
+
+func TestSaveErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`now := import("time").unix(0)`, `variable "now": time.Time is not serializable, it has no codec`},
+		{`items := [1, {"ch": import("sync").channel()}]`, `variable "items": [1]: [ch]: sync.Channel is not serializable, it has no codec`},
+		{`func counter() { n := 0; return func() { n } }
+			next := counter()`, `variable "next": function at Ln 1, Col 40 is a closure of a local environment`},
+	}
+	for _, tt := range tests {
+		env, _ := load(t, tt.input)
+		_, err := snapshot.New().Save(env)
+		if err == nil || err.Error() != tt.expected {
+			t.Errorf("expected error %q, got=%v", tt.expected, err)
+		}
+	}
+}
+
+func TestCodec(t *testing.T) {
+	env, _ := load(t, `started := import("time").unix(1700000000)`)
+	s := snapshot.New()
+	s.RegisterCodec("time.Time", snapshot.Codec{
+		Encode: func(obj object.Object) (json.RawMessage, error) {
+			return json.Marshal(obj.(object.NativeValuer).NativeValue())
+		},
+		Decode: func(data json.RawMessage) (object.Object, error) {
+			var tm time.Time
+			if err := json.Unmarshal(data, &tm); err != nil {
+				return nil, err
+			}
+			return &object.Integer{Value: tm.Unix()}, nil
+		},
+	})
+	data, err := s.Save(env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	restored := object.NewEnvironment()
+	if err := s.Restore(data, restored, nil); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := restored.Get("started"); v.Inspect() != "1700000000" {
+		t.Errorf("expected decoded value, got=%s", v.Inspect())
+	}
+}
+
+func TestRestoreErrors(t *testing.T) {
+	env, program := load(t, `func f(x) { x }`)
+	data, err := snapshot.New().Save(env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, changed := load(t, `func f(x) { x + 1 }`)
+
+	tests := []struct {
+		data     string
+		program  *ast.Program
+		expected string
+	}{
+		{string(data), nil, `variable "f": function at Ln 1, Col 11 can not be restored without the program`},
+		{string(data), changed, `variable "f": function at Ln 1, Col 11 is not found in the program`},
+		{`{"version": 2, "variables": {}}`, program, `unsupported snapshot version 2`},
+		{`{"version": 1, "variables": {"x": {"type": "time.Time", "value": 1}}}`, program, `variable "x": time.Time is not serializable, it has no codec`},
+		{`{"version": 1, "variables": {"x": {"type": "INTEGER", "value": "a"}}}`, program, `variable "x": json: cannot unmarshal string into Go value of type int64`},
+		{`{"version": 1, "variables": {"x": {"type": "HASHMAP", "pairs": [{"key": {"type": "ARRAY"}, "value": {"type": "NULL"}}]}}}`, program, `variable "x": unusable as hash key: ARRAY`},
+		{`[]`, program, `invalid snapshot: json: cannot unmarshal array into Go value of type snapshot.document`},
+	}
+	for _, tt := range tests {
+		restored := object.NewEnvironment()
+		err := snapshot.New().Restore([]byte(tt.data), restored, tt.program)
+		if err == nil || err.Error() != tt.expected {
+			t.Errorf("expected error %q, got=%v", tt.expected, err)
+		}
+		if len(restored.Names()) != 0 {
+			t.Errorf("environment should not be restored partially, got=%v", restored.Names())
+		}
+	}
+
+	frozen := object.NewEnvironment()
+	frozen.Freeze()
+	err = snapshot.New().Restore([]byte(`{"version": 1, "variables": {"x": {"type": "NULL"}}}`), frozen, nil)
+	if err == nil || !strings.Contains(err.Error(), "frozen") {
+		t.Errorf("expected frozen error, got=%v", err)
+	}
+}
@@ -0,0 +1,139 @@
+package stdlib
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/thingsme/thingscript/object"
+	"github.com/thingsme/thingscript/token"
+)
+
+// assertPkg checks the values in the tests, a failed assertion is an error
+// prefixed with the position of the call like "[Ln 3, Col 12] not equal: got 2, want 1".
+type assertPkg struct{}
+
+var _ object.Package = &assertPkg{}
+var _ object.Describer = &assertPkg{}
+var _ object.CallSiteMember = &assertPkg{}
+
+func (ap *assertPkg) Type() object.ObjectType { return object.PACKAGE_OBJ }
+
+func (ap *assertPkg) Inspect() string { return "package assert" }
+
+func (ap *assertPkg) Name() string { return "assert" }
+
+func (ap *assertPkg) OnLoad(env *object.Environment) {}
+
+func (ap *assertPkg) Member(name string) object.MemberFunc {
+	return ap.MemberAt(name, token.Position{})
+}
+
+func (ap *assertPkg) MemberAt(name string, pos token.Position) object.MemberFunc {
+	switch name {
+	case "equal", "deep_equal":
+		// equal(got, want, msg)
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 2 && len(args) != 3 {
+				return object.Errorf("wrong number of arguments. want=2 or 3 got=%d", len(args))
+			}
+			got, want := args[0], args[1]
+			var ok bool
+			switch {
+			case isNull(got) || isNull(want):
+				// the functions returning nothing return the nil of Go
+				ok = isNull(got) && isNull(want)
+			case name == "equal":
+				ok = got == want
+				if eq := got.Member("=="); eq != nil {
+					ret, isBool := eq(got, want).(*object.Boolean)
+					ok = isBool && ret.Value
+				}
+			default:
+				ok = equals(got, want)
+			}
+			if ok {
+				return nil
+			}
+			return assertFailure(pos, args[2:], "not equal: got %s, want %s", inspectValue(got), inspectValue(want))
+		}
+	case "is_true":
+		// is_true(value, msg)
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 1 && len(args) != 2 {
+				return object.Errorf("wrong number of arguments. want=1 or 2 got=%d", len(args))
+			}
+			if b, ok := args[0].(*object.Boolean); ok && b.Value {
+				return nil
+			}
+			return assertFailure(pos, args[1:], "not true: got %s", inspectValue(args[0]))
+		}
+	case "error":
+		// error(fn, substr) calls fn and checks that it returns an error containing substr
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 1 && len(args) != 2 {
+				return object.Errorf("wrong number of arguments. want=1 or 2 got=%d", len(args))
+			}
+			switch args[0].(type) {
+			case *object.Function, *object.Builtin, *object.BoundMethod:
+			default:
+				return object.Errorf("argument to error must be function, got %s", args[0].Type())
+			}
+			ret := callFunction(args[0])
+			errObj, ok := ret.(*object.Error)
+			if !ok {
+				return assertFailure(pos, nil, "no error: got %s", inspectValue(ret))
+			}
+			if len(args) == 2 {
+				substr, ok := args[1].(*object.String)
+				if !ok {
+					return object.Errorf("substring of error must be string, got %s", args[1].Type())
+				}
+				if !strings.Contains(errObj.Message, substr.Value) {
+					return assertFailure(pos, nil, "error %q does not contain %q", errObj.Message, substr.Value)
+				}
+			}
+			return nil
+		}
+	default:
+		return nil
+	}
+}
+
+func (ap *assertPkg) Members() []object.MemberDoc {
+	return []object.MemberDoc{
+		{Name: "equal", Signature: "equal(got, want, msg?)", Doc: "fails unless got == want, the arrays and the maps are equal only if they are the same"},
+		{Name: "deep_equal", Signature: "deep_equal(got, want, msg?)", Doc: "fails unless got equals want, comparing the arrays and the maps element by element"},
+		{Name: "is_true", Signature: "is_true(value, msg?)", Doc: "fails unless value is true"},
+		{Name: "error", Signature: "error(fn, substr?)", Doc: "calls fn and fails unless it returns an error containing substr"},
+	}
+}
+
+// assertFailure returns the error of a failed assertion, msg is the optional message given by the script.
+func assertFailure(pos token.Position, msg []object.Object, format string, args ...any) *object.Error {
+	var out strings.Builder
+	if pos.Line > 0 {
+		fmt.Fprintf(&out, "[%s] ", pos)
+	}
+	if len(msg) > 0 {
+		out.WriteString(msg[0].Inspect())
+		out.WriteString(": ")
+	}
+	fmt.Fprintf(&out, format, args...)
+	return &object.Error{Message: out.String()}
+}
+
+func isNull(obj object.Object) bool {
+	return obj == nil || obj.Type() == object.NULL_OBJ
+}
+
+// inspectValue returns the value in a line, the strings are quoted.
+func inspectValue(obj object.Object) string {
+	switch v := obj.(type) {
+	case nil:
+		return "null"
+	case *object.String:
+		return fmt.Sprintf("%q", v.Value)
+	default:
+		return obj.Inspect()
+	}
+}
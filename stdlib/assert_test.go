@@ -0,0 +1,44 @@
+package stdlib
+
+import (
+	"testing"
+
+	"github.com/thingsme/thingscript/object"
+)
+
+func TestAssert(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`assert := import("assert"); assert.equal(1 + 1, 2); 1`, "1"},
+		{`assert := import("assert"); assert.equal(2, 2.0); 1`, "1"},
+		{`assert := import("assert")
+assert.equal("a", "b")`, "ERROR: " + `[Ln 2, Col 8] not equal: got "a", want "b"`},
+		{`assert := import("assert"); assert.equal(1, "1", "count")`, "ERROR: " + `[Ln 1, Col 36] count: not equal: got 1, want "1"`},
+		{`assert := import("assert"); assert.equal([1], [1])`, "ERROR: " + `[Ln 1, Col 36] not equal: got [1], want [1]`},
+		{`assert := import("assert"); assert.equal([1].foreach(func(i, e) {}), nil); 1`, "1"},
+		{`assert := import("assert"); assert.equal([1].foreach(func(i, e) {}), 1)`, "ERROR: " + `[Ln 1, Col 36] not equal: got null, want 1`},
+		{`assert := import("assert"); assert.equal(1, [1].foreach(func(i, e) {}))`, "ERROR: " + `[Ln 1, Col 36] not equal: got 1, want null`},
+		{`assert := import("assert"); assert.deep_equal(nil, [1].foreach(func(i, e) {})); 1`, "1"},
+		{`assert := import("assert"); assert.deep_equal([1, {"a": [2]}], [1, {"a": [2]}]); 1`, "1"},
+		{`assert := import("assert"); assert.deep_equal({"a": 1}, {"a": 2})`, "ERROR: " + `[Ln 1, Col 36] not equal: got {a: 1}, want {a: 2}`},
+		{`assert := import("assert"); assert.is_true(1 < 2); 1`, "1"},
+		{`assert := import("assert"); assert.is_true(1, "positive")`, "ERROR: " + `[Ln 1, Col 36] positive: not true: got 1`},
+		{`assert := import("assert"); assert.error(func() { 1 + "a" }, "type mismatch"); 1`, "1"},
+		{`assert := import("assert"); assert.error(func() { 1 })`, "ERROR: " + `[Ln 1, Col 36] no error: got 1`},
+		{`assert := import("assert"); assert.error(func() { 1 + "a" }, "not found")`,
+			"ERROR: " + `[Ln 1, Col 36] error "type mismatch: INTEGER + STRING" does not contain "not found"`},
+		{`import("assert").error(1)`, "ERROR: " + "argument to error must be function, got INTEGER"},
+	}
+	for _, tt := range tests {
+		ret := evalWith(tt.input, func(env *object.Environment) { env.RegisterPackages(AssertPackage()) })
+		if ret == nil || ret.Inspect() != tt.expected {
+			t.Errorf("expected %s, got=%v <= %s", tt.expected, ret, tt.input)
+		}
+	}
+
+	if ret := testEval(`import("assert")`); ret.Inspect() != `ERROR: package "assert" not found` {
+		t.Errorf("assert should be registered by the test runner only, got=%s", ret.Inspect())
+	}
+}
@@ -0,0 +1,164 @@
+package stdlib
+
+import (
+	"bytes"
+
+	"github.com/thingsme/thingscript/eval"
+	"github.com/thingsme/thingscript/object"
+)
+
+// newBytes is the constructor of bytes,
+// it converts a string in UTF-8 and an array of integers in 0..255.
+func newBytes(args ...object.Object) object.Object {
+	if len(args) == 0 {
+		return &object.Bytes{Value: []byte{}}
+	}
+	if len(args) != 1 {
+		return object.Errorf("wrong number of arguments. want=0 or 1 got=%d", len(args))
+	}
+	switch v := args[0].(type) {
+	case *object.Bytes:
+		return &object.Bytes{Value: bytes.Clone(v.Value)}
+	case *object.String:
+		return &object.Bytes{Value: []byte(v.Value)}
+	case *object.Array:
+		b := make([]byte, len(v.Elements))
+		for i, e := range v.Elements {
+			n, ok := e.(*object.Integer)
+			if !ok || n.Value < 0 || n.Value > 255 {
+				return object.Errorf("bytes element must be int in 0..255, got %s", e.Inspect())
+			}
+			b[i] = byte(n.Value)
+		}
+		return &object.Bytes{Value: b}
+	default:
+		return object.Errorf("argument to bytes must be string, bytes or array, got %s", args[0].Type())
+	}
+}
+
+// bytesArg returns the content of a string or bytes.
+func bytesArg(obj object.Object) ([]byte, bool) {
+	switch v := obj.(type) {
+	case *object.Bytes:
+		return v.Value, true
+	case *object.String:
+		return []byte(v.Value), true
+	default:
+		return nil, false
+	}
+}
+
+func Bytes(member string) object.MemberFunc {
+	switch member {
+	case "type":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 0 {
+				return errWrongNumberOfArguments(0, len(args))
+			}
+			return &object.String{Value: "bytes"}
+		}
+	case "=", "+", "==", "!=":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return errWrongNumberOfArguments(1, len(args))
+			}
+			left, ok := receiver.(*object.Bytes)
+			if !ok {
+				return nil
+			}
+			right, ok := args[0].(*object.Bytes)
+			if !ok {
+				return errTypeMismatched(receiver, member, args[0])
+			}
+			switch member {
+			case "=":
+				left.Value = right.Value
+				return left
+			case "+":
+				b := make([]byte, 0, len(left.Value)+len(right.Value))
+				b = append(b, left.Value...)
+				return &object.Bytes{Value: append(b, right.Value...)}
+			case "==":
+				return &object.Boolean{Value: bytes.Equal(left.Value, right.Value)}
+			case "!=":
+				return &object.Boolean{Value: !bytes.Equal(left.Value, right.Value)}
+			}
+			return errUnknownOperator(receiver, member)
+		}
+	case "[": // index oper
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return errWrongNumberOfArguments(1, len(args))
+			}
+			b := receiver.(*object.Bytes)
+			if rv, ok := args[0].(*object.Integer); ok {
+				idx := rv.Value
+				if idx < 0 || idx >= int64(len(b.Value)) {
+					return eval.NULL
+				}
+				return &object.Integer{Value: int64(b.Value[idx])}
+			}
+			return nil
+		}
+	case "length":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 0 {
+				return errWrongNumberOfArguments(0, len(args))
+			}
+			b := receiver.(*object.Bytes)
+			return &object.Integer{Value: int64(len(b.Value))}
+		}
+	case "slice":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 1 && len(args) != 2 {
+				return object.Errorf("wrong number of arguments. want=1 or 2 got=%d", len(args))
+			}
+			b := receiver.(*object.Bytes)
+			length := int64(len(b.Value))
+			bounds := []int64{0, length}
+			for i, a := range args {
+				v, ok := a.(*object.Integer)
+				if !ok {
+					return object.Errorf("argument to slice must be int, got %s", a.Type())
+				}
+				bounds[i] = clampIndex(v.Value, length)
+			}
+			if bounds[0] > bounds[1] {
+				bounds[0] = bounds[1]
+			}
+			return &object.Bytes{Value: bytes.Clone(b.Value[bounds[0]:bounds[1]])}
+		}
+	case "string":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 0 {
+				return errWrongNumberOfArguments(0, len(args))
+			}
+			b := receiver.(*object.Bytes)
+			return &object.String{Value: string(b.Value)}
+		}
+	case "array":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 0 {
+				return errWrongNumberOfArguments(0, len(args))
+			}
+			b := receiver.(*object.Bytes)
+			elements := make([]object.Object, len(b.Value))
+			for i, c := range b.Value {
+				elements[i] = &object.Integer{Value: int64(c)}
+			}
+			return &object.Array{Elements: elements}
+		}
+	default:
+		return nil
+	}
+}
+
+func BytesMembers() []object.MemberDoc {
+	return []object.MemberDoc{
+		{Name: "type", Signature: "type()", Doc: "returns \"bytes\""},
+		{Name: "length", Signature: "length()", Doc: "returns the number of the bytes"},
+		{Name: "slice", Signature: "slice(start, end?)", Doc: "returns the bytes from start to end, the negative indexes count from the end"},
+		{Name: "string", Signature: "string()", Doc: "returns the bytes as a string"},
+		{Name: "array", Signature: "array()", Doc: "returns the array of the ints of the bytes"},
+	}
+}
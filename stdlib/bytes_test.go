@@ -0,0 +1,40 @@
+package stdlib
+
+import (
+	"testing"
+
+	"github.com/thingsme/thingscript/object"
+)
+
+func TestBytes(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected any
+	}{
+		{`bytes("abc").length`, 3},
+		{`bytes("héllo").length`, 6},
+		{`bytes([1, 2, 255]).array()`, []int64{1, 2, 255}},
+		{`bytes().length`, 0},
+		{`var b bytes; b.length`, 0},
+		{`var b bytes = "ab"; b.array()`, []int64{97, 98}},
+		{`bytes(bytes("ab")).array()`, []int64{97, 98}},
+		{`bytes("abc")[1]`, 98},
+		{`bytes("abc")[3] ?? -1`, -1},
+		{`bytes("abcde").slice(1, 3).array()`, []int64{98, 99}},
+		{`bytes("abcde").slice(-2).array()`, []int64{100, 101}},
+		{`(bytes("ab") + bytes([0])).array()`, []int64{97, 98, 0}},
+		{`bytes("ab") == bytes([97, 98])`, true},
+		{`bytes("ab") != bytes("ab")`, false},
+		{`string(bytes("héllo"))`, "héllo"},
+		{`bytes("héllo").string()`, "héllo"},
+		{`"ab".bytes().array()`, []int64{97, 98}},
+		{`bytes("ab").type()`, "bytes"},
+		{`b := bytes("ab"); b = bytes("xyz"); b.length`, 3},
+		{`bytes([256])`, &object.Error{Message: "bytes element must be int in 0..255, got 256"}},
+		{`bytes(1)`, &object.Error{Message: "argument to bytes must be string, bytes or array, got INTEGER"}},
+		{`bytes("ab") + "c"`, &object.Error{Message: "type mismatch: BYTES + STRING"}},
+	}
+	for _, tt := range tests {
+		runTest(t, tt.input, tt.expected)
+	}
+}
@@ -0,0 +1,459 @@
+package stdlib
+
+import (
+	"math"
+	"sort"
+
+	"github.com/thingsme/thingscript/eval"
+	"github.com/thingsme/thingscript/object"
+)
+
+// arrayCollections provides the higher-order members of array,
+// callbacks are invoked via callCallback so that both of user functions and builtins are accepted.
+func arrayCollections(member string) object.MemberFunc {
+	switch member {
+	case "map":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return errWrongNumberOfArguments(1, len(args))
+			}
+			arr := receiver.(*object.Array)
+			newElements := make([]object.Object, len(arr.Elements))
+			for i, elm := range arr.Elements {
+				ret := callCallback(member, args[0], elm, &object.Integer{Value: int64(i)})
+				if isError(ret) {
+					return ret
+				}
+				newElements[i] = ret
+			}
+			return &object.Array{Elements: newElements}
+		}
+	case "filter":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return errWrongNumberOfArguments(1, len(args))
+			}
+			arr := receiver.(*object.Array)
+			newElements := []object.Object{}
+			for i, elm := range arr.Elements {
+				ret := callCallback(member, args[0], elm, &object.Integer{Value: int64(i)})
+				if isError(ret) {
+					return ret
+				}
+				if isTruthy(ret) {
+					newElements = append(newElements, elm)
+				}
+			}
+			return &object.Array{Elements: newElements}
+		}
+	case "reduce":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 1 && len(args) != 2 {
+				return object.Errorf("wrong number of arguments. want=1 or 2 got=%d", len(args))
+			}
+			arr := receiver.(*object.Array)
+			elements := arr.Elements
+			var acc object.Object
+			if len(args) == 2 {
+				acc = args[1]
+			} else {
+				if len(elements) == 0 {
+					return object.Errorf("reduce of empty array with no initial value")
+				}
+				acc = elements[0]
+				elements = elements[1:]
+			}
+			for _, elm := range elements {
+				acc = callCallback(member, args[0], acc, elm)
+				if isError(acc) {
+					return acc
+				}
+			}
+			return acc
+		}
+	case "find", "find_index":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return errWrongNumberOfArguments(1, len(args))
+			}
+			arr := receiver.(*object.Array)
+			for i, elm := range arr.Elements {
+				ret := callCallback(member, args[0], elm, &object.Integer{Value: int64(i)})
+				if isError(ret) {
+					return ret
+				}
+				if isTruthy(ret) {
+					if member == "find" {
+						return elm
+					}
+					return &object.Integer{Value: int64(i)}
+				}
+			}
+			if member == "find" {
+				return eval.NULL
+			}
+			return &object.Integer{Value: -1}
+		}
+	case "any", "all":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) > 1 {
+				return errWrongNumberOfArguments(1, len(args))
+			}
+			arr := receiver.(*object.Array)
+			for i, elm := range arr.Elements {
+				ret := elm
+				if len(args) == 1 {
+					ret = callCallback(member, args[0], elm, &object.Integer{Value: int64(i)})
+					if isError(ret) {
+						return ret
+					}
+				}
+				if member == "any" && isTruthy(ret) {
+					return &object.Boolean{Value: true}
+				} else if member == "all" && !isTruthy(ret) {
+					return &object.Boolean{Value: false}
+				}
+			}
+			return &object.Boolean{Value: member == "all"}
+		}
+	case "sort":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) > 1 {
+				return errWrongNumberOfArguments(1, len(args))
+			}
+			arr := receiver.(*object.Array)
+			newElements := make([]object.Object, len(arr.Elements))
+			copy(newElements, arr.Elements)
+			var failure object.Object
+			sort.SliceStable(newElements, func(i, j int) bool {
+				if failure != nil {
+					return false
+				}
+				var ret object.Object
+				if len(args) == 1 {
+					ret = callCallback(member, args[0], newElements[i], newElements[j])
+				} else {
+					ret = compareLess(newElements[i], newElements[j])
+				}
+				if isError(ret) {
+					failure = ret
+					return false
+				}
+				return isTruthy(ret)
+			})
+			if failure != nil {
+				return failure
+			}
+			return &object.Array{Elements: newElements}
+		}
+	case "reverse":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 0 {
+				return errWrongNumberOfArguments(0, len(args))
+			}
+			arr := receiver.(*object.Array)
+			length := len(arr.Elements)
+			newElements := make([]object.Object, length)
+			for i, elm := range arr.Elements {
+				newElements[length-1-i] = elm
+			}
+			return &object.Array{Elements: newElements}
+		}
+	case "slice":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 1 && len(args) != 2 {
+				return object.Errorf("wrong number of arguments. want=1 or 2 got=%d", len(args))
+			}
+			arr := receiver.(*object.Array)
+			length := int64(len(arr.Elements))
+			bounds := []int64{0, length}
+			for i, a := range args {
+				v, ok := a.(*object.Integer)
+				if !ok {
+					return object.Errorf("argument to slice must be int, got %s", a.Type())
+				}
+				bounds[i] = clampIndex(v.Value, length)
+			}
+			if bounds[0] > bounds[1] {
+				bounds[0] = bounds[1]
+			}
+			newElements := make([]object.Object, bounds[1]-bounds[0])
+			copy(newElements, arr.Elements[bounds[0]:bounds[1]])
+			return &object.Array{Elements: newElements}
+		}
+	case "concat":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			arr := receiver.(*object.Array)
+			newElements := make([]object.Object, len(arr.Elements))
+			copy(newElements, arr.Elements)
+			for _, a := range args {
+				other, ok := a.(*object.Array)
+				if !ok {
+					return object.Errorf("argument to concat must be array, got %s", a.Type())
+				}
+				newElements = append(newElements, other.Elements...)
+			}
+			return &object.Array{Elements: newElements}
+		}
+	case "contains", "index_of":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return errWrongNumberOfArguments(1, len(args))
+			}
+			arr := receiver.(*object.Array)
+			idx := -1
+			for i, elm := range arr.Elements {
+				if equals(elm, args[0]) {
+					idx = i
+					break
+				}
+			}
+			if member == "contains" {
+				return &object.Boolean{Value: idx >= 0}
+			}
+			return &object.Integer{Value: int64(idx)}
+		}
+	case "unique":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 0 {
+				return errWrongNumberOfArguments(0, len(args))
+			}
+			arr := receiver.(*object.Array)
+			seen := map[object.HashKey]bool{}
+			newElements := []object.Object{}
+		next:
+			for _, elm := range arr.Elements {
+				if h, ok := elm.(object.Hashable); ok {
+					key := uniqueKey(h)
+					if seen[key] {
+						continue
+					}
+					seen[key] = true
+				} else {
+					for _, e := range newElements {
+						if equals(e, elm) {
+							continue next
+						}
+					}
+				}
+				newElements = append(newElements, elm)
+			}
+			return &object.Array{Elements: newElements}
+		}
+	case "flatten":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) > 1 {
+				return errWrongNumberOfArguments(1, len(args))
+			}
+			depth := int64(1)
+			if len(args) == 1 {
+				v, ok := args[0].(*object.Integer)
+				if !ok {
+					return object.Errorf("argument to flatten must be int, got %s", args[0].Type())
+				}
+				depth = v.Value
+			}
+			arr := receiver.(*object.Array)
+			return &object.Array{Elements: flatten(arr.Elements, depth)}
+		}
+	case "zip":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			arr := receiver.(*object.Array)
+			arrays := []*object.Array{arr}
+			length := len(arr.Elements)
+			for _, a := range args {
+				other, ok := a.(*object.Array)
+				if !ok {
+					return object.Errorf("argument to zip must be array, got %s", a.Type())
+				}
+				if len(other.Elements) < length {
+					length = len(other.Elements)
+				}
+				arrays = append(arrays, other)
+			}
+			newElements := make([]object.Object, length)
+			for i := 0; i < length; i++ {
+				tuple := make([]object.Object, len(arrays))
+				for n, a := range arrays {
+					tuple[n] = a.Elements[i]
+				}
+				newElements[i] = &object.Array{Elements: tuple}
+			}
+			return &object.Array{Elements: newElements}
+		}
+	case "group_by":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return errWrongNumberOfArguments(1, len(args))
+			}
+			arr := receiver.(*object.Array)
+			pairs := make(map[object.HashKey]object.HashPair)
+			for i, elm := range arr.Elements {
+				key := callCallback(member, args[0], elm, &object.Integer{Value: int64(i)})
+				if isError(key) {
+					return key
+				}
+				hashKey, ok := key.(object.Hashable)
+				if !ok {
+					return object.Errorf("unusable as hash key: %s", key.Type())
+				}
+				hashed := hashKey.HashKey()
+				pair, ok := pairs[hashed]
+				if !ok {
+					pair = object.HashPair{Key: key, Value: &object.Array{Elements: []object.Object{}}}
+				}
+				group := pair.Value.(*object.Array)
+				group.Elements = append(group.Elements, elm)
+				pairs[hashed] = pair
+			}
+			return &object.HashMap{Pairs: pairs}
+		}
+	case "chunk":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return errWrongNumberOfArguments(1, len(args))
+			}
+			size, ok := args[0].(*object.Integer)
+			if !ok {
+				return object.Errorf("argument to chunk must be int, got %s", args[0].Type())
+			}
+			if size.Value <= 0 {
+				return object.Errorf("chunk size must be positive, got %d", size.Value)
+			}
+			arr := receiver.(*object.Array)
+			newElements := []object.Object{}
+			for i := 0; i < len(arr.Elements); i += int(size.Value) {
+				end := i + int(size.Value)
+				if end > len(arr.Elements) {
+					end = len(arr.Elements)
+				}
+				chunk := make([]object.Object, end-i)
+				copy(chunk, arr.Elements[i:end])
+				newElements = append(newElements, &object.Array{Elements: chunk})
+			}
+			return &object.Array{Elements: newElements}
+		}
+	default:
+		return nil
+	}
+}
+
+// callFunction invokes a user function, a builtin or a bound method with the given arguments.
+// A user function may declare fewer parameters than the arguments supplied,
+// the trailing arguments are dropped in that case.
+func callFunction(fn object.Object, args ...object.Object) object.Object {
+	if f, ok := fn.(*object.Function); ok && len(f.Parameters) < len(args) {
+		args = args[:len(f.Parameters)]
+	}
+	return eval.Apply(fn, args...)
+}
+
+// callCallback is callFunction for the callbacks of member,
+// which have no loop to stop so that break is reported as an error.
+func callCallback(member string, fn object.Object, args ...object.Object) object.Object {
+	ret := callFunction(fn, args...)
+	if ret.Type() == object.BREAK_OBJ {
+		return object.Errorf("break is not allowed in the function of %s", member)
+	}
+	return ret
+}
+
+func isError(obj object.Object) bool {
+	return obj != nil && obj.Type() == object.ERROR_OBJ
+}
+
+func isTruthy(obj object.Object) bool {
+	if obj == nil || obj.Type() == object.NULL_OBJ {
+		return false
+	}
+	if b, ok := obj.(*object.Boolean); ok {
+		return b.Value
+	}
+	return true
+}
+
+// uniqueKey returns the hash key of h, integral floats have the key of the int
+// so that unique agrees with equals on 1 == 1.0.
+func uniqueKey(h object.Hashable) object.HashKey {
+	if f, ok := h.(*object.Float); ok && f.Value == math.Trunc(f.Value) &&
+		f.Value >= math.MinInt64 && f.Value < math.MaxInt64 {
+		return (&object.Integer{Value: int64(f.Value)}).HashKey()
+	}
+	return h.HashKey()
+}
+
+// equals compares two objects with their "==" operator,
+// arrays and maps are compared element by element.
+func equals(a, b object.Object) bool {
+	if a == b {
+		return true
+	}
+	if a == nil || b == nil {
+		return false
+	}
+	if eq := a.Member("=="); eq != nil {
+		ret, ok := eq(a, b).(*object.Boolean)
+		return ok && ret.Value
+	}
+	switch av := a.(type) {
+	case *object.Null:
+		return b.Type() == object.NULL_OBJ
+	case *object.Array:
+		bv, ok := b.(*object.Array)
+		if !ok || len(av.Elements) != len(bv.Elements) {
+			return false
+		}
+		for i := range av.Elements {
+			if !equals(av.Elements[i], bv.Elements[i]) {
+				return false
+			}
+		}
+		return true
+	case *object.HashMap:
+		bv, ok := b.(*object.HashMap)
+		if !ok || len(av.Pairs) != len(bv.Pairs) {
+			return false
+		}
+		for key, pair := range av.Pairs {
+			other, ok := bv.Pairs[key]
+			if !ok || !equals(pair.Value, other.Value) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+func compareLess(a, b object.Object) object.Object {
+	lt := a.Member("<")
+	if lt == nil {
+		return errUnknownOperator(a, "<")
+	}
+	return lt(a, b)
+}
+
+func clampIndex(idx int64, length int64) int64 {
+	if idx < 0 {
+		idx += length
+	}
+	if idx < 0 {
+		return 0
+	}
+	if idx > length {
+		return length
+	}
+	return idx
+}
+
+func flatten(elements []object.Object, depth int64) []object.Object {
+	ret := []object.Object{}
+	for _, elm := range elements {
+		if arr, ok := elm.(*object.Array); ok && depth > 0 {
+			ret = append(ret, flatten(arr.Elements, depth-1)...)
+		} else {
+			ret = append(ret, elm)
+		}
+	}
+	return ret
+}
@@ -0,0 +1,76 @@
+package stdlib
+
+import (
+	"testing"
+
+	"github.com/thingsme/thingscript/object"
+)
+
+func TestCollections(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected any
+	}{
+		{`[1,2,3].map(func(x){ x * 2 })`, []int64{2, 4, 6}},
+		{`[1,2,3].map(func(x, idx){ idx })`, []int64{0, 1, 2}},
+		{`[5,6].map(array)[1]`, []int64{6, 1}},
+		{`[1,2,3,4].filter(func(x){ x % 2 == 0 })`, []int64{2, 4}},
+		{`[1,2,3,4].reduce(func(acc, x){ acc + x })`, 10},
+		{`[1,2,3,4].reduce(func(acc, x){ acc + x }, 10)`, 20},
+		{`[].reduce(func(acc, x){ acc + x })`, &object.Error{Message: "reduce of empty array with no initial value"}},
+		{`[1,2,3,4].find(func(x){ x > 2 })`, 3},
+		{`[1,2,3,4].find(func(x){ x > 10 }) ?? 0`, 0},
+		{`[1,2,3,4].find_index(func(x){ x > 2 })`, 2},
+		{`[1,2,3,4].find_index(func(x){ x > 10 })`, -1},
+		{`[1,2,3].any(func(x){ x > 2 })`, true},
+		{`[1,2,3].all(func(x){ x > 2 })`, false},
+		{`[true, true].all()`, true},
+		{`[3,1,2].sort()`, []int64{1, 2, 3}},
+		{`[3,1,2].sort(func(a, b){ a > b })`, []int64{3, 2, 1}},
+		{`[3,"a"].sort()`, &object.Error{Message: "type mismatch: STRING < INTEGER"}},
+		{`[1,2,3].reverse()`, []int64{3, 2, 1}},
+		{`[1,2,3,4].slice(1)`, []int64{2, 3, 4}},
+		{`[1,2,3,4].slice(1, 3)`, []int64{2, 3}},
+		{`[1,2,3,4].slice(-2)`, []int64{3, 4}},
+		{`[1,2,3,4].slice(3, 1)`, []int64{}},
+		{`[1,2].concat([3], [4,5])`, []int64{1, 2, 3, 4, 5}},
+		{`[1,2,3].contains(2)`, true},
+		{`[1,2,3].contains("2")`, false},
+		{`[1,2,3].index_of(3)`, 2},
+		{`[1,2,3].index_of(4)`, -1},
+		{`[1,2,1,3,2].unique()`, []int64{1, 2, 3}},
+		{`[[1,2],[3,[4]]].flatten().length`, 4},
+		{`[[1,2],[3,[4]]].flatten(2)`, []int64{1, 2, 3, 4}},
+		{`[1,2,3].zip(["a","b"]).length`, 2},
+		{`[1,2,3].zip(["a","b"])[1][1]`, "b"},
+		{`g := [1,2,3,4,5].group_by(func(x){ x % 2 == 0 }); g[true].length`, 2},
+		{`g := [1,2,3,4,5].group_by(func(x){ x % 2 == 0 }); g[false]`, []int64{1, 3, 5}},
+		{`[1,2,3,4,5].chunk(2).length`, 3},
+		{`[1,2,3,4,5].chunk(2)[2]`, []int64{5}},
+		{`[1,2,3].chunk(0)`, &object.Error{Message: "chunk size must be positive, got 0"}},
+		{`[1,2,3].map(func(a, b, c){ a })`, &object.Error{Message: "wrong number of arguments. want=3 got=2"}},
+		{`[1,2,3].map(1)`, &object.Error{Message: "not a function: INTEGER"}},
+		{`[1,2,3].map(func(x){ break })`, &object.Error{Message: "break is not allowed in the function of map"}},
+		{`[1,2,3].filter(func(x){ if x > 1 { break }; true })`, &object.Error{Message: "break is not allowed in the function of filter"}},
+	}
+	for _, tt := range tests {
+		runTest(t, tt.input, tt.expected)
+	}
+}
+
+func TestEquals(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{`[[1,2],[3]].contains([3])`, true},
+		{`[{"a":1}].contains({"a":1})`, true},
+		{`[{"a":1}].contains({"a":2})`, false},
+		{`[1.0, 2.0].contains(2)`, true},
+		{`[1, 1.0, 2.5, 2.5].unique().length == 2`, true},
+		{`[1.0, 1].unique()[0] == 1`, true},
+	}
+	for _, tt := range tests {
+		runTest(t, tt.input, tt.expected)
+	}
+}
@@ -0,0 +1,140 @@
+package stdlib
+
+import (
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/hex"
+	"hash"
+	"hash/crc32"
+
+	"github.com/thingsme/thingscript/object"
+)
+
+type cryptoPkg struct{}
+
+var _ object.Package = &cryptoPkg{}
+var _ object.Describer = &cryptoPkg{}
+
+var cryptoHashes = map[string]func() hash.Hash{
+	"md5":    md5.New,
+	"sha1":   sha1.New,
+	"sha256": sha256.New,
+	"sha512": sha512.New,
+}
+
+func (cp *cryptoPkg) Type() object.ObjectType { return object.PACKAGE_OBJ }
+
+func (cp *cryptoPkg) Inspect() string { return "package crypto" }
+
+func (cp *cryptoPkg) Name() string { return "crypto" }
+
+func (cp *cryptoPkg) OnLoad(env *object.Environment) {}
+
+func (cp *cryptoPkg) Member(name string) object.MemberFunc {
+	switch name {
+	case "md5", "sha1", "sha256", "sha512":
+		newHash := cryptoHashes[name]
+		// md5(data, format)
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 1 && len(args) != 2 {
+				return object.Errorf("wrong number of arguments. want=1 or 2 got=%d", len(args))
+			}
+			data, ok := bytesArg(args[0])
+			if !ok {
+				return object.Errorf("argument to %s must be string or bytes, got %s", name, args[0].Type())
+			}
+			h := newHash()
+			h.Write(data)
+			return digest(h.Sum(nil), args[1:])
+		}
+	case "hmac":
+		// hmac(hash, key, data, format)
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 3 && len(args) != 4 {
+				return object.Errorf("wrong number of arguments. want=3 or 4 got=%d", len(args))
+			}
+			hashName, ok := args[0].(*object.String)
+			if !ok {
+				return object.Errorf("hash of hmac must be string, got %s", args[0].Type())
+			}
+			newHash, ok := cryptoHashes[hashName.Value]
+			if !ok {
+				return object.Errorf("unknown hash %q, want md5, sha1, sha256 or sha512", hashName.Value)
+			}
+			key, ok := bytesArg(args[1])
+			if !ok {
+				return object.Errorf("key of hmac must be string or bytes, got %s", args[1].Type())
+			}
+			data, ok := bytesArg(args[2])
+			if !ok {
+				return object.Errorf("data of hmac must be string or bytes, got %s", args[2].Type())
+			}
+			mac := hmac.New(newHash, key)
+			mac.Write(data)
+			return digest(mac.Sum(nil), args[3:])
+		}
+	case "crc32":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return errWrongNumberOfArguments(1, len(args))
+			}
+			data, ok := bytesArg(args[0])
+			if !ok {
+				return object.Errorf("argument to crc32 must be string or bytes, got %s", args[0].Type())
+			}
+			return &object.Integer{Value: int64(crc32.ChecksumIEEE(data))}
+		}
+	case "compare":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return errWrongNumberOfArguments(2, len(args))
+			}
+			a, ok := bytesArg(args[0])
+			if !ok {
+				return object.Errorf("argument to compare must be string or bytes, got %s", args[0].Type())
+			}
+			b, ok := bytesArg(args[1])
+			if !ok {
+				return object.Errorf("argument to compare must be string or bytes, got %s", args[1].Type())
+			}
+			return &object.Boolean{Value: subtle.ConstantTimeCompare(a, b) == 1}
+		}
+	default:
+		return nil
+	}
+}
+
+func (cp *cryptoPkg) Members() []object.MemberDoc {
+	return []object.MemberDoc{
+		{Name: "md5", Signature: "md5(data, format?)", Doc: "returns the MD5 digest in \"hex\" or \"bytes\""},
+		{Name: "sha1", Signature: "sha1(data, format?)", Doc: "returns the SHA-1 digest in \"hex\" or \"bytes\""},
+		{Name: "sha256", Signature: "sha256(data, format?)", Doc: "returns the SHA-256 digest in \"hex\" or \"bytes\""},
+		{Name: "sha512", Signature: "sha512(data, format?)", Doc: "returns the SHA-512 digest in \"hex\" or \"bytes\""},
+		{Name: "hmac", Signature: "hmac(hash, key, data, format?)", Doc: "returns the HMAC of data by hash, which is md5, sha1, sha256 or sha512"},
+		{Name: "crc32", Signature: "crc32(data)", Doc: "returns the IEEE CRC-32 checksum"},
+		{Name: "compare", Signature: "compare(a, b)", Doc: "returns true if a equals b in constant time"},
+	}
+}
+
+// digest returns sum in the optional format, "hex" (default) or "bytes".
+func digest(sum []byte, format []object.Object) object.Object {
+	if len(format) == 0 {
+		return &object.String{Value: hex.EncodeToString(sum)}
+	}
+	f, ok := format[0].(*object.String)
+	if !ok {
+		return object.Errorf("format must be string, got %s", format[0].Type())
+	}
+	switch f.Value {
+	case "hex":
+		return &object.String{Value: hex.EncodeToString(sum)}
+	case "bytes":
+		return &object.Bytes{Value: sum}
+	default:
+		return object.Errorf("format must be \"hex\" or \"bytes\", got %q", f.Value)
+	}
+}
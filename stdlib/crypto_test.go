@@ -0,0 +1,39 @@
+package stdlib
+
+import (
+	"testing"
+
+	"github.com/thingsme/thingscript/object"
+)
+
+func TestCrypto(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected any
+	}{
+		{`import("crypto").md5("hello")`, "5d41402abc4b2a76b9719d911017c592"},
+		{`import("crypto").sha1("hello")`, "aaf4c61ddcc5e8a2dabede0f3b482cd9aea9434d"},
+		{`import("crypto").sha256("hello")`, "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"},
+		{`import("crypto").sha512("")`, "cf83e1357eefb8bdf1542850d66d8007d620e4050b5715dc83f4a921d36ce9ce47d0d13c5d85f2b0ff8318d2877eec2f63b931bd47417a81a538327af927da3e"},
+		{`import("crypto").sha256(bytes("hello"), "hex")`, "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"},
+		{`import("crypto").sha256("hello", "bytes").length`, 32},
+		{`import("crypto").md5("hello", "bytes")[0]`, 0x5d},
+		{`import("crypto").hmac("sha256", "key", "The quick brown fox jumps over the lazy dog")`, "f7bc83f430538424b13298e6aa6fb143ef4d59a14946175997479dbc2d1a3cd8"},
+		{`import("crypto").hmac("md5", bytes("key"), "The quick brown fox jumps over the lazy dog")`, "80070713463e7749b90c2dc24911e275"},
+		{`import("crypto").hmac("sha1", "key", "data", "bytes").length`, 20},
+		{`import("crypto").crc32("hello")`, 907060870},
+		{`import("crypto").compare("abc", "abc")`, true},
+		{`import("crypto").compare("abc", bytes("abd"))`, false},
+		{`import("crypto").compare("abc", "ab")`, false},
+		{`c := import("crypto")
+			c.compare(c.hmac("sha256", "secret", "body"), c.hmac("sha256", "secret", bytes("body")))`, true},
+		{`import("crypto").md5(1)`, &object.Error{Message: "argument to md5 must be string or bytes, got INTEGER"}},
+		{`import("crypto").md5("a", "base32")`, &object.Error{Message: `format must be "hex" or "bytes", got "base32"`}},
+		{`import("crypto").hmac("sha3", "k", "d")`, &object.Error{Message: `unknown hash "sha3", want md5, sha1, sha256 or sha512`}},
+		{`import("crypto").hmac("sha1", "k")`, &object.Error{Message: "wrong number of arguments. want=3 or 4 got=2"}},
+		{`import("crypto").compare("a", 1)`, &object.Error{Message: "argument to compare must be string or bytes, got INTEGER"}},
+	}
+	for _, tt := range tests {
+		runTest(t, tt.input, tt.expected)
+	}
+}
@@ -0,0 +1,485 @@
+package stdlib
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"io/fs"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/thingsme/thingscript/eval"
+	"github.com/thingsme/thingscript/object"
+)
+
+// csvPkg reads and writes CSV, the files are accessed through the fs package
+// so that the same restrictions apply.
+type csvPkg struct {
+	env *object.Environment
+}
+
+var _ object.Package = &csvPkg{}
+var _ object.Describer = &csvPkg{}
+
+func (cp *csvPkg) Type() object.ObjectType { return object.PACKAGE_OBJ }
+
+func (cp *csvPkg) Inspect() string { return "package csv" }
+
+func (cp *csvPkg) Name() string { return "csv" }
+
+func (cp *csvPkg) OnLoad(env *object.Environment) {
+	cp.env = env
+}
+
+func (cp *csvPkg) Member(name string) object.MemberFunc {
+	switch name {
+	case "parse":
+		// parse(text, options)
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 1 && len(args) != 2 {
+				return object.Errorf("wrong number of arguments. want=1 or 2 got=%d", len(args))
+			}
+			text, ok := args[0].(*object.String)
+			if !ok {
+				return object.Errorf("argument to parse must be string, got %s", args[0].Type())
+			}
+			opts, errObj := csvOptionsArg(args[1:])
+			if errObj != nil {
+				return errObj
+			}
+			return readAllCSV(strings.NewReader(text.Value), opts)
+		}
+	case "read_file":
+		// read_file(path, options)
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 1 && len(args) != 2 {
+				return object.Errorf("wrong number of arguments. want=1 or 2 got=%d", len(args))
+			}
+			f, errObj := cp.open(name, args[0])
+			if errObj != nil {
+				return errObj
+			}
+			defer f.Close()
+			opts, errObj := csvOptionsArg(args[1:])
+			if errObj != nil {
+				return errObj
+			}
+			return readAllCSV(f, opts)
+		}
+	case "open":
+		// open(path, options)
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 1 && len(args) != 2 {
+				return object.Errorf("wrong number of arguments. want=1 or 2 got=%d", len(args))
+			}
+			opts, errObj := csvOptionsArg(args[1:])
+			if errObj != nil {
+				return errObj
+			}
+			f, errObj := cp.open(name, args[0])
+			if errObj != nil {
+				return errObj
+			}
+			reader := &CSVReader{file: f, r: opts.reader(f), opts: opts}
+			if opts.header {
+				if errObj := reader.readHeader(); errObj != nil {
+					f.Close()
+					return errObj
+				}
+			}
+			return reader
+		}
+	case "format":
+		// format(rows, options)
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 1 && len(args) != 2 {
+				return object.Errorf("wrong number of arguments. want=1 or 2 got=%d", len(args))
+			}
+			opts, errObj := csvOptionsArg(args[1:])
+			if errObj != nil {
+				return errObj
+			}
+			b, errObj := formatCSV(args[0], opts)
+			if errObj != nil {
+				return errObj
+			}
+			return &object.String{Value: string(b)}
+		}
+	case "write_file":
+		// write_file(path, rows, options)
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 2 && len(args) != 3 {
+				return object.Errorf("wrong number of arguments. want=2 or 3 got=%d", len(args))
+			}
+			fp, p, errObj := fsPathArg(cp.env, name, args[0])
+			if errObj != nil {
+				return errObj
+			}
+			opts, errObj := csvOptionsArg(args[2:])
+			if errObj != nil {
+				return errObj
+			}
+			b, errObj := formatCSV(args[1], opts)
+			if errObj != nil {
+				return errObj
+			}
+			n, err := fp.write(p, b, false)
+			if err != nil {
+				return object.Errorf("%s", err)
+			}
+			return &object.Integer{Value: int64(n)}
+		}
+	default:
+		return nil
+	}
+}
+
+func (cp *csvPkg) Members() []object.MemberDoc {
+	return []object.MemberDoc{
+		{Name: "parse", Signature: "parse(text, options?)", Doc: "returns the rows of text, maps keyed by the header with the header option"},
+		{Name: "read_file", Signature: "read_file(path, options?)", Doc: "returns the rows of the file"},
+		{Name: "open", Signature: "open(path, options?)", Doc: "returns the csv.Reader streaming the rows of the file"},
+		{Name: "format", Signature: "format(rows, options?)", Doc: "returns the rows in CSV"},
+		{Name: "write_file", Signature: "write_file(path, rows, options?)", Doc: "writes the rows to the file in CSV"},
+	}
+}
+
+func (cp *csvPkg) open(name string, arg object.Object) (fs.File, *object.Error) {
+	fp, p, errObj := fsPathArg(cp.env, name, arg)
+	if errObj != nil {
+		return nil, errObj
+	}
+	f, err := fp.open(p)
+	if err != nil {
+		return nil, object.Errorf("%s", err)
+	}
+	return f, nil
+}
+
+// csvOptions are the options of the csv package,
+// they are the fields of csv.Reader and csv.Writer and "header" and "columns".
+type csvOptions struct {
+	comma            rune
+	comment          rune
+	lazyQuotes       bool
+	trimLeadingSpace bool
+	fieldsPerRecord  int
+	useCRLF          bool
+	header           bool
+	headerSet        bool
+	columns          []string
+}
+
+func csvOptionsArg(args []object.Object) (*csvOptions, *object.Error) {
+	opts := &csvOptions{comma: ','}
+	if len(args) == 0 {
+		return opts, nil
+	}
+	hm, ok := args[0].(*object.HashMap)
+	if !ok {
+		return nil, object.Errorf("options must be map, got %s", args[0].Type())
+	}
+	for _, pair := range hm.Pairs {
+		key := pair.Key.Inspect()
+		var err error
+		switch key {
+		case "delimiter":
+			opts.comma, err = csvRuneOption(key, pair.Value)
+		case "comment":
+			opts.comment, err = csvRuneOption(key, pair.Value)
+		case "lazy_quotes":
+			opts.lazyQuotes, err = csvBoolOption(key, pair.Value)
+		case "trim_leading_space":
+			opts.trimLeadingSpace, err = csvBoolOption(key, pair.Value)
+		case "use_crlf":
+			opts.useCRLF, err = csvBoolOption(key, pair.Value)
+		case "header":
+			opts.header, err = csvBoolOption(key, pair.Value)
+			opts.headerSet = true
+		case "fields_per_record":
+			n, ok := pair.Value.(*object.Integer)
+			if !ok {
+				err = fmt.Errorf("option %s must be int, got %s", key, pair.Value.Type())
+			} else {
+				opts.fieldsPerRecord = int(n.Value)
+			}
+		case "columns":
+			arr, ok := pair.Value.(*object.Array)
+			if !ok {
+				err = fmt.Errorf("option %s must be array, got %s", key, pair.Value.Type())
+				break
+			}
+			opts.columns = make([]string, len(arr.Elements))
+			for i, e := range arr.Elements {
+				opts.columns[i] = e.Inspect()
+			}
+		default:
+			err = fmt.Errorf("unknown option %q", key)
+		}
+		if err != nil {
+			return nil, object.Errorf("%s", err)
+		}
+	}
+	return opts, nil
+}
+
+func csvRuneOption(key string, value object.Object) (rune, error) {
+	s, ok := value.(*object.String)
+	if !ok || utf8.RuneCountInString(s.Value) != 1 {
+		return 0, fmt.Errorf("option %s must be a character, got %s", key, value.Inspect())
+	}
+	r, _ := utf8.DecodeRuneInString(s.Value)
+	return r, nil
+}
+
+func csvBoolOption(key string, value object.Object) (bool, error) {
+	b, ok := value.(*object.Boolean)
+	if !ok {
+		return false, fmt.Errorf("option %s must be bool, got %s", key, value.Type())
+	}
+	return b.Value, nil
+}
+
+func (opts *csvOptions) reader(r io.Reader) *csv.Reader {
+	cr := csv.NewReader(r)
+	cr.Comma = opts.comma
+	cr.Comment = opts.comment
+	cr.LazyQuotes = opts.lazyQuotes
+	cr.TrimLeadingSpace = opts.trimLeadingSpace
+	cr.FieldsPerRecord = opts.fieldsPerRecord
+	return cr
+}
+
+func readAllCSV(r io.Reader, opts *csvOptions) object.Object {
+	records, err := opts.reader(r).ReadAll()
+	if err != nil {
+		return object.Errorf("%s", err)
+	}
+	elements := []object.Object{}
+	var header []string
+	for _, record := range records {
+		if opts.header && header == nil {
+			header = record
+			continue
+		}
+		elements = append(elements, csvRecord(record, header))
+	}
+	return &object.Array{Elements: elements}
+}
+
+// csvRecord returns the array of the record,
+// or the map of the header to the fields in the order of the header.
+func csvRecord(record []string, header []string) object.Object {
+	if header == nil {
+		elements := make([]object.Object, len(record))
+		for i, field := range record {
+			elements[i] = &object.String{Value: field}
+		}
+		return &object.Array{Elements: elements}
+	}
+	hm := object.NewOrderedHashMap()
+	for i, name := range header {
+		if i < len(record) {
+			hm.Set(&object.String{Value: name}, &object.String{Value: record[i]})
+		}
+	}
+	return hm
+}
+
+// formatCSV writes rows which are arrays of fields or maps,
+// the columns of maps are the "columns" option or the keys of the first map,
+// and the header is written unless the "header" option is false.
+func formatCSV(rows object.Object, opts *csvOptions) ([]byte, *object.Error) {
+	arr, ok := rows.(*object.Array)
+	if !ok {
+		return nil, object.Errorf("rows must be array, got %s", rows.Type())
+	}
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Comma = opts.comma
+	w.UseCRLF = opts.useCRLF
+
+	columns := opts.columns
+	for i, row := range arr.Elements {
+		var record []string
+		switch row := row.(type) {
+		case *object.Array:
+			record = make([]string, len(row.Elements))
+			for j, e := range row.Elements {
+				record[j] = csvField(e)
+			}
+		case *object.HashMap:
+			if columns == nil {
+				columns = csvColumns(row)
+			}
+			if i == 0 && (opts.header || !opts.headerSet) {
+				if err := w.Write(columns); err != nil {
+					return nil, object.Errorf("%s", err)
+				}
+			}
+			record = make([]string, len(columns))
+			for j, c := range columns {
+				if pair, ok := row.Pairs[(&object.String{Value: c}).HashKey()]; ok {
+					record[j] = csvField(pair.Value)
+				} else {
+					record[j] = ""
+				}
+			}
+		default:
+			return nil, object.Errorf("row must be array or map, got %s", row.Type())
+		}
+		if err := w.Write(record); err != nil {
+			return nil, object.Errorf("%s", err)
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, object.Errorf("%s", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// csvField returns the field of a value, nil is an empty field.
+func csvField(obj object.Object) string {
+	if obj == nil || obj.Type() == object.NULL_OBJ {
+		return ""
+	}
+	return obj.Inspect()
+}
+
+// csvColumns returns the keys of hm in the insertion order,
+// or in alphabetical order if hm does not keep the order.
+func csvColumns(hm *object.HashMap) []string {
+	columns := []string{}
+	for _, pair := range hm.OrderedPairs() {
+		columns = append(columns, pair.Key.Inspect())
+	}
+	if hm.Keys == nil {
+		sort.Strings(columns)
+	}
+	return columns
+}
+
+// CSVReader reads a CSV file row by row.
+type CSVReader struct {
+	file   fs.File
+	r      *csv.Reader
+	opts   *csvOptions
+	header []string
+	count  int64
+	closed bool
+}
+
+var _ object.Object = &CSVReader{}
+var _ object.Describer = &CSVReader{}
+
+func (cr *CSVReader) Type() object.ObjectType { return "csv.Reader" }
+
+func (cr *CSVReader) Inspect() string { return "csv.Reader" }
+
+func (cr *CSVReader) readHeader() *object.Error {
+	record, err := cr.r.Read()
+	if err == io.EOF {
+		cr.header = []string{}
+		return nil
+	}
+	if err != nil {
+		return object.Errorf("%s", err)
+	}
+	cr.header = record
+	return nil
+}
+
+// next returns the next row or nil at the end of the file.
+func (cr *CSVReader) next() object.Object {
+	if cr.closed {
+		return eval.NULL
+	}
+	record, err := cr.r.Read()
+	if err == io.EOF {
+		cr.close()
+		return eval.NULL
+	}
+	if err != nil {
+		return object.Errorf("%s", err)
+	}
+	cr.count++
+	return csvRecord(record, cr.header)
+}
+
+func (cr *CSVReader) close() {
+	if !cr.closed {
+		cr.closed = true
+		cr.file.Close()
+	}
+}
+
+func (cr *CSVReader) Member(name string) object.MemberFunc {
+	switch name {
+	case "next":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 0 {
+				return errWrongNumberOfArguments(0, len(args))
+			}
+			return cr.next()
+		}
+	case "header":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 0 {
+				return errWrongNumberOfArguments(0, len(args))
+			}
+			if cr.header == nil {
+				return eval.NULL
+			}
+			return csvRecord(cr.header, nil)
+		}
+	case "each":
+		// each(fn) calls fn(row, idx) for the remaining rows, it stops if fn returns false.
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return errWrongNumberOfArguments(1, len(args))
+			}
+			n := int64(0)
+			for {
+				idx := cr.count
+				row := cr.next()
+				if row == eval.NULL || isError(row) {
+					if isError(row) {
+						return row
+					}
+					return &object.Integer{Value: n}
+				}
+				n++
+				ret := callFunction(args[0], row, &object.Integer{Value: idx})
+				if isError(ret) {
+					cr.close()
+					return ret
+				}
+				if b, ok := ret.(*object.Boolean); ok && !b.Value {
+					return &object.Integer{Value: n}
+				}
+			}
+		}
+	case "close":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 0 {
+				return errWrongNumberOfArguments(0, len(args))
+			}
+			cr.close()
+			return nil
+		}
+	default:
+		return nil
+	}
+}
+
+func (cr *CSVReader) Members() []object.MemberDoc {
+	return []object.MemberDoc{
+		{Name: "next", Signature: "next()", Doc: "returns the next row, or nil at the end"},
+		{Name: "header", Signature: "header()", Doc: "returns the header row, or nil if the rows have no header"},
+		{Name: "each", Signature: "each(fn)", Doc: "calls fn(row, idx) for the remaining rows until fn returns false"},
+		{Name: "close", Signature: "close()", Doc: "closes the file"},
+	}
+}
@@ -0,0 +1,95 @@
+package stdlib_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/thingsme/thingscript/object"
+	"github.com/thingsme/thingscript/stdlib"
+)
+
+func TestCSV(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "sensors.csv"), []byte("device,temp,unit\nt1,21.5,C\n# comment\nt2,70.1,F\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, "quoted.csv"), []byte("a,b\n1,\"x,y\"\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, "lazy.csv"), []byte("a,b\"c\n"), 0o644)
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`import("csv").read_file("quoted.csv")`, `[[a, b], [1, x,y]]`},
+		{"import(\"csv\").parse(\"a;b\n1;2\", {\"delimiter\": \";\"})[1]", `[1, 2]`},
+		{"import(\"csv\").parse(\"a,b\n#x,y\n1,2\", {\"comment\": \"#\"}).length()", `2`},
+		{`import("csv").parse("a, b", {"trim_leading_space": true})[0][1]`, `b`},
+		{"import(\"csv\").parse(\"a,b\n1\", {\"fields_per_record\": -1})[1]", `[1]`},
+		{`import("csv").read_file("lazy.csv", {"lazy_quotes": true})[0][1]`, `b"c`},
+		{"import(\"csv\").parse(\"z,a,m\n1,2,3\n4,5,6\", {\"header\": true})", `[{z: 1, a: 2, m: 3}, {z: 4, a: 5, m: 6}]`},
+		{"import(\"csv\").parse(\"z,a,m\n1,2,3\", {\"header\": true})[0].keys()", `[z, a, m]`},
+		{`import("csv").parse("", {"header": true})`, `[]`},
+		{`import("csv").read_file("sensors.csv", {"header": true, "comment": "#"})[1]["temp"]`, `70.1`},
+		{`import("csv").format([["a", "b"], [1, "x,y"], [true, nil]])`, "a,b\n1,\"x,y\"\ntrue,\n"},
+		{"import(\"csv\").format([[\"a\", \"b\"]], {\"delimiter\": \"\t\", \"use_crlf\": true})", "a\tb\r\n"},
+		{`import("csv").format([{"temp": 21, "device": "t1"}, {"device": "t2"}])`, "device,temp\nt1,21\nt2,\n"},
+		{`import("csv").format([{"temp": 21, "device": "t1"}], {"columns": ["temp", "device"], "header": false})`, "21,t1\n"},
+		{`csv := import("csv")
+			rows := csv.parse("z,a
+1,2", {"header": true})
+			csv.format(rows)`, "z,a\n1,2\n"},
+		{`csv := import("csv")
+			csv.write_file("out.csv", [["x", "y"], [1, 2]])
+			csv.read_file("out.csv")`, `[[x, y], [1, 2]]`},
+		{`r := import("csv").open("sensors.csv", {"header": true, "comment": "#"})
+			[r.header(), r.next()["device"], r.next()["unit"], r.next()]`, `[[device, temp, unit], t1, F, null]`},
+		{`r := import("csv").open("sensors.csv", {"comment": "#"})
+			rows := []
+			n := r.each(func(row, idx) { rows = rows.concat([idx]) })
+			[n, rows, r.next()]`, `[3, [0, 1, 2], null]`},
+		{`r := import("csv").open("sensors.csv", {"header": true, "comment": "#"})
+			r.each(func(row) { false })`, `1`},
+		{`r := import("csv").open("sensors.csv")
+			r.close()
+			r.next()`, `null`},
+	}
+	for _, tt := range tests {
+		ret := stdlib.EvalWith(tt.input, func(env *object.Environment) { env.WritableRoot = dir })
+		if ret == nil || ret.Inspect() != tt.expected {
+			t.Errorf("expected %q, got=%v <= %s", tt.expected, ret, tt.input)
+		}
+	}
+}
+
+func TestCSVErrors(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "broken.csv"), []byte("a,b\n1,2,3\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, "bare.csv"), []byte("a\"b\n"), 0o644)
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"import(\"csv\").parse(\"a,b\n1\")", "record on line 2: wrong number of fields"},
+		{`import("csv").read_file("bare.csv")`, `parse error on line 1, column 2: bare " in non-quoted-field`},
+		{`import("csv").parse(1)`, "argument to parse must be string, got INTEGER"},
+		{`import("csv").parse("a", {"delimiter": ";;"})`, "option delimiter must be a character, got ;;"},
+		{`import("csv").parse("a", {"header": 1})`, "option header must be bool, got INTEGER"},
+		{`import("csv").parse("a", {"quote": "'"})`, `unknown option "quote"`},
+		{`import("csv").read_file("none.csv")`, "open none.csv: file does not exist"},
+		{`import("csv").read_file("../x.csv")`, `invalid path "../x.csv"`},
+		{`r := import("csv").open("broken.csv"); r.next(); r.next()`, "record on line 2: wrong number of fields"},
+		{`import("csv").format([1])`, "row must be array or map, got INTEGER"},
+		{`import("csv").format("a")`, "rows must be array, got STRING"},
+	}
+	for _, tt := range tests {
+		ret := stdlib.EvalWith(tt.input, func(env *object.Environment) { env.WritableRoot = dir })
+		if err, ok := ret.(*object.Error); !ok || err.Message != tt.expected {
+			t.Errorf("expected error %q, got=%v", tt.expected, ret)
+		}
+	}
+
+	ret := stdlib.EvalWith(`import("csv").write_file("a.csv", [[1]])`, nil)
+	if err, ok := ret.(*object.Error); !ok || err.Message != "file system is read-only" {
+		t.Errorf("expected read-only error, got=%v", ret)
+	}
+}
@@ -0,0 +1,449 @@
+package stdlib
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"math"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/thingsme/thingscript/object"
+)
+
+// encodingPkg groups the encodings, e.g. encoding.base64.encode(b).
+type encodingPkg struct{}
+
+var _ object.Package = &encodingPkg{}
+var _ object.Describer = &encodingPkg{}
+
+func (ep *encodingPkg) Type() object.ObjectType { return object.PACKAGE_OBJ }
+
+func (ep *encodingPkg) Inspect() string { return "package encoding" }
+
+func (ep *encodingPkg) Name() string { return "encoding" }
+
+func (ep *encodingPkg) OnLoad(env *object.Environment) {}
+
+func (ep *encodingPkg) Member(name string) object.MemberFunc {
+	var enc object.Object
+	switch name {
+	case "base64":
+		enc = &base64Obj{name: name, enc: base64.StdEncoding}
+	case "base64url":
+		enc = &base64Obj{name: name, enc: base64.RawURLEncoding}
+	case "hex":
+		enc = &hexObj{}
+	case "url":
+		enc = &urlObj{}
+	case "binary":
+		enc = &binaryObj{}
+	default:
+		return nil
+	}
+	return func(receiver object.Object, args ...object.Object) object.Object {
+		if len(args) != 0 {
+			return errWrongNumberOfArguments(0, len(args))
+		}
+		return enc
+	}
+}
+
+func (ep *encodingPkg) Members() []object.MemberDoc {
+	return []object.MemberDoc{
+		{Name: "base64", Doc: "standard base64 with encode(data) and decode(s)"},
+		{Name: "base64url", Doc: "URL-safe base64 without padding with encode(data) and decode(s)"},
+		{Name: "hex", Doc: "hexadecimal with encode(data) and decode(s)"},
+		{Name: "url", Doc: "escape, unescape, path_escape, path_unescape, encode_query and parse_query of URLs"},
+		{Name: "binary", Doc: "read(b, type, order, offset) and write(value, type, order) of binary numbers"},
+	}
+}
+
+type base64Obj struct {
+	name string
+	enc  *base64.Encoding
+}
+
+var _ object.Object = &base64Obj{}
+var _ object.Describer = &base64Obj{}
+
+func (bo *base64Obj) Type() object.ObjectType { return object.ObjectType("encoding." + bo.name) }
+
+func (bo *base64Obj) Inspect() string { return "encoding." + bo.name }
+
+func (bo *base64Obj) Member(name string) object.MemberFunc {
+	switch name {
+	case "encode":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return errWrongNumberOfArguments(1, len(args))
+			}
+			b, ok := bytesArg(args[0])
+			if !ok {
+				return object.Errorf("argument to encode must be string or bytes, got %s", args[0].Type())
+			}
+			return &object.String{Value: bo.enc.EncodeToString(b)}
+		}
+	case "decode":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return errWrongNumberOfArguments(1, len(args))
+			}
+			s, ok := args[0].(*object.String)
+			if !ok {
+				return object.Errorf("argument to decode must be string, got %s", args[0].Type())
+			}
+			// accept the encoding with or without padding
+			b, err := bo.enc.WithPadding(base64.NoPadding).DecodeString(strings.TrimRight(s.Value, "="))
+			if err != nil {
+				return object.Errorf("%s", err)
+			}
+			return &object.Bytes{Value: b}
+		}
+	default:
+		return nil
+	}
+}
+
+func (bo *base64Obj) Members() []object.MemberDoc {
+	return []object.MemberDoc{
+		{Name: "encode", Signature: "encode(data)", Doc: "returns the base64 string of a string or bytes"},
+		{Name: "decode", Signature: "decode(s)", Doc: "returns the bytes of the base64 string s"},
+	}
+}
+
+type hexObj struct{}
+
+var _ object.Object = &hexObj{}
+var _ object.Describer = &hexObj{}
+
+func (ho *hexObj) Type() object.ObjectType { return "encoding.hex" }
+
+func (ho *hexObj) Inspect() string { return "encoding.hex" }
+
+func (ho *hexObj) Member(name string) object.MemberFunc {
+	switch name {
+	case "encode":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return errWrongNumberOfArguments(1, len(args))
+			}
+			b, ok := bytesArg(args[0])
+			if !ok {
+				return object.Errorf("argument to encode must be string or bytes, got %s", args[0].Type())
+			}
+			return &object.String{Value: hex.EncodeToString(b)}
+		}
+	case "decode":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return errWrongNumberOfArguments(1, len(args))
+			}
+			s, ok := args[0].(*object.String)
+			if !ok {
+				return object.Errorf("argument to decode must be string, got %s", args[0].Type())
+			}
+			b, err := hex.DecodeString(s.Value)
+			if err != nil {
+				return object.Errorf("%s", err)
+			}
+			return &object.Bytes{Value: b}
+		}
+	default:
+		return nil
+	}
+}
+
+func (ho *hexObj) Members() []object.MemberDoc {
+	return []object.MemberDoc{
+		{Name: "encode", Signature: "encode(data)", Doc: "returns the hex string of a string or bytes"},
+		{Name: "decode", Signature: "decode(s)", Doc: "returns the bytes of the hex string s"},
+	}
+}
+
+type urlObj struct{}
+
+var _ object.Object = &urlObj{}
+var _ object.Describer = &urlObj{}
+
+func (uo *urlObj) Type() object.ObjectType { return "encoding.url" }
+
+func (uo *urlObj) Inspect() string { return "encoding.url" }
+
+func (uo *urlObj) Member(name string) object.MemberFunc {
+	switch name {
+	case "escape", "unescape", "path_escape", "path_unescape":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return errWrongNumberOfArguments(1, len(args))
+			}
+			s, ok := args[0].(*object.String)
+			if !ok {
+				return object.Errorf("argument to %s must be string, got %s", name, args[0].Type())
+			}
+			var ret string
+			var err error
+			switch name {
+			case "escape":
+				ret = url.QueryEscape(s.Value)
+			case "unescape":
+				ret, err = url.QueryUnescape(s.Value)
+			case "path_escape":
+				ret = url.PathEscape(s.Value)
+			case "path_unescape":
+				ret, err = url.PathUnescape(s.Value)
+			}
+			if err != nil {
+				return object.Errorf("%s", err)
+			}
+			return &object.String{Value: ret}
+		}
+	case "encode_query":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return errWrongNumberOfArguments(1, len(args))
+			}
+			hm, ok := args[0].(*object.HashMap)
+			if !ok {
+				return object.Errorf("argument to encode_query must be map, got %s", args[0].Type())
+			}
+			values := url.Values{}
+			for _, pair := range hm.Pairs {
+				key := pair.Key.Inspect()
+				if arr, ok := pair.Value.(*object.Array); ok {
+					for _, e := range arr.Elements {
+						values.Add(key, e.Inspect())
+					}
+				} else {
+					values.Add(key, pair.Value.Inspect())
+				}
+			}
+			return &object.String{Value: values.Encode()}
+		}
+	case "parse_query":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return errWrongNumberOfArguments(1, len(args))
+			}
+			s, ok := args[0].(*object.String)
+			if !ok {
+				return object.Errorf("argument to parse_query must be string, got %s", args[0].Type())
+			}
+			values, err := url.ParseQuery(s.Value)
+			if err != nil {
+				return object.Errorf("%s", err)
+			}
+			ret := make(map[string]object.Object, len(values))
+			for k, vs := range values {
+				if len(vs) == 1 {
+					ret[k] = &object.String{Value: vs[0]}
+					continue
+				}
+				elements := make([]object.Object, len(vs))
+				for i, v := range vs {
+					elements[i] = &object.String{Value: v}
+				}
+				ret[k] = &object.Array{Elements: elements}
+			}
+			return newHashMap(ret)
+		}
+	default:
+		return nil
+	}
+}
+
+func (uo *urlObj) Members() []object.MemberDoc {
+	return []object.MemberDoc{
+		{Name: "escape", Signature: "escape(s)", Doc: "escapes s for a query component"},
+		{Name: "unescape", Signature: "unescape(s)", Doc: "unescapes a query component"},
+		{Name: "path_escape", Signature: "path_escape(s)", Doc: "escapes s for a path segment"},
+		{Name: "path_unescape", Signature: "path_unescape(s)", Doc: "unescapes a path segment"},
+		{Name: "encode_query", Signature: "encode_query(params)", Doc: "returns the query string of the map of params"},
+		{Name: "parse_query", Signature: "parse_query(query)", Doc: "returns the map of the parameters of query"},
+	}
+}
+
+// binaryTypes are the sizes of the types of binary.read and binary.write.
+var binaryTypes = map[string]int{
+	"int8": 1, "uint8": 1,
+	"int16": 2, "uint16": 2,
+	"int32": 4, "uint32": 4,
+	"int64": 8, "uint64": 8,
+	"float32": 4, "float64": 8,
+}
+
+type binaryObj struct{}
+
+var _ object.Object = &binaryObj{}
+var _ object.Describer = &binaryObj{}
+
+func (bo *binaryObj) Type() object.ObjectType { return "encoding.binary" }
+
+func (bo *binaryObj) Inspect() string { return "encoding.binary" }
+
+func (bo *binaryObj) Member(name string) object.MemberFunc {
+	switch name {
+	case "read":
+		// read(b, type, order, offset)
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 3 && len(args) != 4 {
+				return object.Errorf("wrong number of arguments. want=3 or 4 got=%d", len(args))
+			}
+			b, ok := args[0].(*object.Bytes)
+			if !ok {
+				return object.Errorf("argument to read must be bytes, got %s", args[0].Type())
+			}
+			typ, size, order, errObj := binaryTypeArgs(args[1], args[2])
+			if errObj != nil {
+				return errObj
+			}
+			offset := int64(0)
+			if len(args) == 4 {
+				v, ok := args[3].(*object.Integer)
+				if !ok {
+					return object.Errorf("offset must be int, got %s", args[3].Type())
+				}
+				offset = v.Value
+			}
+			if offset < 0 || offset > int64(len(b.Value))-int64(size) {
+				return object.Errorf("%s at offset %d is out of range, length %d", typ, offset, len(b.Value))
+			}
+			return readBinary(b.Value[offset:offset+int64(size)], typ, order)
+		}
+	case "write":
+		// write(value, type, order)
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 3 {
+				return errWrongNumberOfArguments(3, len(args))
+			}
+			typ, size, order, errObj := binaryTypeArgs(args[1], args[2])
+			if errObj != nil {
+				return errObj
+			}
+			buf := make([]byte, size)
+			if errObj := writeBinary(buf, args[0], typ, order); errObj != nil {
+				return errObj
+			}
+			return &object.Bytes{Value: buf}
+		}
+	default:
+		return nil
+	}
+}
+
+func (bo *binaryObj) Members() []object.MemberDoc {
+	return []object.MemberDoc{
+		{Name: "read", Signature: "read(b, type, order, offset?)", Doc: "decodes the value of type like \"uint16\" from b at offset in the \"big\" or \"little\" order"},
+		{Name: "write", Signature: "write(value, type, order)", Doc: "encodes value as type in the \"big\" or \"little\" order"},
+	}
+}
+
+func binaryTypeArgs(typeArg, orderArg object.Object) (string, int, binary.ByteOrder, *object.Error) {
+	typ, ok := typeArg.(*object.String)
+	if !ok {
+		return "", 0, nil, object.Errorf("type must be string, got %s", typeArg.Type())
+	}
+	size, ok := binaryTypes[typ.Value]
+	if !ok {
+		names := make([]string, 0, len(binaryTypes))
+		for n := range binaryTypes {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return "", 0, nil, object.Errorf("unknown type %q, want one of %v", typ.Value, names)
+	}
+	order, ok := orderArg.(*object.String)
+	if !ok {
+		return "", 0, nil, object.Errorf("byte order must be string, got %s", orderArg.Type())
+	}
+	switch order.Value {
+	case "little":
+		return typ.Value, size, binary.LittleEndian, nil
+	case "big":
+		return typ.Value, size, binary.BigEndian, nil
+	default:
+		return "", 0, nil, object.Errorf("byte order must be \"little\" or \"big\", got %q", order.Value)
+	}
+}
+
+// readBinary reads b of the size of typ, uint64 larger than the max of int wraps around.
+func readBinary(b []byte, typ string, order binary.ByteOrder) object.Object {
+	switch typ {
+	case "int8":
+		return &object.Integer{Value: int64(int8(b[0]))}
+	case "uint8":
+		return &object.Integer{Value: int64(b[0])}
+	case "int16":
+		return &object.Integer{Value: int64(int16(order.Uint16(b)))}
+	case "uint16":
+		return &object.Integer{Value: int64(order.Uint16(b))}
+	case "int32":
+		return &object.Integer{Value: int64(int32(order.Uint32(b)))}
+	case "uint32":
+		return &object.Integer{Value: int64(order.Uint32(b))}
+	case "int64", "uint64":
+		return &object.Integer{Value: int64(order.Uint64(b))}
+	case "float32":
+		return &object.Float{Value: float64(math.Float32frombits(order.Uint32(b)))}
+	default: // float64
+		return &object.Float{Value: math.Float64frombits(order.Uint64(b))}
+	}
+}
+
+func writeBinary(buf []byte, value object.Object, typ string, order binary.ByteOrder) *object.Error {
+	if typ == "float32" || typ == "float64" {
+		var f float64
+		switch v := value.(type) {
+		case *object.Float:
+			f = v.Value
+		case *object.Integer:
+			f = float64(v.Value)
+		default:
+			return object.Errorf("value of %s must be int or float, got %s", typ, value.Type())
+		}
+		if typ == "float32" {
+			order.PutUint32(buf, math.Float32bits(float32(f)))
+		} else {
+			order.PutUint64(buf, math.Float64bits(f))
+		}
+		return nil
+	}
+
+	iv, ok := value.(*object.Integer)
+	if !ok {
+		return object.Errorf("value of %s must be int, got %s", typ, value.Type())
+	}
+	n := iv.Value
+	var min, max int64
+	switch typ {
+	case "int8":
+		min, max = math.MinInt8, math.MaxInt8
+	case "uint8":
+		min, max = 0, math.MaxUint8
+	case "int16":
+		min, max = math.MinInt16, math.MaxInt16
+	case "uint16":
+		min, max = 0, math.MaxUint16
+	case "int32":
+		min, max = math.MinInt32, math.MaxInt32
+	case "uint32":
+		min, max = 0, math.MaxUint32
+	default: // int64 and uint64 which takes the bits of the int
+		min, max = math.MinInt64, math.MaxInt64
+	}
+	if n < min || n > max {
+		return object.Errorf("value %d overflows %s", n, typ)
+	}
+	switch len(buf) {
+	case 1:
+		buf[0] = byte(n)
+	case 2:
+		order.PutUint16(buf, uint16(n))
+	case 4:
+		order.PutUint32(buf, uint32(n))
+	default:
+		order.PutUint64(buf, uint64(n))
+	}
+	return nil
+}
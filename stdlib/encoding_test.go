@@ -0,0 +1,76 @@
+package stdlib
+
+import (
+	"testing"
+
+	"github.com/thingsme/thingscript/object"
+)
+
+func TestEncoding(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected any
+	}{
+		{`import("encoding").base64.encode("hello?>")`, "aGVsbG8/Pg=="},
+		{`import("encoding").base64url.encode("hello?>")`, "aGVsbG8_Pg"},
+		{`import("encoding").base64url.encode("hi?")`, "aGk_"},
+		{`import("encoding").base64url.encode(bytes([251, 255]))`, "-_8"},
+		{`import("encoding").base64url.decode("aGVsbG8_Pg==").string()`, "hello?>"},
+		{`import("encoding").base64.decode("aGVsbG8/Pg").string()`, "hello?>"},
+		{`import("encoding").base64.encode(bytes([0, 255]))`, "AP8="},
+		{`import("encoding").base64.decode("aGVsbG8/Pg==").string()`, "hello?>"},
+		{`import("encoding").base64url.decode("aGVsbG8_Pg").string()`, "hello?>"},
+		{`import("encoding").base64.decode("!!")`, &object.Error{Message: "illegal base64 data at input byte 0"}},
+		{`import("encoding").hex.encode(bytes([1, 171, 255]))`, "01abff"},
+		{`import("encoding").hex.decode("01abff").array()`, []int64{1, 171, 255}},
+		{`import("encoding").hex.decode("0")`, &object.Error{Message: "encoding/hex: odd length hex string"}},
+		{`import("encoding").hex.encode(1)`, &object.Error{Message: "argument to encode must be string or bytes, got INTEGER"}},
+		{`import("encoding").url.escape("a b&c=d")`, "a+b%26c%3Dd"},
+		{`import("encoding").url.unescape("a+b%26c")`, "a b&c"},
+		{`import("encoding").url.path_escape("a b/c")`, "a%20b%2Fc"},
+		{`import("encoding").url.path_unescape("a%20b")`, "a b"},
+		{`import("encoding").url.encode_query({"q": "a b", "n": 1, "tag": ["x", "y"]})`, "n=1&q=a+b&tag=x&tag=y"},
+		{`import("encoding").url.parse_query("q=a+b&tag=x&tag=y")["q"]`, "a b"},
+		{`import("encoding").url.parse_query("q=a+b&tag=x&tag=y")["tag"][1]`, "y"},
+		{`import("encoding").url.unescape("%zz")`, &object.Error{Message: `invalid URL escape "%zz"`}},
+	}
+	for _, tt := range tests {
+		runTest(t, tt.input, tt.expected)
+	}
+}
+
+func TestBinary(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected any
+	}{
+		{`import("encoding").binary.write(258, "uint16", "big").array()`, []int64{1, 2}},
+		{`import("encoding").binary.write(258, "uint16", "little").array()`, []int64{2, 1}},
+		{`import("encoding").binary.write(-1, "int8", "big").array()`, []int64{255}},
+		{`import("encoding").binary.write(-2, "int32", "little").array()`, []int64{254, 255, 255, 255}},
+		{`import("encoding").binary.write(1, "float32", "big").array()`, []int64{63, 128, 0, 0}},
+		{`import("encoding").binary.read(bytes([1, 2]), "uint16", "big")`, 258},
+		{`import("encoding").binary.read(bytes([1, 2]), "uint16", "little")`, 513},
+		{`import("encoding").binary.read(bytes([255]), "int8", "big")`, -1},
+		{`import("encoding").binary.read(bytes([255]), "uint8", "big")`, 255},
+		{`import("encoding").binary.read(bytes([0, 254, 255, 255, 255]), "int32", "little", 1)`, -2},
+		{`import("encoding").binary.read(bytes([255, 255, 255, 255]), "uint32", "big")`, 4294967295},
+		{`import("encoding").binary.read(bytes([63, 128, 0, 0]), "float32", "big")`, 1.0},
+		{`b := import("encoding").binary
+			b.read(b.write(-1.5, "float64", "little"), "float64", "little")`, -1.5},
+		{`b := import("encoding").binary
+			b.read(b.write(-5, "int64", "big"), "int64", "big")`, -5},
+		{`import("encoding").binary.write(256, "uint8", "big")`, &object.Error{Message: "value 256 overflows uint8"}},
+		{`import("encoding").binary.write(-1, "uint16", "big")`, &object.Error{Message: "value -1 overflows uint16"}},
+		{`import("encoding").binary.write(1.5, "int16", "big")`, &object.Error{Message: "value of int16 must be int, got FLOAT"}},
+		{`import("encoding").binary.write(1, "int", "big")`, &object.Error{Message: `unknown type "int", want one of [float32 float64 int16 int32 int64 int8 uint16 uint32 uint64 uint8]`}},
+		{`import("encoding").binary.write(1, "int16", "middle")`, &object.Error{Message: `byte order must be "little" or "big", got "middle"`}},
+		{`import("encoding").binary.read(bytes([1, 2]), "uint32", "big")`, &object.Error{Message: "uint32 at offset 0 is out of range, length 2"}},
+		{`import("encoding").binary.read(bytes([1, 2]), "uint8", "big", 2)`, &object.Error{Message: "uint8 at offset 2 is out of range, length 2"}},
+		{`import("encoding").binary.read(bytes("abc"), "uint8", "little", 9223372036854775807)`, &object.Error{Message: "uint8 at offset 9223372036854775807 is out of range, length 3"}},
+		{`import("encoding").binary.read("ab", "uint8", "big")`, &object.Error{Message: "argument to read must be bytes, got STRING"}},
+	}
+	for _, tt := range tests {
+		runTest(t, tt.input, tt.expected)
+	}
+}
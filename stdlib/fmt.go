@@ -4,15 +4,18 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"sync"
 
 	"github.com/thingsme/thingscript/object"
 )
 
 type fmtPkg struct {
+	mu  sync.Mutex
 	out io.Writer
 }
 
 var _ object.Package = &fmtPkg{}
+var _ object.Describer = &fmtPkg{}
 
 func (tp *fmtPkg) Type() object.ObjectType { return object.PACKAGE_OBJ }
 
@@ -33,9 +36,11 @@ func (fp *fmtPkg) Member(member string) object.MemberFunc {
 	case "println":
 		return func(receiver object.Object, args ...object.Object) object.Object {
 			params := object2native(args)
+			fp.mu.Lock()
 			n, err := fmt.Fprintln(fp.out, params...)
+			fp.mu.Unlock()
 			if err != nil {
-				return object.Errorf(err.Error())
+				return object.Errorf("%s", err)
 			}
 			return &object.Integer{Value: int64(n)}
 		}
@@ -46,9 +51,11 @@ func (fp *fmtPkg) Member(member string) object.MemberFunc {
 			}
 			format := args[0].Inspect()
 			params := object2native(args[1:])
+			fp.mu.Lock()
 			n, err := fmt.Fprintf(fp.out, format, params...)
+			fp.mu.Unlock()
 			if err != nil {
-				return object.Errorf(err.Error())
+				return object.Errorf("%s", err)
 			}
 			return &object.Integer{Value: int64(n)}
 		}
@@ -57,6 +64,13 @@ func (fp *fmtPkg) Member(member string) object.MemberFunc {
 	}
 }
 
+func (fp *fmtPkg) Members() []object.MemberDoc {
+	return []object.MemberDoc{
+		{Name: "println", Signature: "println(args...)", Doc: "writes args separated by spaces and a newline to Environment.Stdout"},
+		{Name: "printf", Signature: "printf(format, args...)", Doc: "writes args formatted by format to Environment.Stdout"},
+	}
+}
+
 func object2native(args []object.Object) []any {
 	params := make([]any, len(args))
 	for i, a := range args {
@@ -0,0 +1,363 @@
+package stdlib
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/thingsme/thingscript/object"
+)
+
+// fsPkg gives scripts the access to the files the host granted,
+// which are Environment.FS for reading and Environment.WritableRoot for writing.
+// The paths are slash-separated and relative to the roots, they can not escape from the roots.
+type fsPkg struct {
+	mu      sync.Mutex
+	fsys    fs.FS
+	root    string
+	quota   int64
+	written int64
+}
+
+var _ object.Package = &fsPkg{}
+var _ object.Describer = &fsPkg{}
+
+var (
+	errNoFileSystem = errors.New("file system is not available")
+	errReadOnly     = errors.New("file system is read-only")
+	errQuota        = errors.New("file system quota exceeded")
+)
+
+func (fp *fsPkg) Type() object.ObjectType { return object.PACKAGE_OBJ }
+
+func (fp *fsPkg) Inspect() string { return "package fs" }
+
+func (fp *fsPkg) Name() string { return "fs" }
+
+func (fp *fsPkg) OnLoad(env *object.Environment) {
+	fp.root = env.WritableRoot
+	fp.quota = env.FSQuota
+	if env.FS != nil {
+		fp.fsys = env.FS
+	} else if env.WritableRoot != "" {
+		fp.fsys = rootFS(env.WritableRoot)
+	}
+}
+
+func (fp *fsPkg) Member(name string) object.MemberFunc {
+	switch name {
+	case "read_file":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			p, errObj := fp.pathArg(name, args, 1)
+			if errObj != nil {
+				return errObj
+			}
+			b, err := fp.readFile(p)
+			if err != nil {
+				return object.Errorf("%s", err)
+			}
+			return &object.String{Value: string(b)}
+		}
+	case "write_file", "append":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			p, errObj := fp.pathArg(name, args, 2)
+			if errObj != nil {
+				return errObj
+			}
+			data, ok := args[1].(*object.String)
+			if !ok {
+				return object.Errorf("data of %s must be string, got %s", name, args[1].Type())
+			}
+			n, err := fp.write(p, []byte(data.Value), name == "append")
+			if err != nil {
+				return object.Errorf("%s", err)
+			}
+			return &object.Integer{Value: int64(n)}
+		}
+	case "list_dir":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) == 0 {
+				args = []object.Object{&object.String{Value: "."}}
+			}
+			p, errObj := fp.pathArg(name, args, 1)
+			if errObj != nil {
+				return errObj
+			}
+			if fp.fsys == nil {
+				return object.Errorf("%s", errNoFileSystem)
+			}
+			entries, err := fs.ReadDir(fp.fsys, p)
+			if err != nil {
+				return object.Errorf("%s", err)
+			}
+			names := make([]string, len(entries))
+			for i, e := range entries {
+				names[i] = e.Name()
+			}
+			sort.Strings(names)
+			elements := make([]object.Object, len(names))
+			for i, n := range names {
+				elements[i] = &object.String{Value: n}
+			}
+			return &object.Array{Elements: elements}
+		}
+	case "exists":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			p, errObj := fp.pathArg(name, args, 1)
+			if errObj != nil {
+				return errObj
+			}
+			if fp.fsys == nil {
+				return object.Errorf("%s", errNoFileSystem)
+			}
+			_, err := fs.Stat(fp.fsys, p)
+			return &object.Boolean{Value: err == nil}
+		}
+	case "stat":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			p, errObj := fp.pathArg(name, args, 1)
+			if errObj != nil {
+				return errObj
+			}
+			if fp.fsys == nil {
+				return object.Errorf("%s", errNoFileSystem)
+			}
+			info, err := fs.Stat(fp.fsys, p)
+			if err != nil {
+				return object.Errorf("%s", err)
+			}
+			return newHashMap(map[string]object.Object{
+				"name":     &object.String{Value: info.Name()},
+				"size":     &object.Integer{Value: info.Size()},
+				"dir":      &object.Boolean{Value: info.IsDir()},
+				"mode":     &object.String{Value: info.Mode().String()},
+				"mod_time": &TimeObj{tm: info.ModTime()},
+			})
+		}
+	case "remove":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			p, errObj := fp.pathArg(name, args, 1)
+			if errObj != nil {
+				return errObj
+			}
+			full, err := fp.removablePath(p)
+			if err != nil {
+				return object.Errorf("%s", err)
+			}
+			if err := os.Remove(full); err != nil {
+				if errors.Is(err, fs.ErrNotExist) {
+					return &object.Boolean{Value: false}
+				}
+				return object.Errorf("%s", &fs.PathError{Op: "remove", Path: p, Err: portableError(err)})
+			}
+			return &object.Boolean{Value: true}
+		}
+	default:
+		return nil
+	}
+}
+
+func (fp *fsPkg) Members() []object.MemberDoc {
+	return []object.MemberDoc{
+		{Name: "read_file", Signature: "read_file(path)", Doc: "returns the content of the file as string"},
+		{Name: "write_file", Signature: "write_file(path, data)", Doc: "writes data to the file in Environment.WritableRoot"},
+		{Name: "append", Signature: "append(path, data)", Doc: "appends data to the file in Environment.WritableRoot"},
+		{Name: "list_dir", Signature: "list_dir(path?)", Doc: "returns the names of the entries of the directory, which defaults to \".\""},
+		{Name: "exists", Signature: "exists(path)", Doc: "returns true if the file exists"},
+		{Name: "stat", Signature: "stat(path)", Doc: "returns the name, the size, the mode, the modification time and whether it is a directory"},
+		{Name: "remove", Signature: "remove(path)", Doc: "removes the file, the empty directory or the symbolic link"},
+	}
+}
+
+// pathArg checks the number of arguments and returns the cleaned path of the first argument.
+func (fp *fsPkg) pathArg(name string, args []object.Object, want int) (string, *object.Error) {
+	if len(args) != want {
+		return "", errWrongNumberOfArguments(want, len(args))
+	}
+	s, ok := args[0].(*object.String)
+	if !ok {
+		return "", object.Errorf("path of %s must be string, got %s", name, args[0].Type())
+	}
+	p := path.Clean(s.Value)
+	if !fs.ValidPath(p) {
+		return "", object.Errorf("invalid path %q", s.Value)
+	}
+	return p, nil
+}
+
+// fsPathArg returns the fs package of env and the cleaned path of arg,
+// it is used by the packages which access the files through the fs package.
+func fsPathArg(env *object.Environment, name string, arg object.Object) (*fsPkg, string, *object.Error) {
+	s, ok := arg.(*object.String)
+	if !ok {
+		return nil, "", object.Errorf("path of %s must be string, got %s", name, arg.Type())
+	}
+	p := path.Clean(s.Value)
+	if !fs.ValidPath(p) {
+		return nil, "", object.Errorf("invalid path %q", s.Value)
+	}
+	var fp *fsPkg
+	if env != nil {
+		if pkg, ok := env.Import("fs"); ok {
+			fp, _ = pkg.(*fsPkg)
+		}
+	}
+	if fp == nil {
+		return nil, "", object.Errorf("%s", errNoFileSystem)
+	}
+	return fp, p, nil
+}
+
+func (fp *fsPkg) readFile(p string) ([]byte, error) {
+	if fp.fsys == nil {
+		return nil, errNoFileSystem
+	}
+	return fs.ReadFile(fp.fsys, p)
+}
+
+func (fp *fsPkg) open(p string) (fs.File, error) {
+	if fp.fsys == nil {
+		return nil, errNoFileSystem
+	}
+	return fp.fsys.Open(p)
+}
+
+// writablePath returns the OS path of p in the writable root, p must not be the root itself.
+func (fp *fsPkg) writablePath(p string) (string, error) {
+	if fp.root == "" {
+		return "", errReadOnly
+	}
+	full, err := resolveInRoot(fp.root, p)
+	if err != nil {
+		return "", err
+	}
+	return full, fp.checkNotRoot(full, p)
+}
+
+// removablePath is writablePath whose last element is not resolved,
+// so that a symbolic link is removed instead of the file it points to.
+func (fp *fsPkg) removablePath(p string) (string, error) {
+	if fp.root == "" {
+		return "", errReadOnly
+	}
+	dir, err := resolveInRoot(fp.root, path.Dir(p))
+	if err != nil {
+		var pathErr *fs.PathError
+		if errors.As(err, &pathErr) {
+			pathErr.Path = p
+		}
+		return "", err
+	}
+	full := filepath.Join(dir, path.Base(p))
+	return full, fp.checkNotRoot(full, p)
+}
+
+// checkNotRoot denies full if it is the writable root, which scripts can not replace or remove.
+func (fp *fsPkg) checkNotRoot(full string, p string) error {
+	root, err := filepath.EvalSymlinks(fp.root)
+	if err != nil {
+		return err
+	}
+	if full == root {
+		return &fs.PathError{Op: "open", Path: p, Err: fs.ErrPermission}
+	}
+	return nil
+}
+
+// resolveInRoot returns the OS path of p in root,
+// it fails if p escapes from root through symbolic links.
+func resolveInRoot(root string, p string) (string, error) {
+	root, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return "", err
+	}
+	full := filepath.Join(root, filepath.FromSlash(p))
+	resolved, err := filepath.EvalSymlinks(full)
+	if errors.Is(err, fs.ErrNotExist) {
+		// a dangling symbolic link would create the file where it points to
+		if _, err := os.Lstat(full); err == nil {
+			return "", &fs.PathError{Op: "open", Path: p, Err: fs.ErrPermission}
+		}
+		// the file will be created, check its directory instead
+		var dir string
+		dir, err = filepath.EvalSymlinks(filepath.Dir(full))
+		resolved = filepath.Join(dir, filepath.Base(full))
+	}
+	if err != nil {
+		return "", &fs.PathError{Op: "open", Path: p, Err: fs.ErrNotExist}
+	}
+	if resolved != root && !strings.HasPrefix(resolved, root+string(filepath.Separator)) {
+		return "", &fs.PathError{Op: "open", Path: p, Err: fs.ErrPermission}
+	}
+	return resolved, nil
+}
+
+// rootFS is the file system of a directory which does not follow symbolic links out of the directory.
+type rootFS string
+
+func (r rootFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	full, err := resolveInRoot(string(r), name)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(full)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: portableError(err)}
+	}
+	return f, nil
+}
+
+// portableError hides the OS specific error messages of the common errors.
+func portableError(err error) error {
+	for _, e := range []error{fs.ErrNotExist, fs.ErrPermission, fs.ErrExist} {
+		if errors.Is(err, e) {
+			return e
+		}
+	}
+	return errors.Unwrap(err)
+}
+
+func (fp *fsPkg) write(p string, data []byte, appending bool) (int, error) {
+	full, err := fp.writablePath(p)
+	if err != nil {
+		return 0, err
+	}
+	fp.mu.Lock()
+	defer fp.mu.Unlock()
+	if fp.quota > 0 && fp.written+int64(len(data)) > fp.quota {
+		return 0, errQuota
+	}
+	flag := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if appending {
+		flag = os.O_WRONLY | os.O_CREATE | os.O_APPEND
+	}
+	// the link replacing the file after resolveInRoot is not followed
+	f, err := os.OpenFile(full, flag|oNoFollow, 0o644)
+	if err != nil {
+		return 0, &fs.PathError{Op: "open", Path: p, Err: portableError(err)}
+	}
+	defer f.Close()
+	n, err := f.Write(data)
+	fp.written += int64(n)
+	if err != nil {
+		return n, &fs.PathError{Op: "write", Path: p, Err: portableError(err)}
+	}
+	return n, nil
+}
+
+func newHashMap(values map[string]object.Object) *object.HashMap {
+	pairs := make(map[object.HashKey]object.HashPair, len(values))
+	for k, v := range values {
+		key := &object.String{Value: k}
+		pairs[key.HashKey()] = object.HashPair{Key: key, Value: v}
+	}
+	return &object.HashMap{Pairs: pairs}
+}
@@ -0,0 +1,6 @@
+//go:build !unix
+
+package stdlib
+
+// oNoFollow is not supported, the symbolic links are only checked by resolveInRoot.
+const oNoFollow = 0
@@ -0,0 +1,180 @@
+package stdlib_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/thingsme/thingscript/object"
+	"github.com/thingsme/thingscript/stdlib"
+)
+
+func TestFSWritableRoot(t *testing.T) {
+	dir := t.TempDir()
+	os.Mkdir(filepath.Join(dir, "sub"), 0o755)
+	os.WriteFile(filepath.Join(dir, "sub", "config.txt"), []byte("key=value"), 0o644)
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`fs := import("fs"); fs.read_file("sub/config.txt")`, "key=value"},
+		{`fs := import("fs"); fs.read_file("./sub/../sub/config.txt")`, "key=value"},
+		{`fs := import("fs"); fs.write_file("log.txt", "hello")`, "5"},
+		{`fs := import("fs"); fs.append("log.txt", " world"); fs.read_file("log.txt")`, "hello world"},
+		{`fs := import("fs"); fs.exists("log.txt")`, "true"},
+		{`fs := import("fs"); fs.exists("nothing.txt")`, "false"},
+		{`fs := import("fs"); fs.list_dir()`, "[log.txt, sub]"},
+		{`fs := import("fs"); fs.list_dir("sub")`, "[config.txt]"},
+		{`fs := import("fs"); st := fs.stat("log.txt"); [st["name"], st["size"], st["dir"]]`, "[log.txt, 11, false]"},
+		{`fs := import("fs"); fs.stat("sub")["dir"]`, "true"},
+		{`fs := import("fs"); fs.remove("log.txt")`, "true"},
+		{`fs := import("fs"); fs.remove("log.txt")`, "false"},
+		{`fs := import("fs"); fs.read_file("../secret")`, `ERROR: invalid path "../secret"`},
+		{`fs := import("fs"); fs.write_file("/etc/passwd", "x")`, `ERROR: invalid path "/etc/passwd"`},
+		{`fs := import("fs"); fs.read_file("nothing.txt")`, "ERROR: open nothing.txt: file does not exist"},
+		{`fs := import("fs"); fs.write_file("a/b.txt", "x")`, "ERROR: open a/b.txt: file does not exist"},
+		{`fs := import("fs"); fs.write_file("x.txt", 1)`, "ERROR: data of write_file must be string, got INTEGER"},
+		{`fs := import("fs"); fs.read_file(1)`, "ERROR: path of read_file must be string, got INTEGER"},
+	}
+	for _, tt := range tests {
+		ret := stdlib.EvalWith(tt.input, func(env *object.Environment) { env.WritableRoot = dir })
+		if ret == nil || ret.Inspect() != tt.expected {
+			t.Errorf("expected %q, got=%v <= %s", tt.expected, ret, tt.input)
+		}
+	}
+}
+
+func TestFSRoot(t *testing.T) {
+	dir := t.TempDir()
+	os.Mkdir(filepath.Join(dir, "sub"), 0o755)
+	tests := []string{
+		`import("fs").remove(".")`,
+		`import("fs").remove("")`,
+		`import("fs").remove("sub/..")`,
+		`import("fs").write_file(".", "x")`,
+		`import("fs").append("sub/..", "x")`,
+	}
+	for _, tt := range tests {
+		ret := stdlib.EvalWith(tt, func(env *object.Environment) { env.WritableRoot = dir })
+		if err, ok := ret.(*object.Error); !ok || !strings.HasSuffix(err.Message, ": permission denied") {
+			t.Errorf("the root should not be written, got=%v <= %s", ret, tt)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "sub")); err != nil {
+		t.Errorf("the root is removed")
+	}
+}
+
+func TestFSRemoveSymlink(t *testing.T) {
+	outside := t.TempDir()
+	os.WriteFile(filepath.Join(outside, "secret.txt"), []byte("secret"), 0o644)
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "data.txt"), []byte("data"), 0o644)
+	if err := os.Symlink(filepath.Join(dir, "data.txt"), filepath.Join(dir, "link")); err != nil {
+		t.Skip("symlink is not supported", err)
+	}
+	os.Symlink(filepath.Join(outside, "secret.txt"), filepath.Join(dir, "escape"))
+	ret := stdlib.EvalWith(`
+		fs := import("fs")
+		[fs.remove("link"), fs.remove("escape"), fs.list_dir()]
+	`, func(env *object.Environment) { env.WritableRoot = dir })
+	if ret == nil || ret.Inspect() != "[true, true, [data.txt]]" {
+		t.Errorf("the links should be removed, got=%v", ret)
+	}
+	for _, p := range []string{filepath.Join(dir, "data.txt"), filepath.Join(outside, "secret.txt")} {
+		if _, err := os.Stat(p); err != nil {
+			t.Errorf("the target of the link is removed, %s", err)
+		}
+	}
+}
+
+func TestFSSymlinkEscape(t *testing.T) {
+	outside := t.TempDir()
+	os.WriteFile(filepath.Join(outside, "secret.txt"), []byte("secret"), 0o644)
+	dir := t.TempDir()
+	if err := os.Symlink(outside, filepath.Join(dir, "link")); err != nil {
+		t.Skip("symlink is not supported", err)
+	}
+	tests := []string{
+		`import("fs").read_file("link/secret.txt")`,
+		`import("fs").write_file("link/new.txt", "x")`,
+		`import("fs").remove("link/secret.txt")`,
+	}
+	for _, tt := range tests {
+		ret := stdlib.EvalWith(tt, func(env *object.Environment) { env.WritableRoot = dir })
+		if ret == nil || ret.Inspect() != "ERROR: open link/secret.txt: permission denied" &&
+			ret.Inspect() != "ERROR: open link/new.txt: permission denied" {
+			t.Errorf("escape should be denied, got=%v <= %s", ret, tt)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(outside, "secret.txt")); err != nil {
+		t.Errorf("file outside of the root is removed")
+	}
+}
+
+func TestFSDanglingSymlink(t *testing.T) {
+	outside := t.TempDir()
+	dir := t.TempDir()
+	if err := os.Symlink(filepath.Join(outside, "new.txt"), filepath.Join(dir, "link")); err != nil {
+		t.Skip("symlink is not supported", err)
+	}
+	for _, tt := range []string{
+		`import("fs").write_file("link", "x")`,
+		`import("fs").append("link", "x")`,
+	} {
+		ret := stdlib.EvalWith(tt, func(env *object.Environment) { env.WritableRoot = dir })
+		if ret == nil || ret.Inspect() != "ERROR: open link: permission denied" {
+			t.Errorf("escape should be denied, got=%v <= %s", ret, tt)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(outside, "new.txt")); err == nil {
+		t.Errorf("file outside of the root is created")
+	}
+}
+
+func TestFSReadOnly(t *testing.T) {
+	mapFS := fstest.MapFS{
+		"config.json": &fstest.MapFile{Data: []byte(`{"a":1}`)},
+	}
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`import("fs").read_file("config.json")`, `{"a":1}`},
+		{`import("fs").write_file("config.json", "x")`, "ERROR: file system is read-only"},
+		{`import("fs").remove("config.json")`, "ERROR: file system is read-only"},
+	}
+	for _, tt := range tests {
+		ret := stdlib.EvalWith(tt.input, func(env *object.Environment) { env.FS = mapFS })
+		if ret == nil || ret.Inspect() != tt.expected {
+			t.Errorf("expected %q, got=%v <= %s", tt.expected, ret, tt.input)
+		}
+	}
+
+	ret := stdlib.EvalWith(`import("fs").read_file("config.json")`, nil)
+	if ret.Inspect() != "ERROR: file system is not available" {
+		t.Errorf("fs should not be available without grants, got=%s", ret.Inspect())
+	}
+}
+
+func TestFSQuota(t *testing.T) {
+	dir := t.TempDir()
+	ret := stdlib.EvalWith(`
+		fs := import("fs")
+		fs.write_file("a.txt", "12345")
+		fs.append("a.txt", "67890")
+		fs.write_file("b.txt", "x")
+	`, func(env *object.Environment) {
+		env.WritableRoot = dir
+		env.FSQuota = 10
+	})
+	if ret == nil || ret.Inspect() != "ERROR: file system quota exceeded" {
+		t.Errorf("quota should be exceeded, got=%v", ret)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "b.txt")); err == nil {
+		t.Errorf("b.txt should not be written")
+	}
+}
@@ -0,0 +1,8 @@
+//go:build unix
+
+package stdlib
+
+import "syscall"
+
+// oNoFollow makes opening a symbolic link fail.
+const oNoFollow = syscall.O_NOFOLLOW
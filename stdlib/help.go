@@ -0,0 +1,93 @@
+package stdlib
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/thingsme/thingscript/object"
+)
+
+// Dir returns the names of the members described by value in ascending order.
+func Dir(value object.Object) []string {
+	d, ok := value.(object.Describer)
+	if !ok {
+		return []string{}
+	}
+	members := d.Members()
+	names := make([]string, len(members))
+	for i, m := range members {
+		names[i] = m.Name
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Help returns the description of value, or of its member if member is not empty.
+//
+//	package fmt
+//
+//	println(args...)
+//	    writes args separated by spaces and a newline to Environment.Stdout
+func Help(value object.Object, member string) (string, error) {
+	if bm, ok := value.(*object.BoundMethod); ok && member == "" {
+		value, member = bm.Receiver, bm.Name
+	}
+	d, ok := value.(object.Describer)
+	if member != "" {
+		if ok {
+			for _, m := range d.Members() {
+				if m.Name == member {
+					return helpMember(m), nil
+				}
+			}
+		}
+		return "", fmt.Errorf("%q is not a member of %s", member, typeName(value))
+	}
+	if fn, ok := value.(*object.Function); ok {
+		params := make([]string, len(fn.Parameters))
+		for i, p := range fn.Parameters {
+			params[i] = p.Value
+		}
+		return fmt.Sprintf("func %s(%s)", fn.Name, strings.Join(params, ", ")), nil
+	}
+	var out strings.Builder
+	out.WriteString(typeName(value))
+	if ok {
+		for _, m := range d.Members() {
+			out.WriteString("\n\n")
+			out.WriteString(helpMember(m))
+		}
+	}
+	return out.String(), nil
+}
+
+func helpMember(m object.MemberDoc) string {
+	signature := m.Signature
+	if signature == "" {
+		signature = m.Name
+	}
+	if m.Doc == "" {
+		return signature
+	}
+	return signature + "\n    " + m.Doc
+}
+
+// typeName returns the name of the type of value, the packages are named like "package fmt".
+func typeName(value object.Object) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case object.Package:
+		if v.Name() == "" {
+			return "builtins"
+		}
+		return "package " + v.Name()
+	}
+	if t := value.Member("type"); t != nil {
+		if s, ok := t(value).(*object.String); ok {
+			return s.Value
+		}
+	}
+	return string(value.Type())
+}
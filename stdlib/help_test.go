@@ -0,0 +1,45 @@
+package stdlib
+
+import (
+	"testing"
+
+	"github.com/thingsme/thingscript/object"
+)
+
+func TestDir(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`dir(import("sync"))`, `[channel, mutex, select, wait_group]`},
+		{`dir(import("sync").wait_group())`, `[add, done, wait]`},
+		{`dir("abc")`, `[bytes, length, type]`},
+		{`dir(func() {})`, `[]`},
+	}
+	for _, tt := range tests {
+		ret := testEval(tt.input)
+		if ret.Inspect() != tt.expected {
+			t.Errorf("expected %s, got=%s <= %s", tt.expected, ret.Inspect(), tt.input)
+		}
+	}
+}
+
+func TestHelp(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected any
+	}{
+		{`help(import("fmt"))`, "package fmt\n\n" +
+			"println(args...)\n    writes args separated by spaces and a newline to Environment.Stdout\n\n" +
+			"printf(format, args...)\n    writes args formatted by format to Environment.Stdout"},
+		{`help(import("fmt"), "printf")`, "printf(format, args...)\n    writes args formatted by format to Environment.Stdout"},
+		{`help(1, "type")`, "type()\n    returns \"int\""},
+		{`help(import("time").seconds(1), "string")`, "string()\n    returns the duration like \"1m30s\""},
+		{`func add(a, b) { a + b }; help(add)`, "func add(a, b)"},
+		{`help(import("fmt"), "sprintf")`, &object.Error{Message: `"sprintf" is not a member of package fmt`}},
+		{`help(1, 2)`, &object.Error{Message: "member of help must be string, got INTEGER"}},
+	}
+	for _, tt := range tests {
+		runTest(t, tt.input, tt.expected)
+	}
+}
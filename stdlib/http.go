@@ -0,0 +1,346 @@
+package stdlib
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/thingsme/thingscript/eval"
+	"github.com/thingsme/thingscript/object"
+)
+
+// httpPkg is the http client of scripts, the requests are sent through
+// Environment.HTTPTransport, so the host decides which endpoints are reachable.
+type httpPkg struct {
+	env         *object.Environment
+	transport   http.RoundTripper
+	maxBodySize int64
+}
+
+var _ object.Package = &httpPkg{}
+var _ object.Describer = &httpPkg{}
+
+var errNoTransport = errors.New("http transport is not available")
+
+const (
+	httpDefaultTimeout = 30 * time.Second
+	httpMaxBodySize    = 32 << 20
+)
+
+func (hp *httpPkg) Type() object.ObjectType { return object.PACKAGE_OBJ }
+
+func (hp *httpPkg) Inspect() string { return "package http" }
+
+func (hp *httpPkg) Name() string { return "http" }
+
+func (hp *httpPkg) OnLoad(env *object.Environment) {
+	hp.env = env
+	hp.transport = env.HTTPTransport
+	hp.maxBodySize = env.HTTPMaxBodySize
+	if hp.maxBodySize <= 0 {
+		hp.maxBodySize = httpMaxBodySize
+	}
+}
+
+func (hp *httpPkg) Member(name string) object.MemberFunc {
+	switch name {
+	case "get":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 1 && len(args) != 2 {
+				return object.Errorf("wrong number of arguments. want=1 or 2 got=%d", len(args))
+			}
+			return hp.do("GET", args[0], nil, args[1:]...)
+		}
+	case "post":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 2 && len(args) != 3 {
+				return object.Errorf("wrong number of arguments. want=2 or 3 got=%d", len(args))
+			}
+			return hp.do("POST", args[0], args[1], args[2:]...)
+		}
+	case "request":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 2 && len(args) != 3 {
+				return object.Errorf("wrong number of arguments. want=2 or 3 got=%d", len(args))
+			}
+			method, ok := args[0].(*object.String)
+			if !ok {
+				return object.Errorf("method of request must be string, got %s", args[0].Type())
+			}
+			return hp.do(strings.ToUpper(method.Value), args[1], nil, args[2:]...)
+		}
+	default:
+		return nil
+	}
+}
+
+func (hp *httpPkg) Members() []object.MemberDoc {
+	return []object.MemberDoc{
+		{Name: "get", Signature: "get(url, options?)", Doc: "sends a GET request and returns the http.Response"},
+		{Name: "post", Signature: "post(url, body, options?)", Doc: "sends a POST request with body, maps and arrays are sent in JSON"},
+		{Name: "request", Signature: "request(method, url, options?)", Doc: "sends a request of method, the options are headers, query, body, json and timeout"},
+	}
+}
+
+// httpOptions is the options map of a request, which has
+// "headers", "query", "body", "json" and "timeout".
+type httpOptions struct {
+	header      http.Header
+	query       url.Values
+	body        []byte
+	hasBody     bool
+	contentType string
+	timeout     time.Duration
+}
+
+func (hp *httpPkg) do(method string, urlArg object.Object, body object.Object, opts ...object.Object) object.Object {
+	rawURL, ok := urlArg.(*object.String)
+	if !ok {
+		return object.Errorf("url must be string, got %s", urlArg.Type())
+	}
+	options := httpOptions{header: http.Header{}, query: url.Values{}, timeout: httpDefaultTimeout}
+	if len(opts) > 0 {
+		if err := parseHTTPOptions(opts[0], &options); err != nil {
+			return object.Errorf("%s", err)
+		}
+	}
+	if body != nil {
+		_, isString := body.(*object.String)
+		if err := setHTTPBody(&options, body, !isString); err != nil {
+			return object.Errorf("%s", err)
+		}
+	}
+	if hp.transport == nil {
+		return object.Errorf("%s", errNoTransport)
+	}
+	u, err := url.Parse(rawURL.Value)
+	if err != nil {
+		return object.Errorf("%s", err)
+	}
+	if len(options.query) > 0 {
+		q := u.Query()
+		for k, vs := range options.query {
+			for _, v := range vs {
+				q.Add(k, v)
+			}
+		}
+		u.RawQuery = q.Encode()
+	}
+	var reader io.Reader
+	if options.hasBody {
+		reader = bytes.NewReader(options.body)
+	}
+	req, err := http.NewRequestWithContext(hp.env.Ctx(), method, u.String(), reader)
+	if err != nil {
+		return object.Errorf("%s", err)
+	}
+	for k, vs := range options.header {
+		req.Header[k] = vs
+	}
+	if options.hasBody && req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", options.contentType)
+	}
+	client := &http.Client{Transport: hp.transport, Timeout: options.timeout}
+	rsp, err := client.Do(req)
+	if err != nil {
+		return object.Errorf("%s", err)
+	}
+	defer rsp.Body.Close()
+	b, err := io.ReadAll(io.LimitReader(rsp.Body, hp.maxBodySize+1))
+	if err != nil {
+		return object.Errorf("%s", err)
+	}
+	if int64(len(b)) > hp.maxBodySize {
+		return object.Errorf("response body exceeds %d bytes", hp.maxBodySize)
+	}
+	return &HTTPResponse{
+		StatusCode: rsp.StatusCode,
+		Status:     rsp.Status,
+		Header:     rsp.Header,
+		Body:       b,
+	}
+}
+
+func parseHTTPOptions(obj object.Object, options *httpOptions) error {
+	hm, ok := obj.(*object.HashMap)
+	if !ok {
+		return fmt.Errorf("options must be map, got %s", obj.Type())
+	}
+	for _, pair := range hm.Pairs {
+		key, ok := pair.Key.(*object.String)
+		if !ok {
+			return fmt.Errorf("option name must be string, got %s", pair.Key.Type())
+		}
+		switch key.Value {
+		case "headers", "query":
+			values, ok := pair.Value.(*object.HashMap)
+			if !ok {
+				return fmt.Errorf("option %s must be map, got %s", key.Value, pair.Value.Type())
+			}
+			for _, p := range values.Pairs {
+				name := p.Key.Inspect()
+				if s, ok := p.Key.(*object.String); ok {
+					name = s.Value
+				}
+				value := p.Value.Inspect()
+				if s, ok := p.Value.(*object.String); ok {
+					value = s.Value
+				}
+				if key.Value == "headers" {
+					options.header.Add(name, value)
+				} else {
+					options.query.Add(name, value)
+				}
+			}
+		case "body", "json":
+			_, isString := pair.Value.(*object.String)
+			if err := setHTTPBody(options, pair.Value, key.Value == "json" || !isString); err != nil {
+				return err
+			}
+		case "timeout":
+			d, err := durationArg("timeout", pair.Value)
+			if err != nil {
+				return err
+			}
+			options.timeout = d
+		default:
+			return fmt.Errorf("unknown option %q", key.Value)
+		}
+	}
+	return nil
+}
+
+// setHTTPBody sets the body as it is or in JSON, the Content-Type header
+// follows the body unless it was given explicitly.
+func setHTTPBody(options *httpOptions, body object.Object, asJSON bool) error {
+	contentType := "application/json"
+	if asJSON {
+		b, err := object.EncodeJSON(body)
+		if err != nil {
+			return err
+		}
+		options.body = b
+	} else {
+		options.body = []byte(body.(*object.String).Value)
+		contentType = "text/plain; charset=utf-8"
+	}
+	options.hasBody = true
+	options.contentType = contentType
+	return nil
+}
+
+// durationArg returns the duration of a time.Duration or a string like "1.5s".
+func durationArg(name string, obj object.Object) (time.Duration, error) {
+	switch v := obj.(type) {
+	case *DurationObj:
+		return v.d, nil
+	case *object.String:
+		return time.ParseDuration(v.Value)
+	default:
+		return 0, fmt.Errorf("%s must be time.Duration or string, got %s", name, obj.Type())
+	}
+}
+
+// HTTPResponse is the response of the http package.
+type HTTPResponse struct {
+	StatusCode int
+	Status     string
+	Header     http.Header
+	Body       []byte
+}
+
+var _ object.Object = &HTTPResponse{}
+var _ object.Describer = &HTTPResponse{}
+
+func (r *HTTPResponse) Type() object.ObjectType { return "http.Response" }
+
+func (r *HTTPResponse) Inspect() string { return fmt.Sprintf("http.Response(%s)", r.Status) }
+
+func (r *HTTPResponse) Member(name string) object.MemberFunc {
+	switch name {
+	case "status":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 0 {
+				return errWrongNumberOfArguments(0, len(args))
+			}
+			return &object.Integer{Value: int64(r.StatusCode)}
+		}
+	case "status_text":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 0 {
+				return errWrongNumberOfArguments(0, len(args))
+			}
+			return &object.String{Value: r.Status}
+		}
+	case "ok":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 0 {
+				return errWrongNumberOfArguments(0, len(args))
+			}
+			return &object.Boolean{Value: r.StatusCode >= 200 && r.StatusCode < 300}
+		}
+	case "headers":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 0 {
+				return errWrongNumberOfArguments(0, len(args))
+			}
+			values := make(map[string]object.Object, len(r.Header))
+			for k, vs := range r.Header {
+				values[k] = &object.String{Value: strings.Join(vs, ", ")}
+			}
+			return newHashMap(values)
+		}
+	case "header":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return errWrongNumberOfArguments(1, len(args))
+			}
+			key, ok := args[0].(*object.String)
+			if !ok {
+				return object.Errorf("argument to header must be string, got %s", args[0].Type())
+			}
+			vs := r.Header.Values(key.Value)
+			if len(vs) == 0 {
+				return eval.NULL
+			}
+			return &object.String{Value: strings.Join(vs, ", ")}
+		}
+	case "body":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 0 {
+				return errWrongNumberOfArguments(0, len(args))
+			}
+			return &object.String{Value: string(r.Body)}
+		}
+	case "json":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 0 {
+				return errWrongNumberOfArguments(0, len(args))
+			}
+			ret, err := object.DecodeJSON(r.Body)
+			if err != nil {
+				return object.Errorf("invalid json body: %s", err.Error())
+			}
+			return ret
+		}
+	default:
+		return nil
+	}
+}
+
+func (r *HTTPResponse) Members() []object.MemberDoc {
+	return []object.MemberDoc{
+		{Name: "status", Signature: "status()", Doc: "returns the status code"},
+		{Name: "status_text", Signature: "status_text()", Doc: "returns the status line like \"200 OK\""},
+		{Name: "ok", Signature: "ok()", Doc: "returns true if the status code is 2xx"},
+		{Name: "headers", Signature: "headers()", Doc: "returns the map of the headers"},
+		{Name: "header", Signature: "header(name)", Doc: "returns the values of the header name joined by \", \", or nil"},
+		{Name: "body", Signature: "body()", Doc: "returns the body as a string"},
+		{Name: "json", Signature: "json()", Doc: "decodes the body as JSON"},
+	}
+}
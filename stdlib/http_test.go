@@ -0,0 +1,164 @@
+package stdlib_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/thingsme/thingscript/object"
+	"github.com/thingsme/thingscript/stdlib"
+)
+
+func withTransport(transport http.RoundTripper) func(env *object.Environment) {
+	return func(env *object.Environment) { env.HTTPTransport = transport }
+}
+
+func newHTTPServer(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/echo", func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Method", r.Method)
+		json.NewEncoder(w).Encode(map[string]any{
+			"method":       r.Method,
+			"query":        r.URL.RawQuery,
+			"token":        r.Header.Get("X-Token"),
+			"content_type": r.Header.Get("Content-Type"),
+			"body":         string(b),
+			"count":        3,
+			"ratio":        0.5,
+		})
+	})
+	mux.HandleFunc("/missing", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "not here", http.StatusNotFound)
+	})
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(2 * time.Second):
+		case <-r.Context().Done():
+		}
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestHTTP(t *testing.T) {
+	srv := newHTTPServer(t)
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`http := import("http")
+			rsp := http.get(URL + "/echo")
+			[rsp.status, rsp.ok, rsp.header("x-method"), rsp.json()["method"], rsp.json()["count"]]`,
+			`[200, true, GET, GET, 3]`},
+		{`http := import("http")
+			rsp := http.get(URL + "/echo", {"query": {"q": "a b", "n": 1}, "headers": {"X-Token": "secret"}})
+			[rsp.json()["query"], rsp.json()["token"]]`,
+			`[n=1&q=a+b, secret]`},
+		{`http := import("http")
+			rsp := http.post(URL + "/echo", "hello")
+			[rsp.json()["body"], rsp.json()["content_type"]]`,
+			`[hello, text/plain; charset=utf-8]`},
+		{`http := import("http")
+			rsp := http.post(URL + "/echo", {"temp": 21})
+			[rsp.json()["body"], rsp.json()["content_type"]]`,
+			`[{"temp":21}, application/json]`},
+		{`http := import("http")
+			rsp := http.request("put", URL + "/echo", {"json": [1, "a"], "headers": {"Content-Type": "application/x-test"}})
+			[rsp.json()["method"], rsp.json()["body"], rsp.json()["content_type"]]`,
+			`[PUT, [1,"a"], application/x-test]`},
+		{`http := import("http")
+			rsp := http.get(URL + "/missing")
+			[rsp.status, rsp.ok, rsp.body, rsp.header("X-None")]`,
+			"[404, false, not here\n, null]"},
+		{`http := import("http")
+			http.get(URL + "/echo").headers["Content-Type"]`,
+			`application/json`},
+	}
+	for _, tt := range tests {
+		input := strings.ReplaceAll(tt.input, "URL", `"`+srv.URL+`"`)
+		ret := stdlib.EvalWith(input, withTransport(http.DefaultTransport))
+		if ret == nil || ret.Inspect() != tt.expected {
+			t.Errorf("expected %q, got=%q", tt.expected, ret.Inspect())
+		}
+	}
+}
+
+func TestHTTPErrors(t *testing.T) {
+	srv := newHTTPServer(t)
+	tests := []struct {
+		input     string
+		transport http.RoundTripper
+		expected  string
+	}{
+		{`import("http").get(URL)`, nil, "http transport is not available"},
+		{`import("http").get(1)`, http.DefaultTransport, "url must be string, got INTEGER"},
+		{`import("http").get(URL, 1)`, http.DefaultTransport, "options must be map, got INTEGER"},
+		{`import("http").get(URL, {"retry": 1})`, http.DefaultTransport, `unknown option "retry"`},
+		{`import("http").get(URL, {"timeout": 1})`, http.DefaultTransport, "timeout must be time.Duration or string, got INTEGER"},
+		{`import("http").post(URL)`, http.DefaultTransport, "wrong number of arguments. want=2 or 3 got=1"},
+		{`import("http").get(URL + "/missing").json()`, http.DefaultTransport, "invalid json body: invalid character 'o' in literal null (expecting 'u')"},
+	}
+	for _, tt := range tests {
+		input := strings.ReplaceAll(tt.input, "URL", `"`+srv.URL+`"`)
+		ret := stdlib.EvalWith(input, withTransport(tt.transport))
+		if err, ok := ret.(*object.Error); !ok || err.Message != tt.expected {
+			t.Errorf("expected error %q, got=%v", tt.expected, ret)
+		}
+	}
+}
+
+func TestHTTPMaxBodySize(t *testing.T) {
+	srv := newHTTPServer(t)
+	input := `import("http").get("` + srv.URL + `/echo").body.length`
+	ret := stdlib.EvalWith(input, func(env *object.Environment) {
+		env.HTTPTransport = http.DefaultTransport
+		env.HTTPMaxBodySize = 16
+	})
+	if err, ok := ret.(*object.Error); !ok || err.Message != "response body exceeds 16 bytes" {
+		t.Errorf("expected size error, got=%v", ret)
+	}
+	ret = stdlib.EvalWith(input, withTransport(http.DefaultTransport))
+	if ret == nil || ret.Type() != object.INTEGER_OBJ {
+		t.Errorf("expected the body within the default limit, got=%v", ret)
+	}
+}
+
+func TestHTTPTimeout(t *testing.T) {
+	srv := newHTTPServer(t)
+	for _, timeout := range []string{`"50ms"`, `import("time").milliseconds(50)`} {
+		input := `import("http").get(URL + "/slow", {"timeout": ` + timeout + `})`
+		ret := stdlib.EvalWith(strings.ReplaceAll(input, "URL", `"`+srv.URL+`"`), withTransport(http.DefaultTransport))
+		if err, ok := ret.(*object.Error); !ok || !strings.Contains(err.Message, "Client.Timeout exceeded") {
+			t.Errorf("expected timeout error, got=%v", ret)
+		}
+	}
+}
+
+type denyTransport struct{}
+
+func (denyTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	return &http.Response{
+		StatusCode: http.StatusForbidden,
+		Status:     "403 Forbidden",
+		Header:     http.Header{},
+		Body:       io.NopCloser(strings.NewReader("denied " + r.URL.Host)),
+		Request:    r,
+	}, nil
+}
+
+func TestHTTPTransport(t *testing.T) {
+	ret := stdlib.EvalWith(`
+		rsp := import("http").get("http://example.com/")
+		[rsp.status, rsp.body]
+	`, withTransport(denyTransport{}))
+	if ret.Inspect() != "[403, denied example.com]" {
+		t.Errorf("expected response from the transport, got=%s", ret.Inspect())
+	}
+}
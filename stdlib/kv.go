@@ -0,0 +1,256 @@
+package stdlib
+
+import (
+	"bytes"
+	"errors"
+	"time"
+
+	"github.com/thingsme/thingscript/eval"
+	"github.com/thingsme/thingscript/object"
+)
+
+// kvPkg keeps the values in Environment.KV, the values are encoded in JSON.
+type kvPkg struct {
+	env   *object.Environment
+	store object.KVStore
+}
+
+var _ object.Package = &kvPkg{}
+var _ object.Describer = &kvPkg{}
+
+var errNoKVStore = errors.New("kv store is not available")
+
+func (kp *kvPkg) Type() object.ObjectType { return object.PACKAGE_OBJ }
+
+func (kp *kvPkg) Inspect() string { return "package kv" }
+
+func (kp *kvPkg) Name() string { return "kv" }
+
+func (kp *kvPkg) OnLoad(env *object.Environment) {
+	kp.env = env
+	kp.store = env.KV
+}
+
+func (kp *kvPkg) Member(name string) object.MemberFunc {
+	switch name {
+	case "get":
+		// get(key, default)
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 1 && len(args) != 2 {
+				return object.Errorf("wrong number of arguments. want=1 or 2 got=%d", len(args))
+			}
+			key, errObj := kp.keyArg(name, args[0])
+			if errObj != nil {
+				return errObj
+			}
+			b, ok, err := kp.store.Get(kp.env.Ctx(), key)
+			if err != nil {
+				return object.Errorf("%s", err)
+			}
+			if !ok {
+				if len(args) == 2 {
+					return args[1]
+				}
+				return eval.NULL
+			}
+			return decodeKV(b)
+		}
+	case "set":
+		// set(key, value, ttl)
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 2 && len(args) != 3 {
+				return object.Errorf("wrong number of arguments. want=2 or 3 got=%d", len(args))
+			}
+			key, errObj := kp.keyArg(name, args[0])
+			if errObj != nil {
+				return errObj
+			}
+			b, err := object.EncodeJSON(args[1])
+			if err != nil {
+				return object.Errorf("%s", err)
+			}
+			ttl, errObj := ttlArg(args[2:])
+			if errObj != nil {
+				return errObj
+			}
+			if err := kp.store.Set(kp.env.Ctx(), key, b, ttl); err != nil {
+				return object.Errorf("%s", err)
+			}
+			return nil
+		}
+	case "delete":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return errWrongNumberOfArguments(1, len(args))
+			}
+			key, errObj := kp.keyArg(name, args[0])
+			if errObj != nil {
+				return errObj
+			}
+			ok, err := kp.store.Delete(kp.env.Ctx(), key)
+			if err != nil {
+				return object.Errorf("%s", err)
+			}
+			return &object.Boolean{Value: ok}
+		}
+	case "keys":
+		// keys(prefix)
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) > 1 {
+				return object.Errorf("wrong number of arguments. want=0 or 1 got=%d", len(args))
+			}
+			if kp.store == nil {
+				return object.Errorf("%s", errNoKVStore)
+			}
+			prefix := ""
+			if len(args) == 1 {
+				s, ok := args[0].(*object.String)
+				if !ok {
+					return object.Errorf("prefix of keys must be string, got %s", args[0].Type())
+				}
+				prefix = s.Value
+			}
+			keys, err := kp.store.Keys(kp.env.Ctx(), prefix)
+			if err != nil {
+				return object.Errorf("%s", err)
+			}
+			elements := make([]object.Object, len(keys))
+			for i, k := range keys {
+				elements[i] = &object.String{Value: k}
+			}
+			return &object.Array{Elements: elements}
+		}
+	case "compare_and_set":
+		// compare_and_set(key, old, new, ttl), old is nil if the key must not exist
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 3 && len(args) != 4 {
+				return object.Errorf("wrong number of arguments. want=3 or 4 got=%d", len(args))
+			}
+			key, errObj := kp.keyArg(name, args[0])
+			if errObj != nil {
+				return errObj
+			}
+			var old []byte
+			if args[1].Type() != object.NULL_OBJ {
+				b, err := object.EncodeJSON(args[1])
+				if err != nil {
+					return object.Errorf("%s", err)
+				}
+				old = b
+			}
+			b, err := object.EncodeJSON(args[2])
+			if err != nil {
+				return object.Errorf("%s", err)
+			}
+			ttl, errObj := ttlArg(args[3:])
+			if errObj != nil {
+				return errObj
+			}
+			ok, err := kp.store.CompareAndSet(kp.env.Ctx(), key, old, b, ttl)
+			if err != nil {
+				return object.Errorf("%s", err)
+			}
+			return &object.Boolean{Value: ok}
+		}
+	case "increment":
+		// increment(key, delta, ttl) returns the incremented value, a missing key starts from 0
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) < 1 || len(args) > 3 {
+				return object.Errorf("wrong number of arguments. want=1 to 3 got=%d", len(args))
+			}
+			key, errObj := kp.keyArg(name, args[0])
+			if errObj != nil {
+				return errObj
+			}
+			delta := int64(1)
+			if len(args) >= 2 {
+				n, ok := args[1].(*object.Integer)
+				if !ok {
+					return object.Errorf("delta of increment must be int, got %s", args[1].Type())
+				}
+				delta = n.Value
+			}
+			ttl, errObj := ttlArg(args[min(len(args), 2):])
+			if errObj != nil {
+				return errObj
+			}
+			return kp.increment(key, delta, ttl)
+		}
+	default:
+		return nil
+	}
+}
+
+func (kp *kvPkg) Members() []object.MemberDoc {
+	return []object.MemberDoc{
+		{Name: "get", Signature: "get(key, default?)", Doc: "returns the value of key, or default if it is not found"},
+		{Name: "set", Signature: "set(key, value, ttl?)", Doc: "sets value to key, which expires after ttl"},
+		{Name: "delete", Signature: "delete(key)", Doc: "deletes key and returns true if it existed"},
+		{Name: "keys", Signature: "keys(prefix?)", Doc: "returns the sorted keys with prefix"},
+		{Name: "increment", Signature: "increment(key, delta?, ttl?)", Doc: "adds delta, which defaults to 1, to the int of key atomically and returns the result"},
+		{Name: "compare_and_set", Signature: "compare_and_set(key, old, value, ttl?)", Doc: "sets value if the value of key is old, or key is absent if old is nil, returns true if set"},
+	}
+}
+
+// keyArg checks the store and returns the key.
+func (kp *kvPkg) keyArg(name string, arg object.Object) (string, *object.Error) {
+	if kp.store == nil {
+		return "", object.Errorf("%s", errNoKVStore)
+	}
+	s, ok := arg.(*object.String)
+	if !ok {
+		return "", object.Errorf("key of %s must be string, got %s", name, arg.Type())
+	}
+	return s.Value, nil
+}
+
+// increment adds delta to the value of key with compare-and-set,
+// it retries while the value is changed by the others.
+func (kp *kvPkg) increment(key string, delta int64, ttl time.Duration) object.Object {
+	ctx := kp.env.Ctx()
+	for {
+		old, ok, err := kp.store.Get(ctx, key)
+		if err != nil {
+			return object.Errorf("%s", err)
+		}
+		n := int64(0)
+		if ok {
+			v, isInt := decodeKV(old).(*object.Integer)
+			if !isInt {
+				return object.Errorf("value of %q is not int", key)
+			}
+			n = v.Value
+		}
+		ret := &object.Integer{Value: n + delta}
+		b, _ := object.EncodeJSON(ret)
+		set, err := kp.store.CompareAndSet(ctx, key, old, b, ttl)
+		if err != nil {
+			return object.Errorf("%s", err)
+		}
+		if set {
+			return ret
+		}
+		if err := ctx.Err(); err != nil {
+			return object.Errorf("evaluation stopped: %s", err)
+		}
+	}
+}
+
+func ttlArg(args []object.Object) (time.Duration, *object.Error) {
+	if len(args) == 0 {
+		return 0, nil
+	}
+	d, err := durationArg("ttl", args[0])
+	if err != nil {
+		return 0, object.Errorf("%s", err)
+	}
+	return d, nil
+}
+
+func decodeKV(b []byte) object.Object {
+	obj, err := object.DecodeJSON(bytes.TrimSpace(b))
+	if err != nil {
+		return object.Errorf("invalid value in kv store: %s", err.Error())
+	}
+	return obj
+}
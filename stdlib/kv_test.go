@@ -0,0 +1,74 @@
+package stdlib_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/thingsme/thingscript/kv"
+	"github.com/thingsme/thingscript/object"
+	"github.com/thingsme/thingscript/stdlib"
+)
+
+func TestKV(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`kv := import("kv"); kv.set("a", 1); kv.get("a")`, `1`},
+		{`kv := import("kv"); kv.get("missing")`, `null`},
+		{`kv := import("kv"); kv.get("missing", 0)`, `0`},
+		{`kv := import("kv"); kv.set("m", {"name": "pump", "on": true, "level": 0.5}); kv.get("m")["name"]`, `pump`},
+		{`kv := import("kv"); kv.set("l", [1, "two", nil]); kv.get("l")`, `[1, two, null]`},
+		{`kv := import("kv"); kv.set("a", 1); kv.delete("a")`, `true`},
+		{`kv := import("kv"); kv.delete("a")`, `false`},
+		{`kv := import("kv"); kv.set("s/b", 1); kv.set("s/a", 2); kv.set("t", 3); kv.keys("s/")`, `[s/a, s/b]`},
+		{`kv := import("kv"); kv.set("b", 1); kv.set("a", 2); kv.keys()`, `[a, b]`},
+		{`kv := import("kv"); kv.increment("n"); kv.increment("n", 5)`, `6`},
+		{`kv := import("kv"); kv.set("n", "x"); kv.increment("n")`, `ERROR: value of "n" is not int`},
+		{`kv := import("kv"); kv.compare_and_set("c", nil, 1)`, `true`},
+		{`kv := import("kv"); kv.set("c", 1); kv.compare_and_set("c", nil, 2)`, `false`},
+		{`kv := import("kv"); kv.set("c", 1); kv.compare_and_set("c", 1, 2); kv.get("c")`, `2`},
+		{`kv := import("kv"); kv.set("c", 1); kv.compare_and_set("c", 3, 2)`, `false`},
+		{`kv := import("kv"); kv.set(1, 1)`, `ERROR: key of set must be string, got INTEGER`},
+		{`kv := import("kv"); kv.set("a", 1, 10)`, `ERROR: ttl must be time.Duration or string, got INTEGER`},
+		{`kv := import("kv"); kv.get()`, `ERROR: wrong number of arguments. want=1 or 2 got=0`},
+	}
+	for _, tt := range tests {
+		ret := stdlib.EvalWith(tt.input, func(env *object.Environment) {
+			env.KV = kv.NewMemoryStore()
+		})
+		if ret == nil || ret.Inspect() != tt.expected {
+			t.Errorf("expected %s, got=%v <= %s", tt.expected, ret, tt.input)
+		}
+	}
+}
+
+func TestKVTTL(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	store := kv.NewMemoryStore()
+	store.Now = func() time.Time { return now }
+	setup := func(env *object.Environment) { env.KV = store }
+
+	stdlib.EvalWith(`kv := import("kv"); kv.set("session", "abc", "1m"); kv.increment("hits", 1, "30s")`, setup)
+	ret := stdlib.EvalWith(`import("kv").keys()`, setup)
+	if ret.Inspect() != `[hits, session]` {
+		t.Fatalf("expected [hits, session], got=%s", ret.Inspect())
+	}
+	now = now.Add(45 * time.Second)
+	ret = stdlib.EvalWith(`import("kv").keys()`, setup)
+	if ret.Inspect() != `[session]` {
+		t.Fatalf("expected [session], got=%s", ret.Inspect())
+	}
+	now = now.Add(time.Minute)
+	ret = stdlib.EvalWith(`import("kv").get("session", "expired")`, setup)
+	if ret.Inspect() != `expired` {
+		t.Fatalf("expected expired, got=%s", ret.Inspect())
+	}
+}
+
+func TestKVNotAvailable(t *testing.T) {
+	ret := stdlib.EvalWith(`import("kv").get("a")`, nil)
+	if ret == nil || ret.Inspect() != `ERROR: kv store is not available` {
+		t.Errorf("expected not available error, got=%v", ret)
+	}
+}
@@ -0,0 +1,197 @@
+package stdlib
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/thingsme/thingscript/object"
+	"github.com/thingsme/thingscript/token"
+)
+
+// logPkg writes the records to Environment.LogHandler,
+// the records have the script name and the line of the call site.
+type logPkg struct {
+	logger *Logger
+}
+
+var _ object.Package = &logPkg{}
+var _ object.Describer = &logPkg{}
+var _ object.CallSiteMember = &logPkg{}
+
+var logLevels = map[string]slog.Level{
+	"debug": slog.LevelDebug,
+	"info":  slog.LevelInfo,
+	"warn":  slog.LevelWarn,
+	"error": slog.LevelError,
+}
+
+func (lp *logPkg) Type() object.ObjectType { return object.PACKAGE_OBJ }
+
+func (lp *logPkg) Inspect() string { return "package log" }
+
+func (lp *logPkg) Name() string { return "log" }
+
+func (lp *logPkg) OnLoad(env *object.Environment) {
+	lg := &Logger{script: env.ScriptName, ctx: env.Ctx}
+	if env.LogHandler != nil {
+		lg.handler = env.LogHandler
+	} else {
+		lg.handler = slog.Default().Handler()
+	}
+	if env.TimeProvider != nil {
+		lg.now = env.TimeProvider
+	} else {
+		lg.now = time.Now
+	}
+	lp.logger = lg
+}
+
+func (lp *logPkg) Member(name string) object.MemberFunc {
+	return lp.logger.Member(name)
+}
+
+func (lp *logPkg) Members() []object.MemberDoc {
+	return loggerMembers
+}
+
+func (lp *logPkg) MemberAt(name string, pos token.Position) object.MemberFunc {
+	return lp.logger.MemberAt(name, pos)
+}
+
+// Logger is the logger which has the fields given to log.with().
+type Logger struct {
+	handler slog.Handler
+	script  string
+	now     func() time.Time
+	ctx     func() context.Context
+}
+
+var _ object.CallSiteMember = &Logger{}
+var _ object.Describer = &Logger{}
+
+func (lg *Logger) Type() object.ObjectType { return "log.Logger" }
+
+func (lg *Logger) Inspect() string { return "log.Logger" }
+
+func (lg *Logger) Member(name string) object.MemberFunc {
+	return lg.MemberAt(name, token.Position{})
+}
+
+func (lg *Logger) MemberAt(name string, pos token.Position) object.MemberFunc {
+	switch name {
+	case "debug", "info", "warn", "error":
+		level := logLevels[name]
+		// info(msg, key, value, ...) or info(msg, fields)
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) == 0 {
+				return object.Errorf("wrong number of arguments. got=%d, want >= 1", len(args))
+			}
+			msg, ok := args[0].(*object.String)
+			if !ok {
+				return object.Errorf("message of %s must be string, got %s", name, args[0].Type())
+			}
+			attrs, errObj := logAttrs(args[1:])
+			if errObj != nil {
+				return errObj
+			}
+			ctx := lg.ctx()
+			if !lg.handler.Enabled(ctx, level) {
+				return nil
+			}
+			r := slog.NewRecord(lg.now(), level, msg.Value, 0)
+			if lg.script != "" {
+				r.AddAttrs(slog.String("script", lg.script))
+			}
+			if pos.Line > 0 {
+				r.AddAttrs(slog.Int("line", pos.Line))
+			}
+			r.AddAttrs(attrs...)
+			if err := lg.handler.Handle(ctx, r); err != nil {
+				return object.Errorf("%s", err)
+			}
+			return nil
+		}
+	case "with":
+		// with(key, value, ...) or with(fields)
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			attrs, errObj := logAttrs(args)
+			if errObj != nil {
+				return errObj
+			}
+			ret := *lg
+			ret.handler = lg.handler.WithAttrs(attrs)
+			return &ret
+		}
+	case "enabled":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return errWrongNumberOfArguments(1, len(args))
+			}
+			s, ok := args[0].(*object.String)
+			if !ok {
+				return object.Errorf("argument to enabled must be string, got %s", args[0].Type())
+			}
+			level, ok := logLevels[s.Value]
+			if !ok {
+				return object.Errorf("unknown level %q", s.Value)
+			}
+			return &object.Boolean{Value: lg.handler.Enabled(lg.ctx(), level)}
+		}
+	default:
+		return nil
+	}
+}
+
+func (lg *Logger) Members() []object.MemberDoc {
+	return loggerMembers
+}
+
+var loggerMembers = []object.MemberDoc{
+	{Name: "debug", Signature: "debug(msg, fields...)", Doc: "writes a debug record with the key-value pairs or the map of fields"},
+	{Name: "info", Signature: "info(msg, fields...)", Doc: "writes an info record with the key-value pairs or the map of fields"},
+	{Name: "warn", Signature: "warn(msg, fields...)", Doc: "writes a warn record with the key-value pairs or the map of fields"},
+	{Name: "error", Signature: "error(msg, fields...)", Doc: "writes an error record with the key-value pairs or the map of fields"},
+	{Name: "with", Signature: "with(fields...)", Doc: "returns the log.Logger adding the fields to the records"},
+	{Name: "enabled", Signature: "enabled(level)", Doc: "returns true if the records of level are written"},
+}
+
+// logAttrs converts the key-value pairs or a map of fields into attributes.
+func logAttrs(args []object.Object) ([]slog.Attr, *object.Error) {
+	if len(args) == 1 {
+		hm, ok := args[0].(*object.HashMap)
+		if !ok {
+			return nil, object.Errorf("fields must be map or key-value pairs, got %s", args[0].Type())
+		}
+		pairs := hm.OrderedPairs()
+		if hm.Keys == nil {
+			sort.Slice(pairs, func(i, j int) bool { return pairs[i].Key.Inspect() < pairs[j].Key.Inspect() })
+		}
+		attrs := make([]slog.Attr, len(pairs))
+		for i, pair := range pairs {
+			attrs[i] = logAttr(pair.Key.Inspect(), pair.Value)
+		}
+		return attrs, nil
+	}
+	if len(args)%2 != 0 {
+		return nil, object.Errorf("fields must be key-value pairs, got %d arguments", len(args))
+	}
+	attrs := make([]slog.Attr, 0, len(args)/2)
+	for i := 0; i < len(args); i += 2 {
+		key, ok := args[i].(*object.String)
+		if !ok {
+			return nil, object.Errorf("field key must be string, got %s", args[i].Type())
+		}
+		attrs = append(attrs, logAttr(key.Value, args[i+1]))
+	}
+	return attrs, nil
+}
+
+func logAttr(key string, value object.Object) slog.Attr {
+	v, err := object.ToNative(value)
+	if err != nil {
+		v = value.Inspect()
+	}
+	return slog.Any(key, v)
+}
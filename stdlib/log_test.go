@@ -0,0 +1,100 @@
+package stdlib_test
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/thingsme/thingscript/object"
+	"github.com/thingsme/thingscript/stdlib"
+)
+
+func evalLog(input string) (object.Object, string) {
+	var buf bytes.Buffer
+	ret := stdlib.EvalWith(input, func(env *object.Environment) {
+		env.ScriptName = "test.txs"
+		env.LogHandler = slog.NewTextHandler(&buf, &slog.HandlerOptions{
+			Level: slog.LevelInfo,
+			ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+				if a.Key == slog.TimeKey {
+					return slog.Attr{}
+				}
+				return a
+			},
+		})
+	})
+	return ret, buf.String()
+}
+
+func TestLog(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`import("log").info("hello")`, `level=INFO msg=hello script=test.txs line=1`},
+		{`log := import("log")
+
+			log.warn("hot", "device", "t1", "temp", 21.5, "ok", false)`,
+			`level=WARN msg=hot script=test.txs line=3 device=t1 temp=21.5 ok=false`},
+		{`import("log").error("failed", {"b": nil, "a": [1, 2]})`, `level=ERROR msg=failed script=test.txs line=1 a="[1 2]" b=<nil>`},
+		{`import("log").debug("hidden")`, ``},
+		{`log := import("log")
+			dev := log.with("device", "t1")
+			dev.info("started")
+			dev.with({"n": 1}).info("nested")`,
+			"level=INFO msg=started device=t1 script=test.txs line=3\nlevel=INFO msg=nested device=t1 n=1 script=test.txs line=4"},
+		{`log := import("log")
+			func report(msg) {
+				log.info(msg)
+			}
+			report("in function")`, `level=INFO msg="in function" script=test.txs line=3`},
+	}
+	for _, tt := range tests {
+		ret, out := evalLog(tt.input)
+		if err, ok := ret.(*object.Error); ok {
+			t.Errorf("unexpected error %s <= %s", err.Message, tt.input)
+			continue
+		}
+		if strings.TrimSpace(out) != tt.expected {
+			t.Errorf("expected %q, got=%q", tt.expected, out)
+		}
+	}
+}
+
+func TestLogEnabled(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{`import("log").enabled("debug")`, false},
+		{`import("log").enabled("info")`, true},
+		{`import("log").with("a", 1).enabled("error")`, true},
+	}
+	for _, tt := range tests {
+		ret, _ := evalLog(tt.input)
+		if b, ok := ret.(*object.Boolean); !ok || b.Value != tt.expected {
+			t.Errorf("expected %t, got=%v <= %s", tt.expected, ret, tt.input)
+		}
+	}
+}
+
+func TestLogErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`import("log").info()`, "wrong number of arguments. got=0, want >= 1"},
+		{`import("log").info(1)`, "message of info must be string, got INTEGER"},
+		{`import("log").info("a", "b")`, "fields must be map or key-value pairs, got STRING"},
+		{`import("log").info("a", "b", 1, "c")`, "fields must be key-value pairs, got 3 arguments"},
+		{`import("log").info("a", 1, 2)`, "field key must be string, got INTEGER"},
+		{`import("log").enabled("trace")`, `unknown level "trace"`},
+	}
+	for _, tt := range tests {
+		ret, _ := evalLog(tt.input)
+		if err, ok := ret.(*object.Error); !ok || err.Message != tt.expected {
+			t.Errorf("expected error %q, got=%v", tt.expected, ret)
+		}
+	}
+}
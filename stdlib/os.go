@@ -0,0 +1,119 @@
+package stdlib
+
+import (
+	"sort"
+
+	"github.com/thingsme/thingscript/eval"
+	"github.com/thingsme/thingscript/object"
+)
+
+// osPkg exposes the arguments, the environment variables and the host name
+// which the host put in the Environment, nothing is read from the OS.
+type osPkg struct {
+	env *object.Environment
+}
+
+var _ object.Package = &osPkg{}
+var _ object.Describer = &osPkg{}
+
+func (op *osPkg) Type() object.ObjectType { return object.PACKAGE_OBJ }
+
+func (op *osPkg) Inspect() string { return "package os" }
+
+func (op *osPkg) Name() string { return "os" }
+
+func (op *osPkg) OnLoad(env *object.Environment) {
+	op.env = env
+}
+
+func (op *osPkg) Member(name string) object.MemberFunc {
+	switch name {
+	case "args":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 0 {
+				return errWrongNumberOfArguments(0, len(args))
+			}
+			elements := make([]object.Object, len(op.env.Args))
+			for i, a := range op.env.Args {
+				elements[i] = &object.String{Value: a}
+			}
+			return &object.Array{Elements: elements}
+		}
+	case "getenv":
+		// getenv(name, default)
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 1 && len(args) != 2 {
+				return object.Errorf("wrong number of arguments. want=1 or 2 got=%d", len(args))
+			}
+			key, ok := args[0].(*object.String)
+			if !ok {
+				return object.Errorf("argument to getenv must be string, got %s", args[0].Type())
+			}
+			if v, ok := op.env.EnvVars[key.Value]; ok {
+				return &object.String{Value: v}
+			}
+			if len(args) == 2 {
+				return args[1]
+			}
+			return eval.NULL
+		}
+	case "environ":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 0 {
+				return errWrongNumberOfArguments(0, len(args))
+			}
+			keys := make([]string, 0, len(op.env.EnvVars))
+			for k := range op.env.EnvVars {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			ret := object.NewOrderedHashMap()
+			for _, k := range keys {
+				ret.Set(&object.String{Value: k}, &object.String{Value: op.env.EnvVars[k]})
+			}
+			return ret
+		}
+	case "hostname":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 0 {
+				return errWrongNumberOfArguments(0, len(args))
+			}
+			return &object.String{Value: op.env.Hostname}
+		}
+	case "set_exit_code":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return errWrongNumberOfArguments(1, len(args))
+			}
+			code, ok := args[0].(*object.Integer)
+			if !ok {
+				return object.Errorf("exit code must be int, got %s", args[0].Type())
+			}
+			if code.Value < 0 || code.Value > 125 {
+				return object.Errorf("exit code must be between 0 and 125, got %d", code.Value)
+			}
+			op.env.SetExitCode(int(code.Value))
+			return nil
+		}
+	case "exit_code":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 0 {
+				return errWrongNumberOfArguments(0, len(args))
+			}
+			return &object.Integer{Value: int64(op.env.ExitCode())}
+		}
+	default:
+		return nil
+	}
+}
+
+func (op *osPkg) Members() []object.MemberDoc {
+	return []object.MemberDoc{
+		{Name: "args", Signature: "args()", Doc: "returns the arguments of Environment.Args"},
+		{Name: "getenv", Signature: "getenv(name, default?)", Doc: "returns the variable of Environment.EnvVars, or default if it is not set"},
+		{Name: "environ", Signature: "environ()", Doc: "returns the map of Environment.EnvVars"},
+		{Name: "hostname", Signature: "hostname()", Doc: "returns Environment.Hostname"},
+		{Name: "set_exit_code", Signature: "set_exit_code(code)", Doc: "sets the exit code of the environment, from 0 to 125"},
+		{Name: "exit_code", Signature: "exit_code()", Doc: "returns the exit code of the environment"},
+	}
+}
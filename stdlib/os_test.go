@@ -0,0 +1,87 @@
+package stdlib_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/thingsme/thingscript/object"
+	"github.com/thingsme/thingscript/stdlib"
+)
+
+func TestOS(t *testing.T) {
+	setup := func(env *object.Environment) {
+		env.Args = []string{"-n", "3"}
+		env.EnvVars = map[string]string{"HOME": "/home/things", "LANG": "C"}
+		env.Hostname = "gateway-1"
+	}
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`import("os").args()`, `[-n, 3]`},
+		{`import("os").getenv("HOME")`, `/home/things`},
+		{`import("os").getenv("PATH")`, `null`},
+		{`import("os").getenv("PATH", "/bin")`, `/bin`},
+		{`import("os").environ()`, `{HOME: /home/things, LANG: C}`},
+		{`import("os").hostname()`, `gateway-1`},
+		{`os := import("os"); os.set_exit_code(2); os.exit_code()`, `2`},
+	}
+	for _, tt := range tests {
+		ret := stdlib.EvalWith(tt.input, setup)
+		if ret == nil || ret.Inspect() != tt.expected {
+			t.Errorf("expected %s, got=%v <= %s", tt.expected, ret, tt.input)
+		}
+	}
+}
+
+func TestOSNotExposed(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`import("os").args()`, `[]`},
+		{`import("os").getenv("HOME")`, `null`},
+		{`import("os").environ()`, `{}`},
+		{`import("os").hostname()`, ``},
+		{`import("os").exit_code()`, `0`},
+	}
+	for _, tt := range tests {
+		ret := stdlib.EvalWith(tt.input, nil)
+		if ret == nil || ret.Inspect() != tt.expected {
+			t.Errorf("expected %s, got=%v <= %s", tt.expected, ret, tt.input)
+		}
+	}
+}
+
+func TestOSExitCode(t *testing.T) {
+	var env *object.Environment
+	stdlib.EvalWith(`import("os").set_exit_code(4)`, func(e *object.Environment) { env = e })
+	if env.ExitCode() != 4 {
+		t.Errorf("expected exit code 4, got=%d", env.ExitCode())
+	}
+
+	// the host reads the exit code while the function run by go() sets it
+	stdlib.EvalWith(`os := import("os"); go(func() { os.set_exit_code(5) })`, func(e *object.Environment) { env = e })
+	for deadline := time.Now().Add(time.Second); env.ExitCode() != 5 && time.Now().Before(deadline); {
+		time.Sleep(time.Millisecond)
+	}
+	if env.ExitCode() != 5 {
+		t.Errorf("expected exit code 5, got=%d", env.ExitCode())
+	}
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`import("os").set_exit_code(126)`, "exit code must be between 0 and 125, got 126"},
+		{`import("os").set_exit_code("1")`, "exit code must be int, got STRING"},
+		{`import("os").getenv(1)`, "argument to getenv must be string, got INTEGER"},
+		{`import("os").args(1)`, "wrong number of arguments. want=0 got=1"},
+	}
+	for _, tt := range tests {
+		ret := stdlib.EvalWith(tt.input, nil)
+		if err, ok := ret.(*object.Error); !ok || err.Message != tt.expected {
+			t.Errorf("expected error %q, got=%v", tt.expected, ret)
+		}
+	}
+}
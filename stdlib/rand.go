@@ -0,0 +1,176 @@
+package stdlib
+
+import (
+	crand "crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/rand"
+	"sync"
+
+	"github.com/thingsme/thingscript/eval"
+	"github.com/thingsme/thingscript/object"
+)
+
+type randPkg struct {
+	mu  sync.Mutex
+	rnd *rand.Rand
+}
+
+var _ object.Package = &randPkg{}
+var _ object.Describer = &randPkg{}
+
+func (rp *randPkg) Type() object.ObjectType { return object.PACKAGE_OBJ }
+
+func (rp *randPkg) Inspect() string { return "package rand" }
+
+func (rp *randPkg) Name() string { return "rand" }
+
+func (rp *randPkg) OnLoad(env *object.Environment) {
+	if env.RandSource != nil {
+		rp.rnd = rand.New(env.RandSource)
+	} else {
+		rp.rnd = rand.New(CryptoSource())
+	}
+}
+
+func (rp *randPkg) Member(name string) object.MemberFunc {
+	switch name {
+	case "int":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) > 2 {
+				return object.Errorf("wrong number of arguments. want=0, 1 or 2 got=%d", len(args))
+			}
+			bounds := make([]int64, len(args))
+			for i, a := range args {
+				v, ok := a.(*object.Integer)
+				if !ok {
+					return object.Errorf("argument to int must be int, got %s", a.Type())
+				}
+				bounds[i] = v.Value
+			}
+			rp.mu.Lock()
+			defer rp.mu.Unlock()
+			switch len(bounds) {
+			case 0:
+				return &object.Integer{Value: rp.rnd.Int63()}
+			case 1:
+				if bounds[0] <= 0 {
+					return object.Errorf("invalid argument to int, %d <= 0", bounds[0])
+				}
+				return &object.Integer{Value: rp.rnd.Int63n(bounds[0])}
+			default:
+				if bounds[0] >= bounds[1] {
+					return object.Errorf("invalid arguments to int, %d >= %d", bounds[0], bounds[1])
+				}
+				return &object.Integer{Value: randRange(rp.rnd, bounds[0], bounds[1])}
+			}
+		}
+	case "float":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 0 {
+				return errWrongNumberOfArguments(0, len(args))
+			}
+			rp.mu.Lock()
+			defer rp.mu.Unlock()
+			return &object.Float{Value: rp.rnd.Float64()}
+		}
+	case "choice":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return errWrongNumberOfArguments(1, len(args))
+			}
+			arr, ok := args[0].(*object.Array)
+			if !ok {
+				return object.Errorf("argument to choice must be array, got %s", args[0].Type())
+			}
+			if len(arr.Elements) == 0 {
+				return eval.NULL
+			}
+			rp.mu.Lock()
+			defer rp.mu.Unlock()
+			return arr.Elements[rp.rnd.Intn(len(arr.Elements))]
+		}
+	case "shuffle":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return errWrongNumberOfArguments(1, len(args))
+			}
+			arr, ok := args[0].(*object.Array)
+			if !ok {
+				return object.Errorf("argument to shuffle must be array, got %s", args[0].Type())
+			}
+			newElements := make([]object.Object, len(arr.Elements))
+			copy(newElements, arr.Elements)
+			rp.mu.Lock()
+			defer rp.mu.Unlock()
+			rp.rnd.Shuffle(len(newElements), func(i, j int) {
+				newElements[i], newElements[j] = newElements[j], newElements[i]
+			})
+			return &object.Array{Elements: newElements}
+		}
+	case "uuid":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 0 {
+				return errWrongNumberOfArguments(0, len(args))
+			}
+			var b [16]byte
+			rp.mu.Lock()
+			binary.BigEndian.PutUint64(b[0:8], rp.rnd.Uint64())
+			binary.BigEndian.PutUint64(b[8:16], rp.rnd.Uint64())
+			rp.mu.Unlock()
+			b[6] = (b[6] & 0x0f) | 0x40 // version 4
+			b[8] = (b[8] & 0x3f) | 0x80 // variant 10
+			return &object.String{Value: fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])}
+		}
+	default:
+		return nil
+	}
+}
+
+// randRange returns a random int in [lo, hi), the range may be wider than math.MaxInt64.
+func randRange(rnd *rand.Rand, lo, hi int64) int64 {
+	span := uint64(hi) - uint64(lo)
+	if span <= math.MaxInt64 {
+		return lo + rnd.Int63n(int64(span))
+	}
+	// more than a half of the values of Uint64 are in the span, so it rarely retries
+	for {
+		if v := rnd.Uint64(); v < span {
+			return int64(uint64(lo) + v)
+		}
+	}
+}
+
+func (rp *randPkg) Members() []object.MemberDoc {
+	return []object.MemberDoc{
+		{Name: "int", Signature: "int(min?, max?)", Doc: "returns a random int in [min, max), [0, max) or a non-negative int"},
+		{Name: "float", Signature: "float()", Doc: "returns a random float in [0.0, 1.0)"},
+		{Name: "choice", Signature: "choice(array)", Doc: "returns a random element of array"},
+		{Name: "shuffle", Signature: "shuffle(array)", Doc: "returns a shuffled copy of array"},
+		{Name: "uuid", Signature: "uuid()", Doc: "returns a random UUID version 4"},
+	}
+}
+
+// CryptoSource returns a rand.Source backed by crypto/rand,
+// which is the default source of the rand package.
+// Seed is ignored since the source is not deterministic.
+func CryptoSource() rand.Source64 {
+	return cryptoSource{}
+}
+
+type cryptoSource struct{}
+
+func (cryptoSource) Seed(int64) {}
+
+func (s cryptoSource) Int63() int64 {
+	return int64(s.Uint64() & (1<<63 - 1))
+}
+
+func (cryptoSource) Uint64() uint64 {
+	var b [8]byte
+	if _, err := crand.Read(b[:]); err != nil {
+		panic(err)
+	}
+	return binary.BigEndian.Uint64(b[:])
+}
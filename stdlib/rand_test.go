@@ -0,0 +1,103 @@
+package stdlib_test
+
+import (
+	"math"
+	"math/rand"
+	"regexp"
+	"testing"
+
+	"github.com/thingsme/thingscript/object"
+	"github.com/thingsme/thingscript/stdlib"
+)
+
+func withRandSource(src rand.Source) func(env *object.Environment) {
+	return func(env *object.Environment) { env.RandSource = src }
+}
+
+func TestRandReproducible(t *testing.T) {
+	input := `
+		rand := import("rand")
+		[rand.int(), rand.int(10), rand.int(-5, 5), rand.float(), rand.choice(["a", "b", "c"]), rand.shuffle([1, 2, 3, 4]), rand.uuid()]
+	`
+	first := stdlib.EvalWith(input, withRandSource(rand.NewSource(42)))
+	second := stdlib.EvalWith(input, withRandSource(rand.NewSource(42)))
+	if first.Type() != object.ARRAY_OBJ {
+		t.Fatalf("result is not array, got=%s", first.Inspect())
+	}
+	if first.Inspect() != second.Inspect() {
+		t.Errorf("same seed should produce same values\n%s\n%s", first.Inspect(), second.Inspect())
+	}
+	other := stdlib.EvalWith(input, withRandSource(rand.NewSource(43)))
+	if first.Inspect() == other.Inspect() {
+		t.Errorf("different seed should produce different values")
+	}
+}
+
+func TestRandRange(t *testing.T) {
+	for _, src := range []rand.Source{rand.NewSource(1), nil} {
+		ret := stdlib.EvalWith(`
+			rand := import("rand")
+			[rand.int(3), rand.int(-2, 2), rand.float(), rand.shuffle([1, 2, 3]).length, rand.choice([]), rand.uuid()]
+		`, withRandSource(src))
+		arr, ok := ret.(*object.Array)
+		if !ok {
+			t.Fatalf("result is not array, got=%s", ret.Inspect())
+		}
+		if v := arr.Elements[0].(*object.Integer).Value; v < 0 || v >= 3 {
+			t.Errorf("rand.int(3) out of range, got=%d", v)
+		}
+		if v := arr.Elements[1].(*object.Integer).Value; v < -2 || v >= 2 {
+			t.Errorf("rand.int(-2, 2) out of range, got=%d", v)
+		}
+		if v := arr.Elements[2].(*object.Float).Value; v < 0 || v >= 1 {
+			t.Errorf("rand.float() out of range, got=%f", v)
+		}
+		if arr.Elements[3].Inspect() != "3" {
+			t.Errorf("shuffle should keep the elements, got=%s", arr.Elements[3].Inspect())
+		}
+		if arr.Elements[4].Type() != object.NULL_OBJ {
+			t.Errorf("choice of empty array should be nil, got=%s", arr.Elements[4].Inspect())
+		}
+		uuid := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+		if !uuid.MatchString(arr.Elements[5].Inspect()) {
+			t.Errorf("invalid uuid %s", arr.Elements[5].Inspect())
+		}
+	}
+}
+
+func TestRandWideRange(t *testing.T) {
+	ret := stdlib.EvalWith(`
+		rand := import("rand")
+		[rand.int(-9223372036854775807, 9223372036854775807), rand.int(-1, 9223372036854775807)]
+	`, withRandSource(rand.NewSource(1)))
+	arr, ok := ret.(*object.Array)
+	if !ok {
+		t.Fatalf("result is not array, got=%s", ret.Inspect())
+	}
+	if v := arr.Elements[0].(*object.Integer).Value; v == math.MaxInt64 {
+		t.Errorf("rand.int(-max, max) out of range, got=%d", v)
+	}
+	if v := arr.Elements[1].(*object.Integer).Value; v < -1 || v == math.MaxInt64 {
+		t.Errorf("rand.int(-1, max) out of range, got=%d", v)
+	}
+}
+
+func TestRandErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`import("rand").int(0)`, "invalid argument to int, 0 <= 0"},
+		{`import("rand").int(3, 3)`, "invalid arguments to int, 3 >= 3"},
+		{`import("rand").int("1")`, "argument to int must be int, got STRING"},
+		{`import("rand").int(1, 2, 3)`, "wrong number of arguments. want=0, 1 or 2 got=3"},
+		{`import("rand").choice(1)`, "argument to choice must be array, got INTEGER"},
+		{`import("rand").shuffle("abc")`, "argument to shuffle must be array, got STRING"},
+	}
+	for _, tt := range tests {
+		ret := stdlib.EvalWith(tt.input, withRandSource(rand.NewSource(1)))
+		if err, ok := ret.(*object.Error); !ok || err.Message != tt.expected {
+			t.Errorf("expected error %q, got=%v", tt.expected, ret)
+		}
+	}
+}
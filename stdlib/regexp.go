@@ -0,0 +1,358 @@
+package stdlib
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/thingsme/thingscript/eval"
+	"github.com/thingsme/thingscript/object"
+)
+
+type regexpPkg struct{}
+
+var _ object.Package = &regexpPkg{}
+var _ object.Describer = &regexpPkg{}
+
+func (rp *regexpPkg) Type() object.ObjectType { return object.PACKAGE_OBJ }
+
+func (rp *regexpPkg) Inspect() string { return "package regexp" }
+
+func (rp *regexpPkg) Name() string { return "regexp" }
+
+func (rp *regexpPkg) OnLoad(env *object.Environment) {}
+
+func (rp *regexpPkg) Member(name string) object.MemberFunc {
+	switch name {
+	case "compile":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return errWrongNumberOfArguments(1, len(args))
+			}
+			return regexpArg(args[0])
+		}
+	case "quote":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return errWrongNumberOfArguments(1, len(args))
+			}
+			s, ok := args[0].(*object.String)
+			if !ok {
+				return object.Errorf("argument to quote must be string, got %s", args[0].Type())
+			}
+			return &object.String{Value: regexp.QuoteMeta(s.Value)}
+		}
+	default:
+		// the other members are the members of Regexp with the pattern as the first argument,
+		// e.g. regexp.match("[0-9]+", s) is regexp.compile("[0-9]+").match(s)
+		memberFunc := (&RegexpObj{}).Member(name)
+		if memberFunc == nil {
+			return nil
+		}
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) == 0 {
+				return object.Errorf("missing pattern of %s", name)
+			}
+			re := regexpArg(args[0])
+			if isError(re) {
+				return re
+			}
+			return re.Member(name)(re, args[1:]...)
+		}
+	}
+}
+
+func (rp *regexpPkg) Members() []object.MemberDoc {
+	return []object.MemberDoc{
+		{Name: "compile", Signature: "compile(pattern)", Doc: "returns the compiled regexp.Regexp"},
+		{Name: "quote", Signature: "quote(s)", Doc: "escapes the metacharacters of s"},
+		{Name: "match", Signature: "match(pattern, s)", Doc: "returns true if s contains a match of pattern"},
+		{Name: "find", Signature: "find(pattern, s)", Doc: "returns the leftmost match of pattern or nil"},
+		{Name: "find_all", Signature: "find_all(pattern, s, n?)", Doc: "returns the matches of pattern, at most n"},
+		{Name: "find_submatch", Signature: "find_submatch(pattern, s)", Doc: "returns the leftmost match and its groups or nil"},
+		{Name: "find_all_submatch", Signature: "find_all_submatch(pattern, s, n?)", Doc: "returns the matches and their groups, at most n"},
+		{Name: "find_named", Signature: "find_named(pattern, s)", Doc: "returns the map of the named groups of the leftmost match or nil"},
+		{Name: "replace", Signature: "replace(pattern, s, replacement)", Doc: "replaces the matches by the template or the result of fn(match, groups)"},
+		{Name: "split", Signature: "split(pattern, s, n?)", Doc: "splits s by the matches of pattern, into at most n"},
+	}
+}
+
+const regexpCacheSize = 256
+
+var regexpCache = struct {
+	sync.Mutex
+	m map[string]*regexp.Regexp
+}{m: make(map[string]*regexp.Regexp)}
+
+// compileRegexp compiles the pattern, the compiled patterns are cached.
+func compileRegexp(pattern string) (*regexp.Regexp, error) {
+	regexpCache.Lock()
+	defer regexpCache.Unlock()
+	if re, ok := regexpCache.m[pattern]; ok {
+		return re, nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	if len(regexpCache.m) >= regexpCacheSize {
+		regexpCache.m = make(map[string]*regexp.Regexp)
+	}
+	regexpCache.m[pattern] = re
+	return re, nil
+}
+
+// regexpArg returns the Regexp of a pattern string or a Regexp.
+func regexpArg(arg object.Object) object.Object {
+	switch v := arg.(type) {
+	case *RegexpObj:
+		return v
+	case *object.String:
+		re, err := compileRegexp(v.Value)
+		if err != nil {
+			return object.Errorf("%s", err)
+		}
+		return &RegexpObj{re: re}
+	default:
+		return object.Errorf("pattern must be string or regexp.Regexp, got %s", arg.Type())
+	}
+}
+
+type RegexpObj struct {
+	re *regexp.Regexp
+}
+
+var _ object.Object = &RegexpObj{}
+var _ object.Describer = &RegexpObj{}
+
+func (ro *RegexpObj) Type() object.ObjectType {
+	return "regexp.Regexp"
+}
+
+func (ro *RegexpObj) Inspect() string {
+	return fmt.Sprintf("regexp.Regexp(%s)", ro.re.String())
+}
+
+func (ro *RegexpObj) Member(name string) object.MemberFunc {
+	switch name {
+	case "pattern":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 0 {
+				return errWrongNumberOfArguments(0, len(args))
+			}
+			return &object.String{Value: receiver.(*RegexpObj).re.String()}
+		}
+	case "match":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			re := receiver.(*RegexpObj).re
+			s, errObj := regexpStringArg(name, args, 1)
+			if errObj != nil {
+				return errObj
+			}
+			return &object.Boolean{Value: re.MatchString(s)}
+		}
+	case "find":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			re := receiver.(*RegexpObj).re
+			s, errObj := regexpStringArg(name, args, 1)
+			if errObj != nil {
+				return errObj
+			}
+			loc := re.FindStringIndex(s)
+			if loc == nil {
+				return eval.NULL
+			}
+			return &object.String{Value: s[loc[0]:loc[1]]}
+		}
+	case "find_all":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			re := receiver.(*RegexpObj).re
+			s, errObj := regexpStringArg(name, args, 1, 2)
+			if errObj != nil {
+				return errObj
+			}
+			n, errObj := regexpCountArg(name, args, 1)
+			if errObj != nil {
+				return errObj
+			}
+			matches := re.FindAllString(s, n)
+			elements := make([]object.Object, len(matches))
+			for i, m := range matches {
+				elements[i] = &object.String{Value: m}
+			}
+			return &object.Array{Elements: elements}
+		}
+	case "find_submatch":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			re := receiver.(*RegexpObj).re
+			s, errObj := regexpStringArg(name, args, 1)
+			if errObj != nil {
+				return errObj
+			}
+			loc := re.FindStringSubmatchIndex(s)
+			if loc == nil {
+				return eval.NULL
+			}
+			return submatches(s, loc)
+		}
+	case "find_all_submatch":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			re := receiver.(*RegexpObj).re
+			s, errObj := regexpStringArg(name, args, 1, 2)
+			if errObj != nil {
+				return errObj
+			}
+			n, errObj := regexpCountArg(name, args, 1)
+			if errObj != nil {
+				return errObj
+			}
+			locs := re.FindAllStringSubmatchIndex(s, n)
+			elements := make([]object.Object, len(locs))
+			for i, loc := range locs {
+				elements[i] = submatches(s, loc)
+			}
+			return &object.Array{Elements: elements}
+		}
+	case "find_named":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			re := receiver.(*RegexpObj).re
+			s, errObj := regexpStringArg(name, args, 1)
+			if errObj != nil {
+				return errObj
+			}
+			loc := re.FindStringSubmatchIndex(s)
+			if loc == nil {
+				return eval.NULL
+			}
+			groups := submatches(s, loc).Elements
+			values := map[string]object.Object{}
+			for i, n := range re.SubexpNames() {
+				if n != "" {
+					values[n] = groups[i]
+				}
+			}
+			return newHashMap(values)
+		}
+	case "replace":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			re := receiver.(*RegexpObj).re
+			if len(args) != 2 {
+				return errWrongNumberOfArguments(2, len(args))
+			}
+			s, ok := args[0].(*object.String)
+			if !ok {
+				return object.Errorf("argument to replace must be string, got %s", args[0].Type())
+			}
+			switch repl := args[1].(type) {
+			case *object.String:
+				return &object.String{Value: re.ReplaceAllString(s.Value, repl.Value)}
+			case *object.Function, *object.Builtin, *object.BoundMethod:
+				return replaceFunc(re, s.Value, repl)
+			default:
+				return object.Errorf("replacement must be string or function, got %s", args[1].Type())
+			}
+		}
+	case "split":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			re := receiver.(*RegexpObj).re
+			s, errObj := regexpStringArg(name, args, 1, 2)
+			if errObj != nil {
+				return errObj
+			}
+			n, errObj := regexpCountArg(name, args, 1)
+			if errObj != nil {
+				return errObj
+			}
+			parts := re.Split(s, n)
+			elements := make([]object.Object, len(parts))
+			for i, p := range parts {
+				elements[i] = &object.String{Value: p}
+			}
+			return &object.Array{Elements: elements}
+		}
+	default:
+		return nil
+	}
+}
+
+func (ro *RegexpObj) Members() []object.MemberDoc {
+	return []object.MemberDoc{
+		{Name: "pattern", Signature: "pattern()", Doc: "returns the source of the regexp"},
+		{Name: "match", Signature: "match(s)", Doc: "returns true if s contains a match"},
+		{Name: "find", Signature: "find(s)", Doc: "returns the leftmost match or nil"},
+		{Name: "find_all", Signature: "find_all(s, n?)", Doc: "returns the matches, at most n"},
+		{Name: "find_submatch", Signature: "find_submatch(s)", Doc: "returns the leftmost match and its groups or nil"},
+		{Name: "find_all_submatch", Signature: "find_all_submatch(s, n?)", Doc: "returns the matches and their groups, at most n"},
+		{Name: "find_named", Signature: "find_named(s)", Doc: "returns the map of the named groups of the leftmost match or nil"},
+		{Name: "replace", Signature: "replace(s, replacement)", Doc: "replaces the matches by the template or the result of fn(match, groups)"},
+		{Name: "split", Signature: "split(s, n?)", Doc: "splits s by the matches, into at most n"},
+	}
+}
+
+// regexpStringArg checks the number of args and returns the first argument which must be a string.
+func regexpStringArg(name string, args []object.Object, nargs ...int) (string, *object.Error) {
+	valid := false
+	for _, n := range nargs {
+		valid = valid || len(args) == n
+	}
+	if !valid {
+		if len(nargs) == 1 {
+			return "", errWrongNumberOfArguments(nargs[0], len(args))
+		}
+		return "", object.Errorf("wrong number of arguments. want=%d or %d got=%d", nargs[0], nargs[1], len(args))
+	}
+	s, ok := args[0].(*object.String)
+	if !ok {
+		return "", object.Errorf("argument to %s must be string, got %s", name, args[0].Type())
+	}
+	return s.Value, nil
+}
+
+// regexpCountArg returns the optional maximum count at args[idx], -1 means all.
+func regexpCountArg(name string, args []object.Object, idx int) (int, *object.Error) {
+	if len(args) <= idx {
+		return -1, nil
+	}
+	n, ok := args[idx].(*object.Integer)
+	if !ok {
+		return 0, object.Errorf("count of %s must be int, got %s", name, args[idx].Type())
+	}
+	return int(n.Value), nil
+}
+
+// submatches returns the match and the groups at loc, unmatched groups are nil.
+func submatches(s string, loc []int) *object.Array {
+	elements := make([]object.Object, len(loc)/2)
+	for i := range elements {
+		if loc[2*i] < 0 {
+			elements[i] = eval.NULL
+		} else {
+			elements[i] = &object.String{Value: s[loc[2*i]:loc[2*i+1]]}
+		}
+	}
+	return &object.Array{Elements: elements}
+}
+
+// replaceFunc replaces the matches with the results of fn(match, submatches),
+// the first error of fn stops the replacement.
+func replaceFunc(re *regexp.Regexp, s string, fn object.Object) object.Object {
+	var out strings.Builder
+	last := 0
+	for _, loc := range re.FindAllStringSubmatchIndex(s, -1) {
+		groups := submatches(s, loc)
+		v := callFunction(fn, groups.Elements[0], groups)
+		if isError(v) {
+			return v
+		}
+		str, ok := v.(*object.String)
+		if !ok {
+			return object.Errorf("replacement function must return string, got %s", v.Type())
+		}
+		out.WriteString(s[last:loc[0]])
+		out.WriteString(str.Value)
+		last = loc[1]
+	}
+	out.WriteString(s[last:])
+	return &object.String{Value: out.String()}
+}
@@ -0,0 +1,71 @@
+package stdlib
+
+import (
+	"testing"
+
+	"github.com/thingsme/thingscript/object"
+)
+
+func TestRegexp(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected any
+	}{
+		{`import("regexp").match("^[a-z]+[0-9]$", "abc1")`, true},
+		{`import("regexp").match("^[a-z]+[0-9]$", "abc")`, false},
+		{`import("regexp").find("[0-9]+", "ab 12 cd 345")`, "12"},
+		{`import("regexp").find("[0-9]+", "abcd") ?? "none"`, "none"},
+		{`import("regexp").find_all("[0-9]+", "ab 12 cd 345 ef 6").length`, 3},
+		{`import("regexp").find_all("[0-9]+", "ab 12 cd 345 ef 6", 2)[1]`, "345"},
+		{`import("regexp").find_submatch("([a-z]+)=([0-9]+)", "x key=42")[2]`, "42"},
+		{`import("regexp").find_submatch("(a)|(b)", "b")[1] ?? "unmatched"`, "unmatched"},
+		{`import("regexp").find_all_submatch("([a-z])([0-9])", "a1 b2 c3")[2][1]`, "c"},
+		{`import("regexp").find_named("(?P<key>[a-z]+)=(?P<value>[0-9]+)", "temp=21")["value"]`, "21"},
+		{`import("regexp").replace("[0-9]+", "a1 b22", "<$0>")`, "a<1> b<22>"},
+		{`import("regexp").replace("([a-z])([0-9])", "a1 b2", "${2}${1}")`, "1a 2b"},
+		{`import("regexp").replace("\b[a-z]", "hello world", func(m){ m + m })`, "hhello wworld"},
+		{`import("regexp").replace("([a-z]+)-([0-9]+)", "ab-1 cd-2", func(m, g){ g[2] + g[1] })`, "1ab 2cd"},
+		{`import("regexp").split("[,;] *", "a, b;c")`, nil},
+		{`import("regexp").split("[,;] *", "a, b;c").length`, 3},
+		{`import("regexp").split(",", "a,b,c", 2)[1]`, "b,c"},
+		{`import("regexp").quote("1.5+2")`, `1\.5\+2`},
+		{`re := import("regexp").compile("[0-9]+")
+			[re.match("a1"), re.find("a12b"), re.pattern]`, nil},
+		{`re := import("regexp").compile("[0-9]+")
+			re.pattern`, "[0-9]+"},
+		{`re := import("regexp").compile("[a-z]")
+			import("regexp").find(re, "12b3")`, "b"},
+		{`import("regexp").compile("[a-z")`, &object.Error{Message: "error parsing regexp: missing closing ]: `[a-z`"}},
+		{`import("regexp").match(1, "a")`, &object.Error{Message: "pattern must be string or regexp.Regexp, got INTEGER"}},
+		{`import("regexp").match()`, &object.Error{Message: "missing pattern of match"}},
+		{`import("regexp").match("a", 1)`, &object.Error{Message: "argument to match must be string, got INTEGER"}},
+		{`import("regexp").find_all("a", "a", "1")`, &object.Error{Message: "count of find_all must be int, got STRING"}},
+		{`import("regexp").find_all("a")`, &object.Error{Message: "wrong number of arguments. want=1 or 2 got=0"}},
+		{`import("regexp").replace("a", "abc", 1)`, &object.Error{Message: "replacement must be string or function, got INTEGER"}},
+		{`import("regexp").replace("a", "abc", func(m){ 1 })`, &object.Error{Message: "replacement function must return string, got INTEGER"}},
+		{`import("regexp").unknown`, &object.Error{Message: `function "unknown" not found in "PACKAGE"`}},
+	}
+	for _, tt := range tests {
+		if tt.expected == nil {
+			if ret := testEval(tt.input); ret.Type() != object.ARRAY_OBJ {
+				t.Errorf("expected array, got=%s <= %s", ret.Inspect(), tt.input)
+			}
+			continue
+		}
+		runTest(t, tt.input, tt.expected)
+	}
+}
+
+func TestRegexpCache(t *testing.T) {
+	a, _ := compileRegexp("[0-9]+")
+	b, _ := compileRegexp("[0-9]+")
+	if a != b {
+		t.Errorf("compiled pattern should be cached")
+	}
+	for i := 0; i < regexpCacheSize+1; i++ {
+		compileRegexp(string(rune('a'+i%26)) + string(rune('0'+i/26)))
+	}
+	if len(regexpCache.m) > regexpCacheSize {
+		t.Errorf("cache should be limited, got=%d", len(regexpCache.m))
+	}
+}
@@ -1,6 +1,8 @@
 package stdlib
 
 import (
+	"context"
+
 	"github.com/thingsme/thingscript/eval"
 	"github.com/thingsme/thingscript/object"
 )
@@ -10,22 +12,51 @@ func Packages() []object.Package {
 		&primitives{},
 		&fmtPkg{},
 		&timePkg{},
+		&randPkg{},
+		&fsPkg{},
+		&httpPkg{},
+		&regexpPkg{},
+		&encodingPkg{},
+		&cryptoPkg{},
+		&csvPkg{},
+		&templatePkg{},
+		&logPkg{},
+		&osPkg{},
+		&syncPkg{},
+		&kvPkg{},
 	}
 }
 
+// AssertPackage returns the assert package, which is not in Packages
+// since the test runner registers it to the environments of the test files.
+func AssertPackage() object.Package {
+	return &assertPkg{}
+}
+
 func init() {
 	object.StringMemberFunc = Strings
+	object.BytesMemberFunc = Bytes
 	object.IntegerMemberFunc = Integers
 	object.FloatMemberFunc = Floats
 	object.BooleanMemberFunc = Booleans
 	object.ArrayMemberFunc = Arrays
 	object.HashMapMemberFunc = HashMaps
+
+	object.StringMemberDocs = StringMembers
+	object.BytesMemberDocs = BytesMembers
+	object.IntegerMemberDocs = IntegerMembers
+	object.FloatMemberDocs = FloatMembers
+	object.BooleanMemberDocs = BooleanMembers
+	object.ArrayMemberDocs = ArrayMembers
+	object.HashMapMemberDocs = HashMapMembers
 }
 
 type primitives struct {
+	env *object.Environment
 }
 
 var _ object.Package = &primitives{}
+var _ object.Describer = &primitives{}
 
 func (tp *primitives) Type() object.ObjectType { return object.PACKAGE_OBJ }
 
@@ -33,7 +64,9 @@ func (tp *primitives) Inspect() string { return "package <primitives>" }
 
 func (p *primitives) Name() string { return "" }
 
-func (p *primitives) OnLoad(env *object.Environment) {}
+func (p *primitives) OnLoad(env *object.Environment) {
+	p.env = env
+}
 
 func (p *primitives) Member(name string) object.MemberFunc {
 	switch name {
@@ -65,10 +98,16 @@ func (p *primitives) Member(name string) object.MemberFunc {
 				switch v := args[0].(type) {
 				case *object.String:
 					return &object.String{Value: v.Value}
+				case *object.Bytes:
+					return &object.String{Value: string(v.Value)}
 				}
 			}
 			return &object.String{Value: ""}
 		}
+	case "bytes":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			return newBytes(args...)
+		}
 	case "bool":
 		return func(receiver object.Object, args ...object.Object) object.Object {
 			if len(args) == 1 {
@@ -86,10 +125,80 @@ func (p *primitives) Member(name string) object.MemberFunc {
 			}
 			return &object.Array{Elements: []object.Object{}}
 		}
+	case "go":
+		// go(fn, args...) calls fn in a goroutine,
+		// the returned channel receives the result of fn or the error and then it is closed.
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) == 0 {
+				return object.Errorf("wrong number of arguments. got=%d, want >= 1", len(args))
+			}
+			switch args[0].(type) {
+			case *object.Function, *object.Builtin, *object.BoundMethod:
+			default:
+				return object.Errorf("not a function: %s", args[0].Type())
+			}
+			ctx := context.Background
+			if p.env != nil {
+				ctx = p.env.Ctx
+			}
+			ch := newChannel(1, ctx)
+			fn, fnArgs := args[0], args[1:]
+			go func() {
+				ch.send(eval.Apply(fn, fnArgs...))
+				ch.close()
+			}()
+			return ch
+		}
+	case "dir":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return errWrongNumberOfArguments(1, len(args))
+			}
+			names := Dir(args[0])
+			elements := make([]object.Object, len(names))
+			for i, name := range names {
+				elements[i] = &object.String{Value: name}
+			}
+			return &object.Array{Elements: elements}
+		}
+	case "help":
+		// help(value, member)
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 1 && len(args) != 2 {
+				return object.Errorf("wrong number of arguments. want=1 or 2 got=%d", len(args))
+			}
+			member := ""
+			if len(args) == 2 {
+				s, ok := args[1].(*object.String)
+				if !ok {
+					return object.Errorf("member of help must be string, got %s", args[1].Type())
+				}
+				member = s.Value
+			}
+			text, err := Help(args[0], member)
+			if err != nil {
+				return object.Errorf("%s", err)
+			}
+			return &object.String{Value: text}
+		}
 	}
 	return nil
 }
 
+func (p *primitives) Members() []object.MemberDoc {
+	return []object.MemberDoc{
+		{Name: "int", Signature: "int(value)", Doc: "converts an int to int, the others to 0"},
+		{Name: "float", Signature: "float(value)", Doc: "converts an int or a float to float, the others to 0.0"},
+		{Name: "string", Signature: "string(value)", Doc: "converts a string or bytes to string, the others to \"\""},
+		{Name: "bytes", Signature: "bytes(value?)", Doc: "returns bytes of a string, bytes or an array of ints"},
+		{Name: "bool", Signature: "bool(value)", Doc: "converts a bool to bool, the others to false"},
+		{Name: "array", Signature: "array(elements...)", Doc: "returns an array of the elements"},
+		{Name: "go", Signature: "go(fn, args...)", Doc: "calls fn with args in a goroutine, the returned sync.Channel receives the result"},
+		{Name: "dir", Signature: "dir(value)", Doc: "returns the sorted names of the members of a package or an object"},
+		{Name: "help", Signature: "help(value, member?)", Doc: "returns the description of a package, an object or its member"},
+	}
+}
+
 func errWrongNumberOfArguments(want int, got int) *object.Error {
 	return object.Errorf("wrong number of arguments. want=%d got=%d", want, got)
 }
@@ -176,6 +285,12 @@ func Integers(member string) object.MemberFunc {
 				default:
 					return errTypeMismatched(receiver, member, args[0])
 				}
+			case *DurationObj:
+				// multiplication is commutative, int * Duration is Duration * int
+				if member == "*" {
+					return right.Member(member)(right, left)
+				}
+				return errTypeMismatched(receiver, member, args[0])
 			default:
 				return errTypeMismatched(receiver, member, args[0])
 			}
@@ -184,6 +299,12 @@ func Integers(member string) object.MemberFunc {
 	return nil
 }
 
+func IntegerMembers() []object.MemberDoc {
+	return []object.MemberDoc{
+		{Name: "type", Signature: "type()", Doc: "returns \"int\""},
+	}
+}
+
 func Floats(member string) object.MemberFunc {
 	switch member {
 	case "type":
@@ -208,6 +329,12 @@ func Floats(member string) object.MemberFunc {
 				rightValue = float64(rv.Value)
 			case *object.Float:
 				rightValue = rv.Value
+			case *DurationObj:
+				// multiplication is commutative, float * Duration is Duration * float
+				if member == "*" {
+					return rv.Member(member)(rv, left)
+				}
+				return errTypeMismatched(receiver, member, args[0])
 			default:
 				return errTypeMismatched(receiver, member, args[0])
 			}
@@ -243,6 +370,12 @@ func Floats(member string) object.MemberFunc {
 	return nil
 }
 
+func FloatMembers() []object.MemberDoc {
+	return []object.MemberDoc{
+		{Name: "type", Signature: "type()", Doc: "returns \"float\""},
+	}
+}
+
 func Booleans(member string) object.MemberFunc {
 	switch member {
 	case "type":
@@ -282,6 +415,12 @@ func Booleans(member string) object.MemberFunc {
 	}
 }
 
+func BooleanMembers() []object.MemberDoc {
+	return []object.MemberDoc{
+		{Name: "type", Signature: "type()", Doc: "returns \"bool\""},
+	}
+}
+
 func Strings(member string) object.MemberFunc {
 	switch member {
 	case "type":
@@ -334,11 +473,27 @@ func Strings(member string) object.MemberFunc {
 			str := receiver.(*object.String)
 			return &object.Integer{Value: int64(len(str.Value))}
 		}
+	case "bytes":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 0 {
+				return errWrongNumberOfArguments(0, len(args))
+			}
+			str := receiver.(*object.String)
+			return &object.Bytes{Value: []byte(str.Value)}
+		}
 	default:
 		return nil
 	}
 }
 
+func StringMembers() []object.MemberDoc {
+	return []object.MemberDoc{
+		{Name: "type", Signature: "type()", Doc: "returns \"string\""},
+		{Name: "length", Signature: "length()", Doc: "returns the number of the bytes"},
+		{Name: "bytes", Signature: "bytes()", Doc: "returns the bytes of the string"},
+	}
+}
+
 func HashMaps(member string) object.MemberFunc {
 	switch member {
 	case "type":
@@ -357,6 +512,7 @@ func HashMaps(member string) object.MemberFunc {
 				switch rv := args[0].(type) {
 				case *object.HashMap:
 					lv.Pairs = rv.Pairs
+					lv.Keys = rv.Keys
 					return lv
 				}
 			}
@@ -386,11 +542,37 @@ func HashMaps(member string) object.MemberFunc {
 			h := receiver.(*object.HashMap)
 			return &object.Integer{Value: int64(len(h.Pairs))}
 		}
+	case "keys", "values":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 0 {
+				return errWrongNumberOfArguments(0, len(args))
+			}
+			h := receiver.(*object.HashMap)
+			pairs := h.OrderedPairs()
+			elements := make([]object.Object, len(pairs))
+			for i, pair := range pairs {
+				if member == "keys" {
+					elements[i] = pair.Key
+				} else {
+					elements[i] = pair.Value
+				}
+			}
+			return &object.Array{Elements: elements}
+		}
 	default:
 		return nil
 	}
 }
 
+func HashMapMembers() []object.MemberDoc {
+	return []object.MemberDoc{
+		{Name: "type", Signature: "type()", Doc: "returns \"map\""},
+		{Name: "length", Signature: "length()", Doc: "returns the number of the pairs"},
+		{Name: "keys", Signature: "keys()", Doc: "returns the keys in the insertion order if the map keeps it"},
+		{Name: "values", Signature: "values()", Doc: "returns the values in the order of keys()"},
+	}
+}
+
 func Arrays(member string) object.MemberFunc {
 	switch member {
 	case "type":
@@ -493,22 +675,13 @@ func Arrays(member string) object.MemberFunc {
 			if len(args) != 1 {
 				return errWrongNumberOfArguments(1, len(args))
 			}
-			fn := args[0].(*object.Function)
-			if len(fn.Parameters) != 2 {
-				return errWrongNumberOfArguments(2, len(args))
-			}
 			arr := receiver.(*object.Array)
 			for i, elm := range arr.Elements {
-				env := object.NewEnclosedEnvironment(fn.Env)
-				env.Set(fn.Parameters[0].Value, &object.Integer{Value: int64(i)})
-				env.Set(fn.Parameters[1].Value, elm)
-				ret := eval.Eval(fn.Body, env)
-				if ret != nil {
-					if ret.Type() == object.ERROR_OBJ {
-						return ret
-					} else if ret.Type() == object.BREAK_OBJ {
-						break
-					}
+				ret := callFunction(args[0], &object.Integer{Value: int64(i)}, elm)
+				if ret.Type() == object.ERROR_OBJ {
+					return ret
+				} else if ret.Type() == object.BREAK_OBJ {
+					break
 				}
 			}
 			return nil
@@ -526,6 +699,37 @@ func Arrays(member string) object.MemberFunc {
 			return &object.Array{Elements: newElements}
 		}
 	default:
-		return nil
+		return arrayCollections(member)
+	}
+}
+
+func ArrayMembers() []object.MemberDoc {
+	return []object.MemberDoc{
+		{Name: "type", Signature: "type()", Doc: "returns \"array\""},
+		{Name: "length", Signature: "length()", Doc: "returns the number of the elements"},
+		{Name: "head", Signature: "head()", Doc: "returns the first element"},
+		{Name: "tail", Signature: "tail()", Doc: "returns the elements except the first"},
+		{Name: "init", Signature: "init()", Doc: "returns the elements except the last"},
+		{Name: "last", Signature: "last()", Doc: "returns the last element"},
+		{Name: "foreach", Signature: "foreach(fn)", Doc: "calls fn(idx, elm) for the elements until fn returns break"},
+		{Name: "push", Signature: "push(elm)", Doc: "returns a new array appending elm"},
+		{Name: "map", Signature: "map(fn)", Doc: "returns the results of fn(elm, idx)"},
+		{Name: "filter", Signature: "filter(fn)", Doc: "returns the elements for which fn(elm, idx) is truthy"},
+		{Name: "reduce", Signature: "reduce(fn, initial?)", Doc: "folds the elements by fn(acc, elm), starting from initial or the first element"},
+		{Name: "find", Signature: "find(fn)", Doc: "returns the first element for which fn(elm, idx) is truthy, or nil"},
+		{Name: "find_index", Signature: "find_index(fn)", Doc: "returns the index of the first element for which fn(elm, idx) is truthy, or -1"},
+		{Name: "any", Signature: "any(fn?)", Doc: "returns true if fn(elm, idx), or the element, is truthy for any element"},
+		{Name: "all", Signature: "all(fn?)", Doc: "returns true if fn(elm, idx), or the element, is truthy for all the elements"},
+		{Name: "sort", Signature: "sort(less?)", Doc: "returns the elements sorted stably by less(a, b) or in ascending order"},
+		{Name: "reverse", Signature: "reverse()", Doc: "returns the elements in reverse order"},
+		{Name: "slice", Signature: "slice(start, end?)", Doc: "returns the elements from start to end, the negative indexes count from the end"},
+		{Name: "concat", Signature: "concat(arrays...)", Doc: "returns a new array appending the elements of arrays"},
+		{Name: "contains", Signature: "contains(value)", Doc: "returns true if an element equals value"},
+		{Name: "index_of", Signature: "index_of(value)", Doc: "returns the index of the first element equal to value, or -1"},
+		{Name: "unique", Signature: "unique()", Doc: "returns the elements without the duplicates"},
+		{Name: "flatten", Signature: "flatten(depth?)", Doc: "returns the elements flattening the nested arrays up to depth, which defaults to 1"},
+		{Name: "zip", Signature: "zip(arrays...)", Doc: "returns the tuples of the elements of the array and arrays, as long as the shortest"},
+		{Name: "group_by", Signature: "group_by(fn)", Doc: "returns the map of the elements grouped by fn(elm, idx)"},
+		{Name: "chunk", Signature: "chunk(size)", Doc: "returns the elements split into the arrays of size"},
 	}
 }
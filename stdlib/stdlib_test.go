@@ -2,7 +2,13 @@ package stdlib
 
 import (
 	"fmt"
+	goast "go/ast"
+	goparser "go/parser"
+	"go/token"
+	"path/filepath"
+	"reflect"
 	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/thingsme/thingscript/eval"
@@ -23,6 +29,25 @@ func testEval(input string) object.Object {
 	return eval.Eval(program, env)
 }
 
+// evalWith is testEval in the environment prepared by setup before the packages are registered.
+func evalWith(input string, setup func(env *object.Environment)) object.Object {
+	l := lexer.New(input)
+	p := parser.New(l)
+	program := p.ParseProgram()
+	for _, err := range p.Errors() {
+		fmt.Println("Parse Error:", err)
+	}
+	env := object.NewEnvironment()
+	if setup != nil {
+		setup(env)
+	}
+	env.RegisterPackages(Packages()...)
+	return eval.Eval(program, env)
+}
+
+// EvalWith is evalWith for the tests of package stdlib_test.
+var EvalWith = evalWith
+
 func runTest(t *testing.T, input string, expected any) {
 	t.Helper()
 	l := lexer.New(input)
@@ -329,6 +354,8 @@ func TestFunctions(t *testing.T) {
 		{`sum := 0; [1,2,3].foreach(func(idx,elm){ sum += elm}); sum`, 6},
 		{`sum := ""; ["1","2","3"].foreach(func(idx,elm){ sum += elm}); sum`, "123"},
 		{`ret := true; [true, true, false].foreach(func(idx,elm){ ret = elm }); ret`, false},
+		{`sum := 0; [1,2,3].foreach(func(idx){ sum += idx }); sum`, 3},
+		{`sum := 0; [1,2,3].foreach(func(idx, elm){ if idx == 2 { break }; sum += elm }); sum`, 3},
 		{`func arr(){return [1,2,3]}; arr().head()`, 1},
 		{`func arr(){return [1,2,3]}; arr().head`, 1},
 		{`func arr(){return [1,2,3]}; arr().last()`, 3},
@@ -431,3 +458,139 @@ func checkIntegerArray(t *testing.T, obj object.Object, expectArr []int64) {
 		}
 	}
 }
+
+func TestHashMapKeys(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected any
+	}{
+		{`{"a": 1}.keys()[0]`, "a"},
+		{`{"a": 1, "b": 2}.keys().length`, 2},
+		{`{"a": 1}.values()`, []int64{1}},
+		{`{"a": 1, "b": 2}.values().sort()`, []int64{1, 2}},
+		{`{}.keys()`, []int64{}},
+		{`{"a": 1}.keys(1)`, &object.Error{Message: "wrong number of arguments. want=0 got=1"}},
+	}
+	for _, tt := range tests {
+		runTest(t, tt.input, tt.expected)
+	}
+}
+
+func TestPackageMembers(t *testing.T) {
+	cases := memberCases(t)
+	env := object.NewEnvironment()
+	env.RegisterPackages(Packages()...)
+	for _, pkg := range append(Packages(), AssertPackage()) {
+		pkg.OnLoad(env)
+		d, ok := pkg.(object.Describer)
+		if !ok {
+			t.Errorf("package %q does not describe its members", pkg.Name())
+			continue
+		}
+		documented := make(map[string]bool)
+		for _, m := range d.Members() {
+			documented[m.Name] = true
+			if pkg.Member(m.Name) == nil {
+				t.Errorf("member %q of package %q is not found", m.Name, pkg.Name())
+			}
+			if m.Doc == "" {
+				t.Errorf("member %q of package %q has no doc", m.Name, pkg.Name())
+			}
+		}
+		for _, name := range cases[reflect.TypeOf(pkg).Elem().Name()] {
+			if !documented[name] {
+				t.Errorf("member %q of package %q is not documented", name, pkg.Name())
+			}
+		}
+	}
+}
+
+// memberCases returns the member names in the cases of the switches in the Member and MemberAt methods
+// of the package source by the names of their receiver types, so that the members without docs are found.
+func memberCases(t *testing.T) map[string][]string {
+	t.Helper()
+	fset := token.NewFileSet()
+	files, err := filepath.Glob("*.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := make(map[string][]string)
+	for _, file := range files {
+		if strings.HasSuffix(file, "_test.go") {
+			continue
+		}
+		f, err := goparser.ParseFile(fset, file, nil, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, decl := range f.Decls {
+			fn, ok := decl.(*goast.FuncDecl)
+			if !ok || fn.Recv == nil || (fn.Name.Name != "Member" && fn.Name.Name != "MemberAt") {
+				continue
+			}
+			recv := fn.Recv.List[0].Type
+			if star, ok := recv.(*goast.StarExpr); ok {
+				recv = star.X
+			}
+			param := fn.Type.Params.List[0].Names[0].Name
+			for _, stmt := range fn.Body.List {
+				sw, ok := stmt.(*goast.SwitchStmt)
+				if !ok {
+					continue
+				}
+				if tag, ok := sw.Tag.(*goast.Ident); !ok || tag.Name != param {
+					continue
+				}
+				for _, c := range sw.Body.List {
+					for _, e := range c.(*goast.CaseClause).List {
+						if lit, ok := e.(*goast.BasicLit); ok {
+							name, _ := strconv.Unquote(lit.Value)
+							if !token.IsIdentifier(name) {
+								// the operators like "==" are not described
+								continue
+							}
+							cases[recv.(*goast.Ident).Name] = append(cases[recv.(*goast.Ident).Name], name)
+						}
+					}
+				}
+			}
+		}
+	}
+	return cases
+}
+
+func TestObjectMembers(t *testing.T) {
+	cases := memberCases(t)
+	objects := []object.Object{
+		&object.Integer{}, &object.Float{}, &object.Boolean{}, &object.String{}, &object.Bytes{},
+		&object.Array{}, &object.HashMap{},
+		&TimeObj{}, &DurationObj{}, &RegexpObj{}, &HTTPResponse{}, &CSVReader{}, &Template{},
+		&Logger{}, &Channel{}, &WaitGroup{}, &Mutex{},
+		&base64Obj{}, &hexObj{}, &urlObj{}, &binaryObj{},
+	}
+	for _, obj := range objects {
+		d, ok := obj.(object.Describer)
+		if !ok {
+			t.Errorf("%s does not describe its members", obj.Type())
+			continue
+		}
+		if len(d.Members()) == 0 {
+			t.Errorf("%s has no members", obj.Type())
+		}
+		documented := make(map[string]bool)
+		for _, m := range d.Members() {
+			documented[m.Name] = true
+			if obj.Member(m.Name) == nil {
+				t.Errorf("member %q of %s is not found", m.Name, obj.Type())
+			}
+			if m.Doc == "" {
+				t.Errorf("member %q of %s has no doc", m.Name, obj.Type())
+			}
+		}
+		for _, name := range cases[reflect.TypeOf(obj).Elem().Name()] {
+			if !documented[name] {
+				t.Errorf("member %q of %s is not documented", name, obj.Type())
+			}
+		}
+	}
+}
@@ -0,0 +1,470 @@
+package stdlib
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/thingsme/thingscript/eval"
+	"github.com/thingsme/thingscript/object"
+)
+
+// syncPkg provides the channels, the wait groups and the mutexes for the functions run by go().
+// The blocking operations are interrupted when the evaluation is stopped by the context.
+type syncPkg struct {
+	ctx func() context.Context
+}
+
+var _ object.Package = &syncPkg{}
+var _ object.Describer = &syncPkg{}
+
+func (sp *syncPkg) Type() object.ObjectType { return object.PACKAGE_OBJ }
+
+func (sp *syncPkg) Inspect() string { return "package sync" }
+
+func (sp *syncPkg) Name() string { return "sync" }
+
+func (sp *syncPkg) OnLoad(env *object.Environment) {
+	sp.ctx = env.Ctx
+}
+
+func (sp *syncPkg) Member(name string) object.MemberFunc {
+	switch name {
+	case "channel":
+		// channel(size)
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) > 1 {
+				return object.Errorf("wrong number of arguments. want=0 or 1 got=%d", len(args))
+			}
+			size := int64(0)
+			if len(args) == 1 {
+				n, ok := args[0].(*object.Integer)
+				if !ok {
+					return object.Errorf("size of channel must be int, got %s", args[0].Type())
+				}
+				if n.Value < 0 {
+					return object.Errorf("size of channel must not be negative, got %d", n.Value)
+				}
+				size = n.Value
+			}
+			return newChannel(int(size), sp.ctx)
+		}
+	case "wait_group":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 0 {
+				return errWrongNumberOfArguments(0, len(args))
+			}
+			return &WaitGroup{ctx: sp.ctx}
+		}
+	case "mutex":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 0 {
+				return errWrongNumberOfArguments(0, len(args))
+			}
+			return &Mutex{ch: make(chan struct{}, 1), ctx: sp.ctx}
+		}
+	case "select":
+		// select(cases, timeout)
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 1 && len(args) != 2 {
+				return object.Errorf("wrong number of arguments. want=1 or 2 got=%d", len(args))
+			}
+			return sp.selectCases(args[0], args[1:])
+		}
+	default:
+		return nil
+	}
+}
+
+func (sp *syncPkg) Members() []object.MemberDoc {
+	return []object.MemberDoc{
+		{Name: "channel", Signature: "channel(size?)", Doc: "returns a sync.Channel, buffered if size is given"},
+		{Name: "wait_group", Signature: "wait_group()", Doc: "returns a sync.WaitGroup"},
+		{Name: "mutex", Signature: "mutex()", Doc: "returns a sync.Mutex"},
+		{Name: "select", Signature: "select(cases, timeout?)", Doc: "receives from a channel or sends [channel, value], returns [index, value] or [-1, nil] on timeout"},
+	}
+}
+
+// selectCases waits until one of the cases can proceed and returns [index, value].
+// A case is a channel to receive from or an array [channel, value] to send the value.
+// The index is -1 if the timeout expired, a zero timeout makes select non-blocking.
+func (sp *syncPkg) selectCases(arg object.Object, timeout []object.Object) (ret object.Object) {
+	arr, ok := arg.(*object.Array)
+	if !ok {
+		return object.Errorf("cases of select must be array, got %s", arg.Type())
+	}
+	cases := make([]reflect.SelectCase, 0, len(arr.Elements)+2)
+	for i, e := range arr.Elements {
+		switch c := e.(type) {
+		case *Channel:
+			cases = append(cases, reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(c.ch)})
+		case *object.Array:
+			var ch *Channel
+			if len(c.Elements) == 2 {
+				ch, _ = c.Elements[0].(*Channel)
+			}
+			if ch == nil {
+				return object.Errorf("case %d of select must be channel or [channel, value], got %s", i, e.Inspect())
+			}
+			cases = append(cases, reflect.SelectCase{Dir: reflect.SelectSend, Chan: reflect.ValueOf(ch.ch), Send: reflect.ValueOf(&c.Elements[1]).Elem()})
+		default:
+			return object.Errorf("case %d of select must be channel or [channel, value], got %s", i, e.Type())
+		}
+	}
+	n := len(arr.Elements)
+	ctx := sp.ctx()
+	cases = append(cases, reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ctx.Done())})
+	if len(timeout) > 0 {
+		d, err := durationArg("timeout", timeout[0])
+		if err != nil {
+			return object.Errorf("%s", err)
+		}
+		if d <= 0 {
+			cases = append(cases, reflect.SelectCase{Dir: reflect.SelectDefault})
+		} else {
+			timer := time.NewTimer(d)
+			defer timer.Stop()
+			cases = append(cases, reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(timer.C)})
+		}
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			ret = object.Errorf("send on closed channel")
+		}
+	}()
+	chosen, recv, recvOK := reflect.Select(cases)
+	switch {
+	case chosen == n:
+		return object.Errorf("evaluation stopped: %s", ctx.Err())
+	case chosen > n:
+		return &object.Array{Elements: []object.Object{&object.Integer{Value: -1}, eval.NULL}}
+	}
+	var value object.Object = eval.NULL
+	if recvOK {
+		value = recv.Interface().(object.Object)
+	}
+	return &object.Array{Elements: []object.Object{&object.Integer{Value: int64(chosen)}, value}}
+}
+
+// Channel passes the values between the functions run by go().
+type Channel struct {
+	ch     chan object.Object
+	ctx    func() context.Context
+	mu     sync.Mutex
+	closed bool
+}
+
+var _ object.Object = &Channel{}
+var _ object.Describer = &Channel{}
+
+func newChannel(size int, ctx func() context.Context) *Channel {
+	return &Channel{ch: make(chan object.Object, size), ctx: ctx}
+}
+
+func (c *Channel) Type() object.ObjectType { return "sync.Channel" }
+
+func (c *Channel) Inspect() string { return fmt.Sprintf("sync.Channel(%d/%d)", len(c.ch), cap(c.ch)) }
+
+func (c *Channel) Member(name string) object.MemberFunc {
+	switch name {
+	case "send":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return errWrongNumberOfArguments(1, len(args))
+			}
+			if errObj := c.send(args[0]); errObj != nil {
+				return errObj
+			}
+			return nil
+		}
+	case "receive":
+		// receive() returns nil once the channel is closed and drained
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 0 {
+				return errWrongNumberOfArguments(0, len(args))
+			}
+			v, _, errObj := c.receive()
+			if errObj != nil {
+				return errObj
+			}
+			return v
+		}
+	case "each":
+		// each(fn(value)) receives the values until the channel is closed or fn returns false
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return errWrongNumberOfArguments(1, len(args))
+			}
+			count := int64(0)
+			for {
+				v, ok, errObj := c.receive()
+				if errObj != nil {
+					return errObj
+				}
+				if !ok {
+					break
+				}
+				count++
+				ret := callFunction(args[0], v)
+				if isError(ret) {
+					return ret
+				}
+				if b, ok := ret.(*object.Boolean); ok && !b.Value {
+					break
+				}
+			}
+			return &object.Integer{Value: count}
+		}
+	case "close":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 0 {
+				return errWrongNumberOfArguments(0, len(args))
+			}
+			if errObj := c.close(); errObj != nil {
+				return errObj
+			}
+			return nil
+		}
+	case "length":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			return &object.Integer{Value: int64(len(c.ch))}
+		}
+	case "cap":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			return &object.Integer{Value: int64(cap(c.ch))}
+		}
+	default:
+		return nil
+	}
+}
+
+func (c *Channel) Members() []object.MemberDoc {
+	return []object.MemberDoc{
+		{Name: "send", Signature: "send(value)", Doc: "sends value, blocking while the channel is full"},
+		{Name: "receive", Signature: "receive()", Doc: "receives a value, it returns nil once the channel is closed and drained"},
+		{Name: "each", Signature: "each(fn)", Doc: "calls fn(value) for the received values until the channel is closed or fn returns false"},
+		{Name: "close", Signature: "close()", Doc: "closes the channel"},
+		{Name: "length", Signature: "length()", Doc: "returns the number of the buffered values"},
+		{Name: "cap", Signature: "cap()", Doc: "returns the size of the buffer"},
+	}
+}
+
+func (c *Channel) send(v object.Object) (ret *object.Error) {
+	defer func() {
+		if r := recover(); r != nil {
+			ret = object.Errorf("send on closed channel")
+		}
+	}()
+	ctx := c.ctx()
+	select {
+	case c.ch <- v:
+		return nil
+	case <-ctx.Done():
+		return object.Errorf("evaluation stopped: %s", ctx.Err())
+	}
+}
+
+func (c *Channel) close() *object.Error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return object.Errorf("close of closed channel")
+	}
+	c.closed = true
+	close(c.ch)
+	return nil
+}
+
+func (c *Channel) receive() (object.Object, bool, *object.Error) {
+	ctx := c.ctx()
+	select {
+	case v, ok := <-c.ch:
+		if !ok {
+			return eval.NULL, false, nil
+		}
+		return v, true, nil
+	case <-ctx.Done():
+		return nil, false, object.Errorf("evaluation stopped: %s", ctx.Err())
+	}
+}
+
+// WaitGroup waits for the functions run by go() to finish.
+type WaitGroup struct {
+	mu sync.Mutex
+	n  int64
+	// zero is closed when n returns to zero, it is nil while n is zero.
+	zero chan struct{}
+	ctx  func() context.Context
+}
+
+var _ object.Object = &WaitGroup{}
+var _ object.Describer = &WaitGroup{}
+
+func (w *WaitGroup) Type() object.ObjectType { return "sync.WaitGroup" }
+
+func (w *WaitGroup) Inspect() string { return "sync.WaitGroup" }
+
+func (w *WaitGroup) Member(name string) object.MemberFunc {
+	switch name {
+	case "add":
+		// add(delta)
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) > 1 {
+				return object.Errorf("wrong number of arguments. want=0 or 1 got=%d", len(args))
+			}
+			delta := int64(1)
+			if len(args) == 1 {
+				n, ok := args[0].(*object.Integer)
+				if !ok {
+					return object.Errorf("argument to add must be int, got %s", args[0].Type())
+				}
+				delta = n.Value
+			}
+			return w.add(delta)
+		}
+	case "done":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 0 {
+				return errWrongNumberOfArguments(0, len(args))
+			}
+			return w.add(-1)
+		}
+	case "wait":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 0 {
+				return errWrongNumberOfArguments(0, len(args))
+			}
+			w.mu.Lock()
+			zero := w.zero
+			w.mu.Unlock()
+			if zero == nil {
+				return nil
+			}
+			ctx := w.ctx()
+			select {
+			case <-zero:
+				return nil
+			case <-ctx.Done():
+				return object.Errorf("evaluation stopped: %s", ctx.Err())
+			}
+		}
+	default:
+		return nil
+	}
+}
+
+func (w *WaitGroup) Members() []object.MemberDoc {
+	return []object.MemberDoc{
+		{Name: "add", Signature: "add(delta?)", Doc: "adds delta, which defaults to 1, to the counter"},
+		{Name: "done", Signature: "done()", Doc: "decrements the counter"},
+		{Name: "wait", Signature: "wait()", Doc: "blocks until the counter is zero"},
+	}
+}
+
+func (w *WaitGroup) add(delta int64) object.Object {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.n+delta < 0 {
+		return object.Errorf("negative wait group counter")
+	}
+	if w.n == 0 && delta > 0 {
+		w.zero = make(chan struct{})
+	}
+	w.n += delta
+	if w.n == 0 && w.zero != nil {
+		close(w.zero)
+		w.zero = nil
+	}
+	return nil
+}
+
+// Mutex guards the values shared by the functions run by go().
+type Mutex struct {
+	ch  chan struct{}
+	ctx func() context.Context
+}
+
+var _ object.Object = &Mutex{}
+var _ object.Describer = &Mutex{}
+
+func (m *Mutex) Type() object.ObjectType { return "sync.Mutex" }
+
+func (m *Mutex) Inspect() string { return "sync.Mutex" }
+
+func (m *Mutex) Member(name string) object.MemberFunc {
+	switch name {
+	case "lock":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 0 {
+				return errWrongNumberOfArguments(0, len(args))
+			}
+			if errObj := m.lock(); errObj != nil {
+				return errObj
+			}
+			return nil
+		}
+	case "try_lock":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 0 {
+				return errWrongNumberOfArguments(0, len(args))
+			}
+			select {
+			case m.ch <- struct{}{}:
+				return &object.Boolean{Value: true}
+			default:
+				return &object.Boolean{Value: false}
+			}
+		}
+	case "unlock":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 0 {
+				return errWrongNumberOfArguments(0, len(args))
+			}
+			return m.unlock()
+		}
+	case "with":
+		// with(fn) calls fn holding the lock and returns the result of fn
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return errWrongNumberOfArguments(1, len(args))
+			}
+			if errObj := m.lock(); errObj != nil {
+				return errObj
+			}
+			defer m.unlock()
+			return eval.Apply(args[0])
+		}
+	default:
+		return nil
+	}
+}
+
+func (m *Mutex) Members() []object.MemberDoc {
+	return []object.MemberDoc{
+		{Name: "lock", Signature: "lock()", Doc: "locks the mutex"},
+		{Name: "try_lock", Signature: "try_lock()", Doc: "locks the mutex if it is not locked and returns true if locked"},
+		{Name: "unlock", Signature: "unlock()", Doc: "unlocks the mutex"},
+		{Name: "with", Signature: "with(fn)", Doc: "calls fn holding the lock and returns the result of fn"},
+	}
+}
+
+func (m *Mutex) lock() *object.Error {
+	ctx := m.ctx()
+	select {
+	case m.ch <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return object.Errorf("evaluation stopped: %s", ctx.Err())
+	}
+}
+
+func (m *Mutex) unlock() object.Object {
+	select {
+	case <-m.ch:
+		return nil
+	default:
+		return object.Errorf("unlock of unlocked mutex")
+	}
+}
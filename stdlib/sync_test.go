@@ -0,0 +1,163 @@
+package stdlib
+
+import (
+	"context"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/thingsme/thingscript/eval"
+	"github.com/thingsme/thingscript/lexer"
+	"github.com/thingsme/thingscript/object"
+	"github.com/thingsme/thingscript/parser"
+)
+
+func TestSync(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected any
+	}{
+		{`go(func(a, b){ a + b }, 1, 2).receive()`, 3},
+		{`ch := go(func(){ 1 }); ch.receive(); ch.receive() ?? 0`, 0},
+		{`[1, 2, 3].map(func(x){ go(func(n){ n * 10 }, x) }).map(func(ch){ ch.receive() })`, []int64{10, 20, 30}},
+		{`go(func(){ 1 + "a" }).receive()`, &object.Error{Message: "type mismatch: INTEGER + STRING"}},
+		{`go(func(a){ a }).receive()`, &object.Error{Message: "wrong number of arguments. want=1 got=0"}},
+		{`go(1)`, &object.Error{Message: "not a function: INTEGER"}},
+		{`go()`, &object.Error{Message: "wrong number of arguments. got=0, want >= 1"}},
+		{`sync := import("sync")
+			ch := sync.channel(2)
+			ch.send(1)
+			ch.send(2)
+			[ch.length(), ch.cap(), ch.receive(), ch.receive()]`, []int64{2, 2, 1, 2}},
+		{`sync := import("sync")
+			ch := sync.channel()
+			go(func(){ [1, 2, 3].foreach(func(idx, x){ ch.send(x) }); ch.close() })
+			sum := 0
+			n := ch.each(func(v){ sum += v })
+			[n, sum]`, []int64{3, 6}},
+		{`sync := import("sync")
+			ch := sync.channel(3)
+			ch.send(1); ch.send(2); ch.send(3)
+			ch.each(func(v){ v < 2 })`, 2},
+		{`sync := import("sync")
+			ch := sync.channel(1)
+			ch.close()
+			ch.send(1)`, &object.Error{Message: "send on closed channel"}},
+		{`sync := import("sync")
+			ch := sync.channel(1)
+			ch.close()
+			ch.close()`, &object.Error{Message: "close of closed channel"}},
+		{`sync := import("sync")
+			a := sync.channel(1)
+			b := sync.channel(1)
+			b.send("b")
+			sync.select([a, b])`, `[1, b]`},
+		{`sync := import("sync")
+			a := sync.channel(1)
+			sync.select([a, [a, "x"]])`, `[1, null]`},
+		{`sync := import("sync")
+			a := sync.channel()
+			sync.select([a], "0s")`, `[-1, null]`},
+		{`sync := import("sync")
+			a := sync.channel()
+			sync.select([a], "5ms")`, `[-1, null]`},
+		{`sync := import("sync")
+			a := sync.channel()
+			a.close()
+			sync.select([a])`, `[0, null]`},
+		{`sync := import("sync")
+			a := sync.channel()
+			a.close()
+			sync.select([[a, 1]])`, &object.Error{Message: "send on closed channel"}},
+		{`import("sync").select([1])`, &object.Error{Message: "case 0 of select must be channel or [channel, value], got INTEGER"}},
+		{`import("sync").select([[1]])`, &object.Error{Message: "case 0 of select must be channel or [channel, value], got [1]"}},
+		{`import("sync").select([], 1)`, &object.Error{Message: "timeout must be time.Duration or string, got INTEGER"}},
+		{`import("sync").channel(-1)`, &object.Error{Message: "size of channel must not be negative, got -1"}},
+		{`sync := import("sync")
+			mu := sync.mutex()
+			wg := sync.wait_group()
+			count := 0
+			i := 0
+			while (i < 50) {
+				wg.add()
+				go(func(){
+					mu.lock()
+					count = count + 1
+					mu.unlock()
+					wg.done()
+				})
+				i = i + 1
+			}
+			wg.wait()
+			count`, 50},
+		{`sync := import("sync")
+			mu := sync.mutex()
+			wg := sync.wait_group()
+			wg.add(10)
+			total := 0
+			[1, 2, 3, 4, 5, 6, 7, 8, 9, 10].foreach(func(idx, x){
+				go(func(){
+					mu.with(func(){ total = total + x })
+					wg.done()
+				})
+			})
+			wg.wait()
+			total`, 55},
+		{`mu := import("sync").mutex(); [mu.try_lock(), mu.try_lock()]`, `[true, false]`},
+		{`mu := import("sync").mutex(); mu.with(func(){ 1 }); mu.try_lock()`, true},
+		{`import("sync").mutex().unlock()`, &object.Error{Message: "unlock of unlocked mutex"}},
+		{`import("sync").wait_group().done()`, &object.Error{Message: "negative wait group counter"}},
+		{`import("sync").wait_group().wait()`, nil},
+	}
+	for _, tt := range tests {
+		switch expected := tt.expected.(type) {
+		case nil:
+			if ret := testEval(tt.input); ret != nil {
+				t.Errorf("expected nil, got=%v <= %s", ret, tt.input)
+			}
+		case string:
+			if ret := testEval(tt.input); ret == nil || ret.Inspect() != expected {
+				t.Errorf("expected %s, got=%v <= %s", expected, ret, tt.input)
+			}
+		default:
+			runTest(t, tt.input, tt.expected)
+		}
+	}
+}
+
+func TestWaitGroupStopped(t *testing.T) {
+	before := runtime.NumGoroutine()
+	for i := 0; i < 10; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+		env := object.NewEnvironment()
+		env.Context = ctx
+		env.RegisterPackages(Packages()...)
+		eval.Eval(parser.New(lexer.New(`wg := import("sync").wait_group(); wg.add(); wg.wait()`)).ParseProgram(), env)
+		cancel()
+	}
+	if after := runtime.NumGoroutine(); after > before {
+		t.Errorf("stopped waits should not leave goroutines, %d -> %d", before, after)
+	}
+}
+
+func TestSyncContext(t *testing.T) {
+	tests := []string{
+		`import("sync").channel().receive()`,
+		`import("sync").channel().send(1)`,
+		`import("sync").select([import("sync").channel()])`,
+		`wg := import("sync").wait_group(); wg.add(); wg.wait()`,
+		`mu := import("sync").mutex(); mu.lock(); mu.lock()`,
+	}
+	for _, input := range tests {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		program := parser.New(lexer.New(input)).ParseProgram()
+		env := object.NewEnvironment()
+		env.Context = ctx
+		env.RegisterPackages(Packages()...)
+		ret := eval.Eval(program, env)
+		cancel()
+		if err, ok := ret.(*object.Error); !ok || err.Message != "evaluation stopped: context deadline exceeded" {
+			t.Errorf("expected evaluation stopped, got=%v <= %s", ret, input)
+		}
+	}
+}
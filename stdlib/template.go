@@ -0,0 +1,227 @@
+package stdlib
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"text/template"
+	"unicode"
+
+	"github.com/thingsme/thingscript/object"
+)
+
+// templatePkg compiles text/template templates,
+// the data of the templates are converted into Go values by object.ToNative.
+type templatePkg struct {
+	env *object.Environment
+}
+
+var _ object.Package = &templatePkg{}
+var _ object.Describer = &templatePkg{}
+
+func (tp *templatePkg) Type() object.ObjectType { return object.PACKAGE_OBJ }
+
+func (tp *templatePkg) Inspect() string { return "package template" }
+
+func (tp *templatePkg) Name() string { return "template" }
+
+func (tp *templatePkg) OnLoad(env *object.Environment) {
+	tp.env = env
+}
+
+func (tp *templatePkg) Member(name string) object.MemberFunc {
+	switch name {
+	case "parse":
+		// parse(text, options)
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 1 && len(args) != 2 {
+				return object.Errorf("wrong number of arguments. want=1 or 2 got=%d", len(args))
+			}
+			text, ok := args[0].(*object.String)
+			if !ok {
+				return object.Errorf("argument to parse must be string, got %s", args[0].Type())
+			}
+			return parseTemplate("template", text.Value, args[1:])
+		}
+	case "parse_file":
+		// parse_file(path, options)
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 1 && len(args) != 2 {
+				return object.Errorf("wrong number of arguments. want=1 or 2 got=%d", len(args))
+			}
+			fp, p, errObj := fsPathArg(tp.env, name, args[0])
+			if errObj != nil {
+				return errObj
+			}
+			b, err := fp.readFile(p)
+			if err != nil {
+				return object.Errorf("%s", err)
+			}
+			return parseTemplate(p, string(b), args[1:])
+		}
+	case "render":
+		// render(text, data, options)
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 2 && len(args) != 3 {
+				return object.Errorf("wrong number of arguments. want=2 or 3 got=%d", len(args))
+			}
+			text, ok := args[0].(*object.String)
+			if !ok {
+				return object.Errorf("argument to render must be string, got %s", args[0].Type())
+			}
+			ret := parseTemplate("template", text.Value, args[2:])
+			tmpl, ok := ret.(*Template)
+			if !ok {
+				return ret
+			}
+			return tmpl.render(args[1])
+		}
+	default:
+		return nil
+	}
+}
+
+func (tp *templatePkg) Members() []object.MemberDoc {
+	return []object.MemberDoc{
+		{Name: "parse", Signature: "parse(text, options?)", Doc: "returns the template.Template of text, the options are funcs, delims and missing_key"},
+		{Name: "parse_file", Signature: "parse_file(path, options?)", Doc: "returns the template.Template of the file"},
+		{Name: "render", Signature: "render(text, data, options?)", Doc: "renders the template of text with data"},
+	}
+}
+
+var templateMissingKeys = map[string]bool{"default": true, "zero": true, "error": true}
+
+func parseTemplate(name string, text string, opts []object.Object) object.Object {
+	tmpl := template.New(name)
+	if len(opts) > 0 {
+		hm, ok := opts[0].(*object.HashMap)
+		if !ok {
+			return object.Errorf("options must be map, got %s", opts[0].Type())
+		}
+		for _, pair := range hm.Pairs {
+			key := pair.Key.Inspect()
+			switch key {
+			case "funcs":
+				funcs, ok := pair.Value.(*object.HashMap)
+				if !ok {
+					return object.Errorf("option %s must be map, got %s", key, pair.Value.Type())
+				}
+				funcMap := template.FuncMap{}
+				for _, f := range funcs.Pairs {
+					fname := f.Key.Inspect()
+					if !isTemplateFuncName(fname) {
+						return object.Errorf("invalid function name %q", fname)
+					}
+					funcMap[fname] = templateFunc(f.Value)
+				}
+				tmpl.Funcs(funcMap)
+			case "delims":
+				arr, ok := pair.Value.(*object.Array)
+				if !ok || len(arr.Elements) != 2 {
+					return object.Errorf("option %s must be array of left and right delimiters, got %s", key, pair.Value.Inspect())
+				}
+				tmpl.Delims(arr.Elements[0].Inspect(), arr.Elements[1].Inspect())
+			case "missing_key":
+				s, ok := pair.Value.(*object.String)
+				if !ok || !templateMissingKeys[s.Value] {
+					return object.Errorf("option %s must be \"default\", \"zero\" or \"error\", got %s", key, pair.Value.Inspect())
+				}
+				tmpl.Option("missingkey=" + s.Value)
+			default:
+				return object.Errorf("unknown option %q", key)
+			}
+		}
+	}
+	if _, err := tmpl.Parse(text); err != nil {
+		return object.Errorf("%s", err)
+	}
+	return &Template{tmpl: tmpl}
+}
+
+func isTemplateFuncName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, r := range name {
+		if r != '_' && !unicode.IsLetter(r) && (i == 0 || !unicode.IsDigit(r)) {
+			return false
+		}
+	}
+	return true
+}
+
+// templateFunc returns the Go function which calls fn from the templates.
+func templateFunc(fn object.Object) func(args ...any) (any, error) {
+	return func(args ...any) (any, error) {
+		params := make([]object.Object, len(args))
+		for i, a := range args {
+			obj, err := object.FromNative(a)
+			if err != nil {
+				return nil, err
+			}
+			params[i] = obj
+		}
+		ret := callFunction(fn, params...)
+		if errObj, ok := ret.(*object.Error); ok {
+			return nil, errors.New(errObj.Message)
+		}
+		return object.ToNative(ret)
+	}
+}
+
+// Template is a compiled template.
+type Template struct {
+	tmpl *template.Template
+}
+
+var _ object.Object = &Template{}
+var _ object.Describer = &Template{}
+
+func (t *Template) Type() object.ObjectType { return "template.Template" }
+
+func (t *Template) Inspect() string { return fmt.Sprintf("template.Template(%s)", t.tmpl.Name()) }
+
+func (t *Template) Member(name string) object.MemberFunc {
+	switch name {
+	case "name":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 0 {
+				return errWrongNumberOfArguments(0, len(args))
+			}
+			return &object.String{Value: t.tmpl.Name()}
+		}
+	case "render":
+		// render(data)
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) > 1 {
+				return object.Errorf("wrong number of arguments. want=0 or 1 got=%d", len(args))
+			}
+			var data object.Object
+			if len(args) == 1 {
+				data = args[0]
+			}
+			return t.render(data)
+		}
+	default:
+		return nil
+	}
+}
+
+func (t *Template) Members() []object.MemberDoc {
+	return []object.MemberDoc{
+		{Name: "name", Signature: "name()", Doc: "returns the name of the template"},
+		{Name: "render", Signature: "render(data?)", Doc: "returns the template executed with data"},
+	}
+}
+
+func (t *Template) render(data object.Object) object.Object {
+	v, err := object.ToNative(data)
+	if err != nil {
+		return object.Errorf("%s", err)
+	}
+	var out bytes.Buffer
+	if err := t.tmpl.Execute(&out, v); err != nil {
+		return object.Errorf("%s", err)
+	}
+	return &object.String{Value: out.String()}
+}
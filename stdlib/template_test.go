@@ -0,0 +1,72 @@
+package stdlib_test
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/thingsme/thingscript/object"
+	"github.com/thingsme/thingscript/stdlib"
+)
+
+func TestTemplate(t *testing.T) {
+	fsys := fstest.MapFS{
+		"alert.tmpl": {Data: []byte("{{.device}} is {{.temp}}{{.unit}}")},
+	}
+	tests := []struct {
+		input    string
+		expected any
+	}{
+		{`import("template").parse("Hello {{.}}").render("world")`, "Hello world"},
+		{`import("template").parse("{{.name}}: {{.n}}").render({"name": "a", "n": 1})`, "a: 1"},
+		{`import("template").parse("{{range .}}[{{.}}]{{end}}").render([1, 2.5, true])`, "[1][2.5][true]"},
+		{`import("template").parse("{{range $k, $v := .}}{{$k}}={{$v}};{{end}}").render({"b": 2, "a": 1})`, "a=1;b=2;"},
+		{`import("template").parse("{{if .ok}}yes{{else}}no{{end}}").render({"ok": false})`, "no"},
+		{`import("template").parse("{{index .items 1}}").render({"items": ["x", "y"]})`, "y"},
+		{`import("template").parse("static").render()`, "static"},
+		{`import("template").render("<<.>>", 1, {"delims": ["<<", ">>"]})`, "1"},
+		{`import("template").parse("{{.x}}").render({})`, "<no value>"},
+		{`import("template").parse("{{.x}}", {"missing_key": "zero"}).render({})`, "<no value>"},
+		{`t := import("template").parse("{{double .n}}", {"funcs": {"double": func(x){ x * 2 }}})
+			t.render({"n": 21})`, "42"},
+		{`t := import("template").parse("{{range split .s}}<{{.}}>{{end}}", {"funcs": {"split": func(s){ [s, s] }}})
+			t.render({"s": "a"})`, "<a><a>"},
+		{"t := import(\"template\").parse(\"{{printf `%.1f` (f .)}}\", {\"funcs\": {\"f\": float}})\n" +
+			"t.render(42)", "42.0"},
+		{`t := import("template").parse("{{add 1 2}}", {"funcs": {"add": func(a, b){ a + b }}})
+			t.render()`, "3"},
+		{`t := import("template").parse("{{.at.Year}}")
+			t.render({"at": import("time").unix(0)})`, "1970"},
+		{`import("template").parse_file("alert.tmpl").render({"device": "t1", "temp": 21.5, "unit": "C"})`, "t1 is 21.5C"},
+		{`import("template").parse_file("alert.tmpl").name()`, "alert.tmpl"},
+		{`import("template").parse("").name()`, "template"},
+		{`import("template").parse("{{.x}}", {"missing_key": "error"}).render({})`,
+			&object.Error{Message: `template: template:1:2: executing "template" at <.x>: map has no entry for key "x"`}},
+		{`import("template").parse("{{.x")`,
+			&object.Error{Message: `template: template:1: unclosed action`}},
+		{`import("template").parse("{{nope}}")`,
+			&object.Error{Message: `template: template:1: function "nope" not defined`}},
+		{`t := import("template").parse("{{fail}}", {"funcs": {"fail": func(){ 1 + "a" }}})
+			t.render()`,
+			&object.Error{Message: `template: template:1:2: executing "template" at <fail>: error calling fail: type mismatch: INTEGER + STRING`}},
+		{`import("template").parse("", {"funcs": {"a-b": func(){ 1 }}})`, &object.Error{Message: `invalid function name "a-b"`}},
+		{`import("template").parse("", {"missing_key": "panic"})`, &object.Error{Message: `option missing_key must be "default", "zero" or "error", got panic`}},
+		{`import("template").parse("", {"delims": "<<"})`, &object.Error{Message: `option delims must be array of left and right delimiters, got <<`}},
+		{`import("template").parse("", {"strict": true})`, &object.Error{Message: `unknown option "strict"`}},
+		{`import("template").parse("{{.}}").render(func(){ 1 })`, &object.Error{Message: "FUNCTION can not be converted into native value"}},
+		{`import("template").parse_file("none.tmpl")`, &object.Error{Message: "open none.tmpl: file does not exist"}},
+		{`import("template").parse(1)`, &object.Error{Message: "argument to parse must be string, got INTEGER"}},
+	}
+	for _, tt := range tests {
+		ret := stdlib.EvalWith(tt.input, func(env *object.Environment) { env.FS = fsys })
+		switch expected := tt.expected.(type) {
+		case string:
+			if s, ok := ret.(*object.String); !ok || s.Value != expected {
+				t.Errorf("expected %q, got=%v <= %s", expected, ret, tt.input)
+			}
+		case *object.Error:
+			if err, ok := ret.(*object.Error); !ok || err.Message != expected.Message {
+				t.Errorf("expected error %q, got=%v <= %s", expected.Message, ret, tt.input)
+			}
+		}
+	}
+}
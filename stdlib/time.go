@@ -8,10 +8,12 @@ import (
 )
 
 type timePkg struct {
-	timeProvider func() time.Time
+	timeProvider  func() time.Time
+	sleepProvider func(time.Duration)
 }
 
 var _ object.Package = &timePkg{}
+var _ object.Describer = &timePkg{}
 
 func (tp *timePkg) Type() object.ObjectType { return object.PACKAGE_OBJ }
 
@@ -25,6 +27,53 @@ func (tp *timePkg) OnLoad(env *object.Environment) {
 	} else {
 		tp.timeProvider = func() time.Time { return time.Now() }
 	}
+	if env.SleepProvider != nil {
+		tp.sleepProvider = env.SleepProvider
+	} else {
+		// the sleep is interrupted when the evaluation is stopped by the context
+		tp.sleepProvider = func(d time.Duration) {
+			timer := time.NewTimer(d)
+			defer timer.Stop()
+			select {
+			case <-timer.C:
+			case <-env.Ctx().Done():
+			}
+		}
+	}
+}
+
+var durationUnits = map[string]time.Duration{
+	"nanoseconds":  time.Nanosecond,
+	"microseconds": time.Microsecond,
+	"milliseconds": time.Millisecond,
+	"seconds":      time.Second,
+	"minutes":      time.Minute,
+	"hours":        time.Hour,
+}
+
+var timeLayouts = map[string]string{
+	"ANSIC":       time.ANSIC,
+	"UnixDate":    time.UnixDate,
+	"RFC822":      time.RFC822,
+	"RFC822Z":     time.RFC822Z,
+	"RFC850":      time.RFC850,
+	"RFC1123":     time.RFC1123,
+	"RFC1123Z":    time.RFC1123Z,
+	"RFC3339":     time.RFC3339,
+	"RFC3339Nano": time.RFC3339Nano,
+	"Kitchen":     time.Kitchen,
+	"DateTime":    "2006-01-02 15:04:05",
+	"DateOnly":    "2006-01-02",
+	"TimeOnly":    "15:04:05",
+}
+
+// timeLayout returns the Go layout of the name, e.g. "RFC3339",
+// or the name itself if it is not a known name.
+func timeLayout(name string) string {
+	if layout, ok := timeLayouts[name]; ok {
+		return layout
+	}
+	return name
 }
 
 func (tp *timePkg) Member(name string) object.MemberFunc {
@@ -47,16 +96,136 @@ func (tp *timePkg) Member(name string) object.MemberFunc {
 		return func(receiver object.Object, args ...object.Object) object.Object {
 			return &TimeObj{tm: tp.timeProvider()}
 		}
+	case "Duration":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) == 1 {
+				switch v := args[0].(type) {
+				case *DurationObj:
+					return &DurationObj{d: v.d}
+				case *object.Integer:
+					return &DurationObj{d: time.Duration(v.Value)}
+				case *object.String:
+					d, err := time.ParseDuration(v.Value)
+					if err != nil {
+						return object.Errorf("%s", err)
+					}
+					return &DurationObj{d: d}
+				default:
+					return nil
+				}
+			}
+			return &DurationObj{}
+		}
+	case "nanoseconds", "microseconds", "milliseconds", "seconds", "minutes", "hours":
+		unit := durationUnits[name]
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return errWrongNumberOfArguments(1, len(args))
+			}
+			switch v := args[0].(type) {
+			case *object.Integer:
+				return &DurationObj{d: time.Duration(v.Value) * unit}
+			case *object.Float:
+				return &DurationObj{d: time.Duration(v.Value * float64(unit))}
+			default:
+				return object.Errorf("argument to %s must be int or float, got %s", name, args[0].Type())
+			}
+		}
+	case "unix":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 1 && len(args) != 2 {
+				return object.Errorf("wrong number of arguments. want=1 or 2 got=%d", len(args))
+			}
+			values := []int64{0, 0}
+			for i, a := range args {
+				v, ok := a.(*object.Integer)
+				if !ok {
+					return object.Errorf("argument to unix must be int, got %s", a.Type())
+				}
+				values[i] = v.Value
+			}
+			return &TimeObj{tm: time.Unix(values[0], values[1])}
+		}
+	case "parse":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 1 && len(args) != 2 {
+				return object.Errorf("wrong number of arguments. want=1 or 2 got=%d", len(args))
+			}
+			strs := make([]string, len(args))
+			for i, a := range args {
+				s, ok := a.(*object.String)
+				if !ok {
+					return object.Errorf("argument to parse must be string, got %s", a.Type())
+				}
+				strs[i] = s.Value
+			}
+			layout, value := time.RFC3339, strs[0]
+			if len(strs) == 2 {
+				layout, value = timeLayout(strs[0]), strs[1]
+			}
+			tm, err := time.Parse(layout, value)
+			if err != nil {
+				return object.Errorf("%s", err)
+			}
+			return &TimeObj{tm: tm}
+		}
+	case "since", "until":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return errWrongNumberOfArguments(1, len(args))
+			}
+			t, ok := args[0].(*TimeObj)
+			if !ok {
+				return object.Errorf("argument to %s must be time.Time, got %s", name, args[0].Type())
+			}
+			if name == "since" {
+				return &DurationObj{d: tp.timeProvider().Sub(t.tm)}
+			}
+			return &DurationObj{d: t.tm.Sub(tp.timeProvider())}
+		}
+	case "sleep":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return errWrongNumberOfArguments(1, len(args))
+			}
+			d, ok := args[0].(*DurationObj)
+			if !ok {
+				return object.Errorf("argument to sleep must be time.Duration, got %s", args[0].Type())
+			}
+			tp.sleepProvider(d.d)
+			return nil
+		}
 	default:
 		return nil
 	}
 }
 
+func (tp *timePkg) Members() []object.MemberDoc {
+	return []object.MemberDoc{
+		{Name: "Time", Signature: "Time(value?)", Doc: "returns the time of a time.Time or unix seconds, the zero time without value"},
+		{Name: "Now", Signature: "Now()", Doc: "returns the current time of Environment.TimeProvider"},
+		{Name: "Duration", Signature: "Duration(value?)", Doc: "returns the duration of a time.Duration, nanoseconds or a string like \"1m30s\""},
+		{Name: "nanoseconds", Signature: "nanoseconds(n)", Doc: "returns the duration of n nanoseconds"},
+		{Name: "microseconds", Signature: "microseconds(n)", Doc: "returns the duration of n microseconds"},
+		{Name: "milliseconds", Signature: "milliseconds(n)", Doc: "returns the duration of n milliseconds"},
+		{Name: "seconds", Signature: "seconds(n)", Doc: "returns the duration of n seconds"},
+		{Name: "minutes", Signature: "minutes(n)", Doc: "returns the duration of n minutes"},
+		{Name: "hours", Signature: "hours(n)", Doc: "returns the duration of n hours"},
+		{Name: "unix", Signature: "unix(sec, nsec?)", Doc: "returns the time of the unix seconds and nanoseconds"},
+		{Name: "parse", Signature: "parse(layout?, value)", Doc: "parses value by layout, which defaults to RFC3339"},
+		{Name: "since", Signature: "since(t)", Doc: "returns the duration elapsed since t"},
+		{Name: "until", Signature: "until(t)", Doc: "returns the duration until t"},
+		{Name: "sleep", Signature: "sleep(d)", Doc: "pauses for the duration d through Environment.SleepProvider"},
+	}
+}
+
 type TimeObj struct {
 	tm time.Time
 }
 
 var _ object.Object = &TimeObj{}
+var _ object.Describer = &TimeObj{}
+var _ object.NativeValuer = &TimeObj{}
 
 func (to *TimeObj) Type() object.ObjectType {
 	return "time.Time"
@@ -66,6 +235,10 @@ func (to *TimeObj) Inspect() string {
 	return fmt.Sprintf("time.Time(%s)", to.tm)
 }
 
+func (to *TimeObj) NativeValue() any {
+	return to.tm
+}
+
 func (to *TimeObj) Member(name string) object.MemberFunc {
 	switch name {
 	case "=":
@@ -82,7 +255,328 @@ func (to *TimeObj) Member(name string) object.MemberFunc {
 			}
 			return nil
 		}
+	case "==", "!=", "<", "<=", ">", ">=", "before", "after", "equal":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return errWrongNumberOfArguments(1, len(args))
+			}
+			left := receiver.(*TimeObj)
+			right, ok := args[0].(*TimeObj)
+			if !ok {
+				return errTypeMismatched(receiver, name, args[0])
+			}
+			var ret bool
+			switch name {
+			case "==", "equal":
+				ret = left.tm.Equal(right.tm)
+			case "!=":
+				ret = !left.tm.Equal(right.tm)
+			case "<", "before":
+				ret = left.tm.Before(right.tm)
+			case "<=":
+				ret = !left.tm.After(right.tm)
+			case ">", "after":
+				ret = left.tm.After(right.tm)
+			case ">=":
+				ret = !left.tm.Before(right.tm)
+			}
+			return &object.Boolean{Value: ret}
+		}
+	case "+", "add":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return errWrongNumberOfArguments(1, len(args))
+			}
+			d, ok := args[0].(*DurationObj)
+			if !ok {
+				return errTypeMismatched(receiver, name, args[0])
+			}
+			return &TimeObj{tm: receiver.(*TimeObj).tm.Add(d.d)}
+		}
+	case "-", "sub":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return errWrongNumberOfArguments(1, len(args))
+			}
+			left := receiver.(*TimeObj)
+			switch right := args[0].(type) {
+			case *TimeObj:
+				return &DurationObj{d: left.tm.Sub(right.tm)}
+			case *DurationObj:
+				return &TimeObj{tm: left.tm.Add(-right.d)}
+			default:
+				return errTypeMismatched(receiver, name, args[0])
+			}
+		}
+	case "truncate", "round":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return errWrongNumberOfArguments(1, len(args))
+			}
+			d, ok := args[0].(*DurationObj)
+			if !ok {
+				return object.Errorf("argument to %s must be time.Duration, got %s", name, args[0].Type())
+			}
+			tm := receiver.(*TimeObj).tm
+			if name == "truncate" {
+				return &TimeObj{tm: tm.Truncate(d.d)}
+			}
+			return &TimeObj{tm: tm.Round(d.d)}
+		}
+	case "in":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return errWrongNumberOfArguments(1, len(args))
+			}
+			zone, ok := args[0].(*object.String)
+			if !ok {
+				return object.Errorf("argument to in must be string, got %s", args[0].Type())
+			}
+			loc, err := time.LoadLocation(zone.Value)
+			if err != nil {
+				return object.Errorf("%s", err)
+			}
+			return &TimeObj{tm: receiver.(*TimeObj).tm.In(loc)}
+		}
+	case "format":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) > 1 {
+				return errWrongNumberOfArguments(1, len(args))
+			}
+			layout := time.RFC3339
+			if len(args) == 1 {
+				s, ok := args[0].(*object.String)
+				if !ok {
+					return object.Errorf("argument to format must be string, got %s", args[0].Type())
+				}
+				layout = timeLayout(s.Value)
+			}
+			return &object.String{Value: receiver.(*TimeObj).tm.Format(layout)}
+		}
+	case "unix", "unix_milli", "unix_nano", "year", "month", "day",
+		"hour", "minute", "second", "nanosecond", "weekday", "yearday":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 0 {
+				return errWrongNumberOfArguments(0, len(args))
+			}
+			tm := receiver.(*TimeObj).tm
+			var v int64
+			switch name {
+			case "unix":
+				v = tm.Unix()
+			case "unix_milli":
+				v = tm.UnixMilli()
+			case "unix_nano":
+				v = tm.UnixNano()
+			case "year":
+				v = int64(tm.Year())
+			case "month":
+				v = int64(tm.Month())
+			case "day":
+				v = int64(tm.Day())
+			case "hour":
+				v = int64(tm.Hour())
+			case "minute":
+				v = int64(tm.Minute())
+			case "second":
+				v = int64(tm.Second())
+			case "nanosecond":
+				v = int64(tm.Nanosecond())
+			case "weekday":
+				v = int64(tm.Weekday())
+			case "yearday":
+				v = int64(tm.YearDay())
+			}
+			return &object.Integer{Value: v}
+		}
+	case "zone":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 0 {
+				return errWrongNumberOfArguments(0, len(args))
+			}
+			name, _ := receiver.(*TimeObj).tm.Zone()
+			return &object.String{Value: name}
+		}
 	default:
 		return nil
 	}
 }
+
+func (to *TimeObj) Members() []object.MemberDoc {
+	return []object.MemberDoc{
+		{Name: "before", Signature: "before(t)", Doc: "returns true if the time is before t"},
+		{Name: "after", Signature: "after(t)", Doc: "returns true if the time is after t"},
+		{Name: "equal", Signature: "equal(t)", Doc: "returns true if the time is the same instant as t"},
+		{Name: "add", Signature: "add(d)", Doc: "returns the time after the duration d"},
+		{Name: "sub", Signature: "sub(value)", Doc: "returns the duration since the time value, or the time before the duration value"},
+		{Name: "truncate", Signature: "truncate(d)", Doc: "returns the time rounded down to a multiple of d"},
+		{Name: "round", Signature: "round(d)", Doc: "returns the time rounded to a multiple of d"},
+		{Name: "in", Signature: "in(zone)", Doc: "returns the time in the location like \"Asia/Tokyo\""},
+		{Name: "format", Signature: "format(layout?)", Doc: "formats the time by layout, which defaults to RFC3339"},
+		{Name: "unix", Signature: "unix()", Doc: "returns the unix seconds"},
+		{Name: "unix_milli", Signature: "unix_milli()", Doc: "returns the unix milliseconds"},
+		{Name: "unix_nano", Signature: "unix_nano()", Doc: "returns the unix nanoseconds"},
+		{Name: "year", Signature: "year()", Doc: "returns the year"},
+		{Name: "month", Signature: "month()", Doc: "returns the month from 1"},
+		{Name: "day", Signature: "day()", Doc: "returns the day of the month"},
+		{Name: "hour", Signature: "hour()", Doc: "returns the hour"},
+		{Name: "minute", Signature: "minute()", Doc: "returns the minute"},
+		{Name: "second", Signature: "second()", Doc: "returns the second"},
+		{Name: "nanosecond", Signature: "nanosecond()", Doc: "returns the nanosecond within the second"},
+		{Name: "weekday", Signature: "weekday()", Doc: "returns the day of the week from 0 for Sunday"},
+		{Name: "yearday", Signature: "yearday()", Doc: "returns the day of the year from 1"},
+		{Name: "zone", Signature: "zone()", Doc: "returns the abbreviated name of the zone"},
+	}
+}
+
+type DurationObj struct {
+	d time.Duration
+}
+
+var _ object.Object = &DurationObj{}
+var _ object.Describer = &DurationObj{}
+var _ object.NativeValuer = &DurationObj{}
+
+func (do *DurationObj) Type() object.ObjectType {
+	return "time.Duration"
+}
+
+func (do *DurationObj) Inspect() string {
+	return fmt.Sprintf("time.Duration(%s)", do.d)
+}
+
+func (do *DurationObj) NativeValue() any {
+	return do.d
+}
+
+func (do *DurationObj) Member(name string) object.MemberFunc {
+	switch name {
+	case "=":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return errWrongNumberOfArguments(1, len(args))
+			}
+			if left, ok := receiver.(*DurationObj); ok {
+				switch v := args[0].(type) {
+				case *DurationObj:
+					left.d = v.d
+					return left
+				}
+			}
+			return nil
+		}
+	case "+", "-", "==", "!=", "<", "<=", ">", ">=":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return errWrongNumberOfArguments(1, len(args))
+			}
+			left := receiver.(*DurationObj)
+			right, ok := args[0].(*DurationObj)
+			if !ok {
+				if t, ok := args[0].(*TimeObj); ok && name == "+" {
+					return &TimeObj{tm: t.tm.Add(left.d)}
+				}
+				return errTypeMismatched(receiver, name, args[0])
+			}
+			switch name {
+			case "+":
+				return &DurationObj{d: left.d + right.d}
+			case "-":
+				return &DurationObj{d: left.d - right.d}
+			case "==":
+				return &object.Boolean{Value: left.d == right.d}
+			case "!=":
+				return &object.Boolean{Value: left.d != right.d}
+			case "<":
+				return &object.Boolean{Value: left.d < right.d}
+			case "<=":
+				return &object.Boolean{Value: left.d <= right.d}
+			case ">":
+				return &object.Boolean{Value: left.d > right.d}
+			default:
+				return &object.Boolean{Value: left.d >= right.d}
+			}
+		}
+	case "*", "/":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return errWrongNumberOfArguments(1, len(args))
+			}
+			left := receiver.(*DurationObj)
+			var factor float64
+			switch right := args[0].(type) {
+			case *object.Integer:
+				factor = float64(right.Value)
+			case *object.Float:
+				factor = right.Value
+			case *DurationObj:
+				if name == "*" {
+					return errTypeMismatched(receiver, name, args[0])
+				}
+				if right.d == 0 {
+					return object.Errorf("division by zero")
+				}
+				return &object.Float{Value: float64(left.d) / float64(right.d)}
+			default:
+				return errTypeMismatched(receiver, name, args[0])
+			}
+			if name == "*" {
+				return &DurationObj{d: time.Duration(float64(left.d) * factor)}
+			}
+			if factor == 0 {
+				return object.Errorf("division by zero")
+			}
+			return &DurationObj{d: time.Duration(float64(left.d) / factor)}
+		}
+	case "nanoseconds", "microseconds", "milliseconds", "seconds", "minutes", "hours":
+		unit := durationUnits[name]
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 0 {
+				return errWrongNumberOfArguments(0, len(args))
+			}
+			d := receiver.(*DurationObj).d
+			if unit <= time.Millisecond {
+				return &object.Integer{Value: int64(d / unit)}
+			}
+			return &object.Float{Value: float64(d) / float64(unit)}
+		}
+	case "string":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 0 {
+				return errWrongNumberOfArguments(0, len(args))
+			}
+			return &object.String{Value: receiver.(*DurationObj).d.String()}
+		}
+	case "truncate", "round":
+		return func(receiver object.Object, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return errWrongNumberOfArguments(1, len(args))
+			}
+			m, ok := args[0].(*DurationObj)
+			if !ok {
+				return object.Errorf("argument to %s must be time.Duration, got %s", name, args[0].Type())
+			}
+			d := receiver.(*DurationObj).d
+			if name == "truncate" {
+				return &DurationObj{d: d.Truncate(m.d)}
+			}
+			return &DurationObj{d: d.Round(m.d)}
+		}
+	default:
+		return nil
+	}
+}
+
+func (do *DurationObj) Members() []object.MemberDoc {
+	return []object.MemberDoc{
+		{Name: "nanoseconds", Signature: "nanoseconds()", Doc: "returns the duration in nanoseconds"},
+		{Name: "microseconds", Signature: "microseconds()", Doc: "returns the duration in microseconds"},
+		{Name: "milliseconds", Signature: "milliseconds()", Doc: "returns the duration in milliseconds"},
+		{Name: "seconds", Signature: "seconds()", Doc: "returns the duration in seconds"},
+		{Name: "minutes", Signature: "minutes()", Doc: "returns the duration in minutes"},
+		{Name: "hours", Signature: "hours()", Doc: "returns the duration in hours"},
+		{Name: "string", Signature: "string()", Doc: "returns the duration like \"1m30s\""},
+		{Name: "truncate", Signature: "truncate(m)", Doc: "returns the duration rounded toward zero to a multiple of m"},
+		{Name: "round", Signature: "round(m)", Doc: "returns the duration rounded to a multiple of m"},
+	}
+}
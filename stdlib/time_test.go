@@ -90,3 +90,128 @@ func TestTime(t *testing.T) {
 		}
 	}
 }
+
+func withTime(timing time.Time, sleep func(time.Duration)) func(env *object.Environment) {
+	return func(env *object.Environment) {
+		env.TimeProvider = func() time.Time { return timing }
+		env.SleepProvider = sleep
+	}
+}
+
+func TestTimeMembers(t *testing.T) {
+	timing := time.Date(2024, 2, 29, 13, 45, 30, 500, time.UTC)
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`time := import("time"); time.Now().year`, "2024"},
+		{`time := import("time"); time.Now().month`, "2"},
+		{`time := import("time"); time.Now().day`, "29"},
+		{`time := import("time"); time.Now().hour`, "13"},
+		{`time := import("time"); time.Now().minute`, "45"},
+		{`time := import("time"); time.Now().second`, "30"},
+		{`time := import("time"); time.Now().nanosecond`, "500"},
+		{`time := import("time"); time.Now().weekday`, "4"},
+		{`time := import("time"); time.Now().yearday`, "60"},
+		{`time := import("time"); time.Now().unix`, strconv.FormatInt(timing.Unix(), 10)},
+		{`time := import("time"); time.Now().unix_milli`, strconv.FormatInt(timing.UnixMilli(), 10)},
+		{`time := import("time"); time.Now().unix_nano`, strconv.FormatInt(timing.UnixNano(), 10)},
+		{`time := import("time"); time.Now().format("2006/01/02 15:04")`, "2024/02/29 13:45"},
+		{`time := import("time"); time.Now().format("DateOnly")`, "2024-02-29"},
+		{`time := import("time"); time.Now().truncate(time.hours(1)).format()`, "2024-02-29T13:00:00Z"},
+		{`time := import("time"); time.Now().round(time.minutes(1)).format("Kitchen")`, "1:46PM"},
+		{`time := import("time"); time.Now().add(time.hours(11)).format("DateOnly")`, "2024-03-01"},
+		{"time := import(\"time\")\n(time.Now() + time.hours(24)).day", "1"},
+		{`time := import("time"); time.Now().in("Asia/Seoul").hour`, "22"},
+		{`time := import("time"); time.Now().in("Asia/Seoul").zone`, "KST"},
+		{`time := import("time"); time.parse("2024-01-02T03:04:05Z").unix`, "1704164645"},
+		{`time := import("time"); time.parse("DateOnly", "2024-03-01").sub(time.Now()).hours`, "10.241667"},
+		{"time := import(\"time\")\n(time.Now() - time.minutes(45)).hour", "13"},
+		{`time := import("time"); time.since(time.unix(1709214330)).string`, "500ns"},
+		{`time := import("time"); time.until(time.unix(1709214390)).seconds`, "60.000000"},
+		{`time := import("time"); t := time.Now(); t.before(t + time.seconds(1))`, "true"},
+		{`time := import("time"); t := time.Now(); t.after(t + time.seconds(1))`, "false"},
+		{`time := import("time"); t := time.Now(); t == time.Time(t.unix_nano)`, "true"},
+		{`time := import("time"); t := time.Now(); t != t`, "false"},
+		{`time := import("time"); t := time.Now(); t < t + time.seconds(1)`, "true"},
+		{`time := import("time"); t := time.Now(); t <= t`, "true"},
+		{`time := import("time"); t := time.Now(); t > t`, "false"},
+		{`time := import("time"); t := time.Now(); t >= t`, "true"},
+		{`time := import("time"); time.Now() + 1`, "ERROR: type mismatch: time.Time + INTEGER"},
+		{`time := import("time"); time.parse("x")`, `ERROR: parsing time "x" as "2006-01-02T15:04:05Z07:00": cannot parse "x" as "2006"`},
+		{`time := import("time"); time.Now().in("Nowhere/City")`, "ERROR: unknown time zone Nowhere/City"},
+	}
+	for _, tt := range tests {
+		ret := stdlib.EvalWith(tt.input, withTime(timing, nil))
+		if ret == nil {
+			t.Errorf("result is nil <= %s", tt.input)
+			continue
+		}
+		if ret.Inspect() != tt.expected {
+			t.Errorf("expected %q, got=%q <= %s", tt.expected, ret.Inspect(), tt.input)
+		}
+	}
+}
+
+func TestDuration(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`time := import("time"); time.seconds(90)`, "time.Duration(1m30s)"},
+		{`time := import("time"); time.milliseconds(1.5)`, "time.Duration(1.5ms)"},
+		{`time := import("time"); time.Duration("1h2m")`, "time.Duration(1h2m0s)"},
+		{`time := import("time"); time.Duration(1000)`, "time.Duration(1µs)"},
+		{`time := import("time"); var d time.Duration; d`, "time.Duration(0s)"},
+		{`time := import("time"); var d time.Duration; d = time.hours(1); d.minutes`, "60.000000"},
+		{`time := import("time"); time.minutes(1).milliseconds`, "60000"},
+		{`time := import("time"); time.minutes(1) + time.seconds(1)`, "time.Duration(1m1s)"},
+		{`time := import("time"); time.minutes(1) - time.seconds(1)`, "time.Duration(59s)"},
+		{`time := import("time"); time.minutes(1) * 2`, "time.Duration(2m0s)"},
+		{`time := import("time"); time.minutes(1) / 4`, "time.Duration(15s)"},
+		{`time := import("time"); time.minutes(1) / time.seconds(20)`, "3.000000"},
+		{`time := import("time"); time.minutes(1) > time.seconds(20)`, "true"},
+		{`time := import("time"); time.minutes(1) == time.seconds(60)`, "true"},
+		{`time := import("time"); time.seconds(100).truncate(time.minutes(1))`, "time.Duration(1m0s)"},
+		{`time := import("time"); time.seconds(100).round(time.minutes(1))`, "time.Duration(2m0s)"},
+		{`time := import("time"); time.minutes(1) / 0`, "ERROR: division by zero"},
+		{`time := import("time"); time.minutes(1) / time.seconds(0)`, "ERROR: division by zero"},
+		{`time := import("time"); 3 * time.minutes(1)`, "time.Duration(3m0s)"},
+		{`time := import("time"); 1.5 * time.minutes(1)`, "time.Duration(1m30s)"},
+		{`time := import("time"); time.minutes(1) * time.minutes(1)`, "ERROR: type mismatch: time.Duration * time.Duration"},
+		{`time := import("time"); 2 / time.minutes(1)`, "ERROR: type mismatch: INTEGER / time.Duration"},
+		{`time := import("time"); time.minutes(1) + 1`, "ERROR: type mismatch: time.Duration + INTEGER"},
+		{`time := import("time"); time.seconds("1")`, "ERROR: argument to seconds must be int or float, got STRING"},
+		{`time := import("time"); time.Duration("1x")`, `ERROR: time: unknown unit "x" in duration "1x"`},
+	}
+	for _, tt := range tests {
+		ret := stdlib.EvalWith(tt.input, withTime(time.Now(), nil))
+		if ret == nil {
+			t.Errorf("result is nil <= %s", tt.input)
+			continue
+		}
+		if ret.Inspect() != tt.expected {
+			t.Errorf("expected %q, got=%q <= %s", tt.expected, ret.Inspect(), tt.input)
+		}
+	}
+}
+
+func TestSleep(t *testing.T) {
+	var slept time.Duration
+	ret := stdlib.EvalWith(`
+		time := import("time")
+		time.sleep(time.seconds(3))
+		time.sleep(time.milliseconds(500))
+	`, withTime(time.Now(), func(d time.Duration) { slept += d }))
+	if ret != nil && ret.Type() == object.ERROR_OBJ {
+		t.Fatalf("result is error; %s", ret.Inspect())
+	}
+	if slept != 3500*time.Millisecond {
+		t.Errorf("expected to sleep 3.5s, got=%s", slept)
+	}
+
+	ret = stdlib.EvalWith(`import("time").sleep(1)`, withTime(time.Now(), func(d time.Duration) {}))
+	if ret.Inspect() != "ERROR: argument to sleep must be time.Duration, got INTEGER" {
+		t.Errorf("unexpected result %s", ret.Inspect())
+	}
+}